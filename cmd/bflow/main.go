@@ -7,15 +7,21 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/rojo/hack/web_bounty_flow/pkg/app"
 	"github.com/rojo/hack/web_bounty_flow/pkg/config"
+	"github.com/rojo/hack/web_bounty_flow/pkg/configstore"
 )
 
 func main() {
 	cfgPath := flag.String("config", "flow.yaml", "path to the YAML configuration file")
+	importPath := flag.String("import", "", "import a HAR file or Burp XML export into the request corpus and exit")
+	importFormat := flag.String("import-format", "", "format of -import: har or burp (detected from content when empty)")
+	session := flag.String("session", "", "session profile (label or id) from the config store to authenticate checks with")
 	flag.Parse()
 
 	cfg, err := config.Load(*cfgPath)
@@ -24,7 +30,33 @@ func main() {
 	}
 
 	logger := log.New(os.Stdout, "[bflow] ", log.LstdFlags)
-	a := app.New(cfg, logger, nil, nil, nil)
+	var store *configstore.Store
+	if *session != "" {
+		path := os.Getenv("BFLOW_CONFIG_PATH")
+		if strings.TrimSpace(path) == "" {
+			path = filepath.Join("data", "config.json")
+		}
+		store, err = configstore.New(path)
+		if err != nil {
+			logger.Fatalf("session profiles need the config store: %v", err)
+		}
+	}
+	a := app.New(cfg, logger, nil, nil, store)
+	a.SetSessionProfile(*session)
+
+	if *importPath != "" {
+		f, err := os.Open(*importPath)
+		if err != nil {
+			logger.Fatalf("failed to open import file: %v", err)
+		}
+		summary, err := a.ImportRequests(*importFormat, f, *importPath)
+		_ = f.Close()
+		if err != nil {
+			logger.Fatalf("request import failed: %v", err)
+		}
+		fmt.Printf("imported %d of %d %s request(s); corpus now holds %d\n", summary.Imported, summary.Parsed, summary.Format, summary.Total)
+		return
+	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
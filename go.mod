@@ -2,4 +2,4 @@ module github.com/rojo/hack/web_bounty_flow
 
 go 1.23.2
 
-require gopkg.in/yaml.v3 v3.0.1 // indirect
+require gopkg.in/yaml.v3 v3.0.1
@@ -30,6 +30,8 @@ import (
 	"github.com/rojo/hack/web_bounty_flow/pkg/config"
 	"github.com/rojo/hack/web_bounty_flow/pkg/configstore"
 	"github.com/rojo/hack/web_bounty_flow/pkg/dorking"
+	"github.com/rojo/hack/web_bounty_flow/pkg/oob"
+	"github.com/rojo/hack/web_bounty_flow/pkg/payloads"
 )
 
 // StepStatus tracks a flow step state.
@@ -66,6 +68,17 @@ const (
 	StepWaybackURLs    = "waybackurls"
 	StepKatana         = "katana"
 	StepURLCorpus      = "url-corpus"
+	StepJSIntel        = "js-intel"
+	StepSourceMaps     = "source-maps"
+	StepURLTemplates   = "url-templates"
+	StepHostRoles      = "host-roles"
+	StepHostClusters   = "host-clusters"
+	StepWAFDetect      = "waf-detect"
+	StepTechProfile    = "tech-profile"
+	StepScreenshots    = "screenshots"
+	StepAPISpecs       = "api-specs"
+	StepGraphQL        = "graphql"
+	StepFormTamper     = "form-tamper"
 	StepParamFuzz      = "param-fuzz"
 	StepInjectionCheck = "injection-checks"
 	StepServerInputChk = "server-input-checks"
@@ -109,7 +122,18 @@ var flowSteps = []Step{
 	{ID: StepRobotsSitemaps, Label: "Run robots.txt and sitemap discovery in main flow."},
 	{ID: StepWaybackURLs, Label: "Integrate waybackurls into active flow."},
 	{ID: StepKatana, Label: "Integrate katana crawling into active flow."},
-	{ID: StepURLCorpus, Label: "Consolidate URL corpus from all sources."},
+	{ID: StepURLCorpus, Label: "Consolidate URL corpus from all sources and index sources, first/last seen and live status per URL."},
+	{ID: StepJSIntel, Label: "Mine JavaScript assets for endpoints, params, hosts, buckets and secrets."},
+	{ID: StepSourceMaps, Label: "Recover original sources from exposed JS source maps and mine them."},
+	{ID: StepURLTemplates, Label: "Cluster URLs into path templates and sample representatives for checks."},
+	{ID: StepHostRoles, Label: "Segment live hosts into auth/admin/uploads/static/cdn/api roles."},
+	{ID: StepHostClusters, Label: "Cluster look-alike live hosts by body simhash, favicon hash and header fingerprint."},
+	{ID: StepWAFDetect, Label: "Detect WAF/CDN vendors per host and throttle protected hosts."},
+	{ID: StepTechProfile, Label: "Score per-host technologies (version, confidence, evidence) and match CVEs."},
+	{ID: StepScreenshots, Label: "Screenshot live web servers headless and cluster look-alike pages."},
+	{ID: StepAPISpecs, Label: "Expand OpenAPI, Swagger and Postman specs into concrete requests and check declared auth."},
+	{ID: StepGraphQL, Label: "Discover and fingerprint GraphQL endpoints, recover the schema and check batching, depth, CSRF and field authz."},
+	{ID: StepFormTamper, Label: "Harvest HTML forms and replay hidden, disabled, length and pattern tampering against a baseline submission."},
 	{ID: StepParamFuzz, Label: "Fuzz query/body/header/cookie parameters with baseline diffing."},
 	{ID: StepInjectionCheck, Label: "Run baseline-diff SQLi/NoSQL/XPath/LDAP checks."},
 	{ID: StepServerInputChk, Label: "Run baseline-diff OS command/path traversal/file inclusion checks."},
@@ -141,17 +165,33 @@ func FlowSteps() []Step {
 
 // App coordinates each stage of the bounty flow.
 type App struct {
-	cfg          *config.Config
-	logger       *log.Logger
-	httpClient   *http.Client
-	logWriter    io.Writer
-	stepUpdate   func(id string, status StepStatus)
-	configStore  *configstore.Store
-	torEnabled   bool
-	proxyEnabled bool
-	proxyHost    string
-	proxyPort    int
-	resumeDone   map[string]bool
+	cfg           *config.Config
+	logger        *log.Logger
+	logWriter     io.Writer
+	stepUpdate    func(id string, status StepStatus)
+	configStore   *configstore.Store
+	torEnabled    bool
+	proxyEnabled  bool
+	proxyHost     string
+	proxyPort     int
+	resumeDone    map[string]bool
+	wafMu         sync.Mutex
+	wafStates     map[string]wafHostState
+	wafStatesMod  time.Time
+	sessionName   string
+	sessions      []*sessionProfile
+	transportMu   sync.Mutex
+	transports    map[bool]*http.Transport
+	evidenceMu    sync.Mutex
+	evidenceUsed  int64
+	evidenceSized bool
+	evidenceFull  bool
+	oobMu         sync.Mutex
+	oobTried      bool
+	oobListener   *oob.Listener
+	oobProbes     map[string]*oobProbe
+	packsMu       sync.Mutex
+	packs         *payloads.Set
 }
 
 // EgressProbe describes best-effort outbound IP detection.
@@ -169,6 +209,7 @@ type liveWebserverRecord struct {
 	WebServer     string
 	Technologies  []string
 	ContentLength int
+	Port          string
 }
 
 const (
@@ -185,7 +226,6 @@ func New(cfg *config.Config, logger *log.Logger, logWriter io.Writer, stepUpdate
 	return &App{
 		cfg:         cfg,
 		logger:      logger,
-		httpClient:  &http.Client{},
 		logWriter:   logWriter,
 		stepUpdate:  stepUpdate,
 		configStore: configStore,
@@ -207,6 +247,15 @@ func (a *App) Run(ctx context.Context) error {
 	if err := a.generateRegexWildcardsFile(); err != nil {
 		return err
 	}
+	if err := a.loadSessionProfiles(); err != nil {
+		return err
+	}
+	a.evidenceMu.Lock()
+	a.evidenceSized, a.evidenceFull = false, false
+	a.evidenceMu.Unlock()
+	a.oobMu.Lock()
+	a.oobTried = false
+	a.oobMu.Unlock()
 
 	if err := a.runStep(StepValidateInputs, func() error {
 		return a.validateReconInputs()
@@ -253,8 +302,12 @@ func (a *App) skipStep(id string) {
 	a.updateStep(id, StepSkipped)
 }
 
-// SetTorEnabled toggles routing tool/network requests through torify/proxy env.
+// SetTorEnabled toggles routing tool requests through torify and in-process requests
+// through Tor's SOCKS5 port.
 func (a *App) SetTorEnabled(enabled bool) {
+	if a.torEnabled != enabled {
+		a.resetTransports()
+	}
 	a.torEnabled = enabled
 	if enabled {
 		a.logger.Printf("network mode: tor enabled")
@@ -263,16 +316,21 @@ func (a *App) SetTorEnabled(enabled bool) {
 	}
 }
 
-// SetProxy configures optional HTTP(S) proxy routing for command execution.
+// SetProxy configures optional HTTP(S) proxy routing for command execution and the
+// in-process HTTP clients.
 func (a *App) SetProxy(enabled bool, host string, port int) {
-	a.proxyEnabled = enabled
-	a.proxyHost = strings.TrimSpace(host)
-	if a.proxyHost == "" {
-		a.proxyHost = "localhost"
+	host = strings.TrimSpace(host)
+	if host == "" {
+		host = "localhost"
 	}
 	if port <= 0 {
 		port = 8080
 	}
+	if enabled != a.proxyEnabled || host != a.proxyHost || port != a.proxyPort {
+		a.resetTransports()
+	}
+	a.proxyEnabled = enabled
+	a.proxyHost = host
 	a.proxyPort = port
 	if enabled {
 		a.logger.Printf("network mode: proxy enabled (%s:%d)", a.proxyHost, a.proxyPort)
@@ -316,7 +374,7 @@ func (a *App) ProbeNetworkEgress(ctx context.Context) EgressProbe {
 		}
 	}
 
-	client := &http.Client{Timeout: 12 * time.Second}
+	client := a.newHTTPClient(12*time.Second, true)
 	for _, endpoint := range endpoints {
 		req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 		if reqErr != nil {
@@ -382,6 +440,7 @@ func (a *App) githubDorking(ctx context.Context) error {
 		UpdateToken: func(tokenID string, used time.Time, lastErr string) {
 			_ = a.configStore.UpdateTokenUsage("github", tokenID, used, lastErr)
 		},
+		Client: a.newHTTPClient(15*time.Second, true),
 	}
 
 	return dorking.RunGithubSearch(ctx, opts)
@@ -463,14 +522,14 @@ func (a *App) prepareDirectories() error {
 
 func (a *App) passiveRecon(ctx context.Context) error {
 	for _, step := range []string{
-		StepSubdomainEnum, StepAmass, StepSublist3r, StepAssetfinder, StepGAU, StepCTL, StepSubfinder, StepChaos, StepRawOutputs, StepDNSX, StepConsolidate, StepHTTPX, StepRobotsSitemaps, StepWaybackURLs, StepKatana, StepURLCorpus, StepParamFuzz, StepInjectionCheck, StepServerInputChk, StepAdvInjection, StepCSRFChecks, StepClickjacking, StepCORSChecks, StepOpenRedirect, StepWorkflowLogic, StepSmugglingStack, StepNmapEnrich, StepNucleiScan, StepTierIsolation, StepStaticReview, StepRunOpsBundle, StepStageScorecard, StepDorkLinks, StepCeWL, StepFuzzDocs, StepFuzzDirs,
+		StepSubdomainEnum, StepAmass, StepSublist3r, StepAssetfinder, StepGAU, StepCTL, StepSubfinder, StepChaos, StepRawOutputs, StepDNSX, StepConsolidate, StepHTTPX, StepRobotsSitemaps, StepWaybackURLs, StepKatana, StepURLCorpus, StepJSIntel, StepSourceMaps, StepURLTemplates, StepHostRoles, StepHostClusters, StepWAFDetect, StepTechProfile, StepScreenshots, StepAPISpecs, StepGraphQL, StepFormTamper, StepParamFuzz, StepInjectionCheck, StepServerInputChk, StepAdvInjection, StepCSRFChecks, StepClickjacking, StepCORSChecks, StepOpenRedirect, StepWorkflowLogic, StepSmugglingStack, StepNmapEnrich, StepNucleiScan, StepTierIsolation, StepStaticReview, StepRunOpsBundle, StepStageScorecard, StepDorkLinks, StepCeWL, StepFuzzDocs, StepFuzzDirs,
 	} {
 		a.updateStep(step, StepPending)
 	}
 
 	if !fileExists(a.cfg.Lists.Wildcards) || len(readSafeLines(a.cfg.Lists.Wildcards)) == 0 {
 		for _, step := range []string{
-			StepSubdomainEnum, StepAmass, StepSublist3r, StepAssetfinder, StepGAU, StepCTL, StepSubfinder, StepChaos, StepRawOutputs, StepDNSX, StepConsolidate, StepHTTPX, StepRobotsSitemaps, StepWaybackURLs, StepKatana, StepURLCorpus, StepParamFuzz, StepInjectionCheck, StepServerInputChk, StepAdvInjection, StepCSRFChecks, StepClickjacking, StepCORSChecks, StepOpenRedirect, StepWorkflowLogic, StepSmugglingStack, StepNmapEnrich, StepNucleiScan, StepTierIsolation, StepStaticReview, StepRunOpsBundle, StepStageScorecard, StepDorkLinks, StepCeWL, StepFuzzDocs, StepFuzzDirs,
+			StepSubdomainEnum, StepAmass, StepSublist3r, StepAssetfinder, StepGAU, StepCTL, StepSubfinder, StepChaos, StepRawOutputs, StepDNSX, StepConsolidate, StepHTTPX, StepRobotsSitemaps, StepWaybackURLs, StepKatana, StepURLCorpus, StepJSIntel, StepSourceMaps, StepURLTemplates, StepHostRoles, StepHostClusters, StepWAFDetect, StepTechProfile, StepScreenshots, StepAPISpecs, StepGraphQL, StepFormTamper, StepParamFuzz, StepInjectionCheck, StepServerInputChk, StepAdvInjection, StepCSRFChecks, StepClickjacking, StepCORSChecks, StepOpenRedirect, StepWorkflowLogic, StepSmugglingStack, StepNmapEnrich, StepNucleiScan, StepTierIsolation, StepStaticReview, StepRunOpsBundle, StepStageScorecard, StepDorkLinks, StepCeWL, StepFuzzDocs, StepFuzzDirs,
 		} {
 			a.skipStep(step)
 		}
@@ -877,7 +936,7 @@ func (a *App) runSubdomainDiscovery(ctx context.Context) error {
 				}
 				req.Header.Set("Authorization", key)
 				req.Header.Set("Accept", "application/json")
-				client := &http.Client{Timeout: 15 * time.Second}
+				client := a.newHTTPClient(15*time.Second, true)
 				resp, err := client.Do(req)
 				if err != nil {
 					return nil, err
@@ -1104,8 +1163,10 @@ func (a *App) runSubdomainDiscovery(ctx context.Context) error {
 	}
 
 	if err := a.runStep(StepHTTPX, func() error {
-		_, err := a.buildHTTPDomains(ctx)
-		return err
+		if _, err := a.buildHTTPDomains(ctx); err != nil {
+			return err
+		}
+		return a.foldNmapWebServices()
 	}); err != nil {
 		return err
 	}
@@ -1135,7 +1196,76 @@ func (a *App) runSubdomainDiscovery(ctx context.Context) error {
 	}
 
 	if err := a.runStep(StepURLCorpus, func() error {
-		return a.consolidateURLCorpus()
+		return a.consolidateURLCorpus(ctx)
+	}); err != nil {
+		return err
+	}
+
+	if err := a.runStep(StepJSIntel, func() error {
+		return a.runJSIntel(ctx)
+	}); err != nil {
+		return err
+	}
+
+	if err := a.runStep(StepSourceMaps, func() error {
+		return a.runSourceMaps(ctx)
+	}); err != nil {
+		return err
+	}
+
+	if err := a.runStep(StepURLTemplates, func() error {
+		return a.runURLTemplates()
+	}); err != nil {
+		return err
+	}
+
+	if err := a.runStep(StepHostRoles, func() error {
+		return a.runHostRoleSegmentation(ctx)
+	}); err != nil {
+		return err
+	}
+
+	if err := a.runStep(StepHostClusters, func() error {
+		return a.runHostClustering(ctx)
+	}); err != nil {
+		return err
+	}
+
+	if err := a.runStep(StepWAFDetect, func() error {
+		return a.runWAFDetection(ctx)
+	}); err != nil {
+		return err
+	}
+
+	if err := a.runStep(StepTechProfile, func() error {
+		return a.runTechProfile(ctx)
+	}); err != nil {
+		return err
+	}
+
+	if _, err := ResolveNodeBinary(); err != nil {
+		a.skipStep(StepScreenshots)
+		a.logger.Printf("%s: skipped (node not found)", StepScreenshots)
+	} else if err := a.runStep(StepScreenshots, func() error {
+		return a.runScreenshots(ctx)
+	}); err != nil {
+		return err
+	}
+
+	if err := a.runStep(StepAPISpecs, func() error {
+		return a.runAPISpecs(ctx)
+	}); err != nil {
+		return err
+	}
+
+	if err := a.runStep(StepGraphQL, func() error {
+		return a.runGraphQL(ctx)
+	}); err != nil {
+		return err
+	}
+
+	if err := a.runStep(StepFormTamper, func() error {
+		return a.runFormTamper(ctx)
 	}); err != nil {
 		return err
 	}
@@ -1445,47 +1575,60 @@ type paramFuzzObservation struct {
 	DurationMS  int64
 	Location    string
 	Snippet     string
+	Body        string
+	Headers     http.Header
 	Cookies     []string
 	TokenHeader string
+	Session     string
+	Exchange    *Evidence
 }
 
 type paramFuzzHit struct {
-	Timestamp    string   `json:"timestamp"`
-	Mode         string   `json:"mode"`
-	Endpoint     string   `json:"endpoint"`
-	Method       string   `json:"method"`
-	Param        string   `json:"param"`
-	Vector       string   `json:"vector"`
-	MutatedURL   string   `json:"mutated_url"`
-	Reasons      []string `json:"reasons"`
-	BaselineCode int      `json:"baseline_status_code"`
-	MutatedCode  int      `json:"mutated_status_code"`
-	BaselineLen  int      `json:"baseline_length"`
-	MutatedLen   int      `json:"mutated_length"`
-	BaselineMS   int64    `json:"baseline_duration_ms"`
-	MutatedMS    int64    `json:"mutated_duration_ms"`
-	BaselineLoc  string   `json:"baseline_location"`
-	MutatedLoc   string   `json:"mutated_location"`
+	Timestamp      string        `json:"timestamp"`
+	Mode           string        `json:"mode"`
+	Endpoint       string        `json:"endpoint"`
+	Method         string        `json:"method"`
+	Param          string        `json:"param"`
+	Payload        string        `json:"payload,omitempty"`
+	Vector         string        `json:"vector"`
+	MutatedURL     string        `json:"mutated_url"`
+	Reasons        []string      `json:"reasons"`
+	BaselineCode   int           `json:"baseline_status_code"`
+	MutatedCode    int           `json:"mutated_status_code"`
+	BaselineLen    int           `json:"baseline_length"`
+	MutatedLen     int           `json:"mutated_length"`
+	BaselineMS     int64         `json:"baseline_duration_ms"`
+	MutatedMS      int64         `json:"mutated_duration_ms"`
+	BaselineLoc    string        `json:"baseline_location"`
+	MutatedLoc     string        `json:"mutated_location"`
+	Diff           *responseDiff `json:"diff,omitempty"`
+	EvidenceIDs    []string      `json:"evidence_ids,omitempty"`
+	SessionProfile string        `json:"session_profile,omitempty"`
 }
 
 type injectionHit struct {
-	Timestamp    string   `json:"timestamp"`
-	Family       string   `json:"family"`
-	Endpoint     string   `json:"endpoint"`
-	Method       string   `json:"method"`
-	Param        string   `json:"param"`
-	Payload      string   `json:"payload"`
-	Vector       string   `json:"vector"`
-	MutatedURL   string   `json:"mutated_url"`
-	Reasons      []string `json:"reasons"`
-	BaselineCode int      `json:"baseline_status_code"`
-	MutatedCode  int      `json:"mutated_status_code"`
-	BaselineLen  int      `json:"baseline_length"`
-	MutatedLen   int      `json:"mutated_length"`
-	BaselineMS   int64    `json:"baseline_duration_ms"`
-	MutatedMS    int64    `json:"mutated_duration_ms"`
-	BaselineLoc  string   `json:"baseline_location"`
-	MutatedLoc   string   `json:"mutated_location"`
+	Timestamp      string          `json:"timestamp"`
+	Family         string          `json:"family"`
+	Endpoint       string          `json:"endpoint"`
+	Method         string          `json:"method"`
+	Param          string          `json:"param"`
+	Payload        string          `json:"payload"`
+	Vector         string          `json:"vector"`
+	MutatedURL     string          `json:"mutated_url"`
+	Reasons        []string        `json:"reasons"`
+	BaselineCode   int             `json:"baseline_status_code"`
+	MutatedCode    int             `json:"mutated_status_code"`
+	BaselineLen    int             `json:"baseline_length"`
+	MutatedLen     int             `json:"mutated_length"`
+	BaselineMS     int64           `json:"baseline_duration_ms"`
+	MutatedMS      int64           `json:"mutated_duration_ms"`
+	BaselineLoc    string          `json:"baseline_location"`
+	MutatedLoc     string          `json:"mutated_location"`
+	Transforms     []string        `json:"transforms,omitempty"`
+	Diff           *responseDiff   `json:"diff,omitempty"`
+	Timing         *timingEvidence `json:"timing,omitempty"`
+	EvidenceIDs    []string        `json:"evidence_ids,omitempty"`
+	SessionProfile string          `json:"session_profile,omitempty"`
 }
 
 type csrfCandidate struct {
@@ -1520,6 +1663,7 @@ type csrfFinding struct {
 	BaselineLen     int      `json:"baseline_length"`
 	CrossOriginLen  int      `json:"cross_origin_length"`
 	MissingOrigLen  int      `json:"missing_origin_length"`
+	EvidenceIDs     []string `json:"evidence_ids,omitempty"`
 }
 
 type clickjackingHeaderRecord struct {
@@ -1539,6 +1683,7 @@ type clickjackingFinding struct {
 	XFrameOptions  string   `json:"x_frame_options"`
 	FrameAncestors string   `json:"frame_ancestors"`
 	ManualAction   string   `json:"manual_action"`
+	EvidenceIDs    []string `json:"evidence_ids,omitempty"`
 }
 
 type corsReplayLog struct {
@@ -1562,6 +1707,7 @@ type corsFinding struct {
 	AllowOrigin      string   `json:"access_control_allow_origin"`
 	AllowCredentials string   `json:"access_control_allow_credentials"`
 	ManualAction     string   `json:"manual_action"`
+	EvidenceIDs      []string `json:"evidence_ids,omitempty"`
 }
 
 type openRedirectCandidate struct {
@@ -1580,13 +1726,12 @@ type openRedirectFinding struct {
 	StatusCode   int      `json:"status_code"`
 	ChainSignals []string `json:"chain_signals"`
 	ManualAction string   `json:"manual_action"`
+	EvidenceIDs  []string `json:"evidence_ids,omitempty"`
 }
 
-type injectionFamilyConfig struct {
-	Name     string
-	Payloads []string
-	Keywords []string
-}
+// injectionFamilyConfig is one payload family from the payload packs. Sleep templates are
+// confirmed by confirmTimeBased and OOB templates are sent by sendOOBProbes.
+type injectionFamilyConfig = payloads.Family
 
 const (
 	paramFuzzMaxEndpoints         = 120
@@ -1624,67 +1769,6 @@ var (
 	paramFuzzSignalKeywords = []string{
 		"sql", "syntax error", "exception", "traceback", "stack trace", "internal server error", "undefined", "forbidden", "unauthorized", "invalid token",
 	}
-	injectionFamilies = []injectionFamilyConfig{
-		{
-			Name:     "sqli",
-			Payloads: []string{"'", "\"", "' OR '1'='1"},
-			Keywords: []string{"sql", "syntax error", "mysql", "postgres", "sqlite", "odbc", "database error"},
-		},
-		{
-			Name:     "nosqli",
-			Payloads: []string{`{"$ne":null}`, `{"$gt":""}`, "[$ne]=1"},
-			Keywords: []string{"mongodb", "bson", "nosql", "cast to object", "operator"},
-		},
-		{
-			Name:     "xpath",
-			Payloads: []string{"' or '1'='1", "\" or \"1\"=\"1", "' and count(//*)>0 and '1'='1"},
-			Keywords: []string{"xpath", "xquery", "xml parsing", "invalid predicate"},
-		},
-		{
-			Name:     "ldap",
-			Payloads: []string{"*)(uid=*))(|(uid=*", "*)(|(objectClass=*))", "*)(&(uid=*))"},
-			Keywords: []string{"ldap", "invalid dn", "directory service", "search filter"},
-		},
-	}
-	serverInputFamilies = []injectionFamilyConfig{
-		{
-			Name:     "os_command",
-			Payloads: []string{";id", "|id", "$(id)", "`id`", "& whoami"},
-			Keywords: []string{"uid=", "gid=", "command not found", "/bin/sh", "whoami", "nt authority"},
-		},
-		{
-			Name:     "path_traversal",
-			Payloads: []string{"../../../../etc/passwd", "..%2f..%2f..%2f..%2fetc%2fpasswd", "..\\..\\..\\..\\windows\\win.ini"},
-			Keywords: []string{"root:x:", "[fonts]", "for 16-bit app support", "windows", "win.ini", "no such file or directory"},
-		},
-		{
-			Name:     "file_inclusion",
-			Payloads: []string{"php://filter/convert.base64-encode/resource=index.php", "file:///etc/passwd", "http://127.0.0.1/"},
-			Keywords: []string{"failed to open stream", "include_path", "warning: include", "root:x:", "<?php"},
-		},
-	}
-	advInjectionFamilies = []injectionFamilyConfig{
-		{
-			Name:     "xxe",
-			Payloads: []string{`<?xml version="1.0"?><!DOCTYPE x [<!ENTITY xxe SYSTEM "file:///etc/passwd">]><x>&xxe;</x>`, `<!DOCTYPE foo [ <!ENTITY xxe SYSTEM "file:///etc/hosts"> ]>`},
-			Keywords: []string{"xml", "doctype", "entity", "parser error", "root:x:"},
-		},
-		{
-			Name:     "soap",
-			Payloads: []string{`<?xml version="1.0"?><soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><FaultProbe>1</FaultProbe></soap:Body></soap:Envelope>`},
-			Keywords: []string{"soap", "envelope", "fault", "mustunderstand", "xml"},
-		},
-		{
-			Name:     "ssrf",
-			Payloads: []string{"http://127.0.0.1/", "http://169.254.169.254/latest/meta-data/", "http://localhost:22/"},
-			Keywords: []string{"connection refused", "timed out", "localhost", "metadata", "169.254.169.254"},
-		},
-		{
-			Name:     "smtp",
-			Payloads: []string{"test%0d%0aBcc:inject@local", "test\r\nBcc:inject@local", "x@y.com%0d%0aX-Test:1"},
-			Keywords: []string{"smtp", "mail", "header", "invalid address", "bcc"},
-		},
-	}
 	csrfTokenNames = []string{
 		"csrf", "csrf_token", "csrftoken", "xsrf-token", "x-csrf-token", "x-xsrf-token", "_token", "__requestverificationtoken",
 	}
@@ -1727,7 +1811,7 @@ func (a *App) runCSRFChecks(ctx context.Context) error {
 	defer replayWriter.Flush()
 
 	endpoints := a.collectParamFuzzEndpoints(filepath.Join(reconDir, "all_urls.txt"))
-	endpoints = prioritizeCSRFCandidateEndpoints(endpoints)
+	endpoints = a.aimEndpointsAtRoles(StepCSRFChecks, prioritizeCSRFCandidateEndpoints(endpoints))
 	if len(endpoints) > csrfMaxEndpoints {
 		a.logger.Printf("%s: limiting endpoints from %d to %d", StepCSRFChecks, len(endpoints), csrfMaxEndpoints)
 		endpoints = endpoints[:csrfMaxEndpoints]
@@ -1739,12 +1823,7 @@ func (a *App) runCSRFChecks(ctx context.Context) error {
 		sort.Strings(globalParams)
 	}
 
-	clients := &http.Client{
-		Timeout: paramFuzzRequestTimeout,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
-	}
+	clients := a.newHTTPClient(paramFuzzRequestTimeout, false)
 	lastByHost := make(map[string]time.Time)
 	metrics := map[string]int{
 		"candidates":            0,
@@ -1858,8 +1937,11 @@ func (a *App) runCSRFChecks(ctx context.Context) error {
 			metrics["token_signals"]++
 		}
 
-		crossAccepted := csrfLooksAccepted(baseline, crossOrigin)
-		missingAccepted := csrfLooksAccepted(baseline, missingOrigin)
+		// The same-origin POST is sent once: replaying a state-changing request only to
+		// sample its noise is not worth it here.
+		base := newResponseBaseline(baseline)
+		crossAccepted := csrfLooksAccepted(base, crossOrigin)
+		missingAccepted := csrfLooksAccepted(base, missingOrigin)
 		if crossAccepted {
 			metrics["cross_origin_accepted"]++
 		}
@@ -1910,6 +1992,7 @@ func (a *App) runCSRFChecks(ctx context.Context) error {
 			BaselineLen:     baseline.Length,
 			CrossOriginLen:  crossOrigin.Length,
 			MissingOrigLen:  missingOrigin.Length,
+			EvidenceIDs:     a.saveEvidence(baseline.Exchange, crossOrigin.Exchange, missingOrigin.Exchange),
 		})
 	}
 
@@ -1939,16 +2022,23 @@ func (a *App) runClickjackingChecks(ctx context.Context) error {
 	defer findingsWriter.Flush()
 
 	targets := normalizeHTTPSTargets(readSafeLines(a.httpListOrDefault(a.cfg.Lists.Domains)))
+	if siblings := a.loadClusterSiblingSkips(); len(siblings) > 0 {
+		kept := targets[:0]
+		for _, target := range targets {
+			if _, ok := siblings[extractHostCandidate(target)]; !ok {
+				kept = append(kept, target)
+			}
+		}
+		if skipped := len(targets) - len(kept); skipped > 0 {
+			a.logger.Printf("%s: %d cluster sibling target(s) covered by representatives", StepClickjacking, skipped)
+		}
+		targets = kept
+	}
 	if len(targets) > clickjackingMaxTargets {
 		targets = targets[:clickjackingMaxTargets]
 	}
 
-	client := &http.Client{
-		Timeout: paramFuzzRequestTimeout,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
-	}
+	client := a.newHTTPClient(paramFuzzRequestTimeout, false)
 
 	metrics := map[string]int{
 		"targets_tested":          0,
@@ -1965,13 +2055,15 @@ func (a *App) runClickjackingChecks(ctx context.Context) error {
 		if err != nil {
 			continue
 		}
+		session := a.applySession(req)
 		resp, err := client.Do(req)
 		if err != nil {
 			continue
 		}
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		body, truncated := readLimitedBody(resp.Body, 1024)
 		_ = resp.Body.Close()
-		_ = body
+		a.observeSession(ctx, session, target, resp.StatusCode, resp.Header.Get("Location"), string(body))
+		exchange := captureEvidence(req, nil, resp, body, truncated)
 
 		xfoRaw := strings.TrimSpace(resp.Header.Get("X-Frame-Options"))
 		cspRaw := strings.TrimSpace(strings.Join(resp.Header.Values("Content-Security-Policy"), "; "))
@@ -2038,6 +2130,7 @@ func (a *App) runClickjackingChecks(ctx context.Context) error {
 			XFrameOptions:  xfoRaw,
 			FrameAncestors: frameAncestors,
 			ManualAction:   "Attempt sensitive action UI redress in an iframe PoC and confirm browser-specific behavior.",
+			EvidenceIDs:    a.saveEvidence(exchange),
 		})
 	}
 
@@ -2068,17 +2161,14 @@ func (a *App) runCORSChecks(ctx context.Context) error {
 	defer replayWriter.Flush()
 	defer findingsWriter.Flush()
 
-	endpoints := collectCORSEndpoints(a.collectParamFuzzEndpoints(filepath.Join(reconDir, "all_urls.txt")))
+	// Requests from the imported corpus were really made by the browser, usually with
+	// credentials; test them before the scored URL corpus.
+	endpoints := unique(append(a.requestCorpusURLs(http.MethodGet), collectCORSEndpoints(a.collectParamFuzzEndpoints(filepath.Join(reconDir, "all_urls.txt")))...))
 	if len(endpoints) > corsMaxEndpoints {
 		endpoints = endpoints[:corsMaxEndpoints]
 	}
 
-	clients := &http.Client{
-		Timeout: paramFuzzRequestTimeout,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
-	}
+	clients := a.newHTTPClient(paramFuzzRequestTimeout, false)
 	lastByHost := make(map[string]time.Time)
 	metrics := map[string]int{
 		"endpoints_tested":    0,
@@ -2187,6 +2277,7 @@ func (a *App) runCORSChecks(ctx context.Context) error {
 			AllowOrigin:      evil.AllowOrigin,
 			AllowCredentials: evil.AllowCredentials,
 			ManualAction:     "Replay with authenticated context and verify sensitive response data is readable cross-origin.",
+			EvidenceIDs:      a.saveEvidence(baseline.Exchange, evil.Exchange, nullOrigin.Exchange),
 		})
 	}
 
@@ -2224,17 +2315,12 @@ func (a *App) runOpenRedirectChecks(ctx context.Context) error {
 	defer findingsWriter.Flush()
 	defer replayWriter.Flush()
 
-	candidates := collectOpenRedirectCandidates(a.collectParamFuzzEndpoints(filepath.Join(reconDir, "all_urls.txt")))
+	candidates := collectOpenRedirectCandidates(a.aimEndpointsAtRoles(StepOpenRedirect, a.collectParamFuzzEndpoints(filepath.Join(reconDir, "all_urls.txt"))))
 	if len(candidates) > openRedirectMaxCandidates {
 		candidates = candidates[:openRedirectMaxCandidates]
 	}
 
-	client := &http.Client{
-		Timeout: paramFuzzRequestTimeout,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
-	}
+	client := a.newHTTPClient(paramFuzzRequestTimeout, false)
 	payloads := []string{
 		"https://evil.example/bflow",
 		"//evil.example/bflow",
@@ -2258,15 +2344,18 @@ func (a *App) runOpenRedirectChecks(ctx context.Context) error {
 			if err != nil {
 				continue
 			}
+			session := a.applySession(req)
 			resp, err := client.Do(req)
 			if err != nil {
 				continue
 			}
-			body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+			body, truncated := readLimitedBody(resp.Body, 2048)
 			_ = resp.Body.Close()
 			metrics["payload_replays"]++
+			exchange := captureEvidence(req, nil, resp, body, truncated)
 
 			location := strings.TrimSpace(resp.Header.Get("Location"))
+			a.observeSession(ctx, session, mutated, resp.StatusCode, location, string(body))
 			bodyLower := strings.ToLower(string(body))
 			externalHeaderRedirect := resp.StatusCode >= 300 && resp.StatusCode < 400 && strings.Contains(strings.ToLower(location), "evil.example")
 			clientSideRedirect := strings.Contains(bodyLower, "evil.example") &&
@@ -2310,6 +2399,7 @@ func (a *App) runOpenRedirectChecks(ctx context.Context) error {
 				StatusCode:   resp.StatusCode,
 				ChainSignals: chainSignals,
 				ManualAction: "Attempt chaining into OAuth/OIDC callbacks, auth flows, or trusted-domain bypass scenarios.",
+				EvidenceIDs:  a.saveEvidence(exchange),
 			})
 		}
 	}
@@ -2348,17 +2438,12 @@ func (a *App) runWorkflowLogicChecks(ctx context.Context) error {
 	defer findingWriter.Flush()
 	defer replayWriter.Flush()
 
-	endpoints := prioritizeWorkflowEndpoints(a.collectParamFuzzEndpoints(filepath.Join(reconDir, "all_urls.txt")))
+	endpoints := a.aimEndpointsAtRoles(StepWorkflowLogic, prioritizeWorkflowEndpoints(a.collectParamFuzzEndpoints(filepath.Join(reconDir, "all_urls.txt"))))
 	if len(endpoints) > 80 {
 		endpoints = endpoints[:80]
 	}
 
-	clients := &http.Client{
-		Timeout: paramFuzzRequestTimeout,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
-	}
+	clients := a.newHTTPClient(paramFuzzRequestTimeout, false)
 	lastByHost := make(map[string]time.Time)
 	metrics := map[string]int{
 		"candidates":             0,
@@ -2388,12 +2473,16 @@ func (a *App) runWorkflowLogicChecks(ctx context.Context) error {
 			"source":   source,
 		})
 
-		baseline, err := a.sendParamFuzzRequest(ctx, clients, lastByHost, endpoint, http.MethodGet, nil, nil, "")
+		baseline, err := a.sampleBaseline(ctx, clients, lastByHost, endpoint, http.MethodGet, nil, nil, "")
 		if err != nil {
 			continue
 		}
 		metrics["tested"]++
 		reasons := []string{}
+		sameAsBaseline := func(obs paramFuzzObservation, probe string) bool {
+			changed, _ := paramFuzzReasons(baseline, obs, probe)
+			return baseline.StatusCode < 400 && obs.StatusCode < 400 && len(changed) <= 1
+		}
 
 		if source == "query" {
 			removedURL := removeQueryParam(endpoint, stepParam)
@@ -2408,7 +2497,7 @@ func (a *App) runWorkflowLogicChecks(ctx context.Context) error {
 						"status_code": removedObs.StatusCode,
 						"length":      removedObs.Length,
 					})
-					if baseline.StatusCode < 400 && removedObs.StatusCode < 400 && len(paramFuzzReasons(baseline, removedObs)) <= 1 {
+					if sameAsBaseline(removedObs, "") {
 						reasons = append(reasons, "removed_step_parameter_still_accepted")
 						metrics["step_skip_signals"]++
 					}
@@ -2428,7 +2517,7 @@ func (a *App) runWorkflowLogicChecks(ctx context.Context) error {
 					"status_code": advancedObs.StatusCode,
 					"length":      advancedObs.Length,
 				})
-				if baseline.StatusCode < 400 && advancedObs.StatusCode < 400 && len(paramFuzzReasons(baseline, advancedObs)) <= 1 {
+				if sameAsBaseline(advancedObs, "9999") {
 					reasons = append(reasons, "forced_step_value_accepted")
 					metrics["sequence_bypass_signal"]++
 				}
@@ -2638,8 +2727,15 @@ func (a *App) runNmapEnrichmentChecks(ctx context.Context) error {
 		}
 		metrics["searchsploit_lines"] = countNonEmptyLines(ssStdout)
 	}
+	webLive, webAdded, webErr := a.probeNmapWebServices(ctx, serviceRows)
+	if webErr != nil {
+		a.logger.Printf("%s: merging web services failed: %v", StepNmapEnrich, webErr)
+	}
+	metrics["web_services_live"] = webLive
+	metrics["web_services_added"] = webAdded
+	a.refreshTechProfileFromNmap(ctx)
 
-	a.logger.Printf("%s: targets=%d services=%d fingerprints=%d", StepNmapEnrich, metrics["targets"], metrics["open_service_rows"], metrics["unique_service_fingerprints"])
+	a.logger.Printf("%s: targets=%d services=%d fingerprints=%d web_live=%d web_added=%d", StepNmapEnrich, metrics["targets"], metrics["open_service_rows"], metrics["unique_service_fingerprints"], metrics["web_services_live"], metrics["web_services_added"])
 	return nil
 }
 
@@ -2678,7 +2774,7 @@ func (a *App) runNucleiScan(ctx context.Context) error {
 	}
 
 	findingsPath := filepath.Join(outDir, "findings.jsonl")
-	stdout, err := a.runCommandCapture(ctx, "nuclei",
+	nucleiArgs := []string{
 		"-silent",
 		"-jsonl",
 		"-l", targetsFile,
@@ -2688,7 +2784,19 @@ func (a *App) runNucleiScan(ctx context.Context) error {
 		"-rate-limit", "50",
 		"-bulk-size", "25",
 		"-c", "25",
-	)
+	}
+	if techTags := a.techNucleiTags(); len(techTags) > 0 {
+		tags := unique(append(append([]string{}, techNucleiBaselineTags...), techTags...))
+		_ = os.WriteFile(filepath.Join(outDir, "tags.txt"), []byte(strings.Join(tags, "\n")+"\n"), 0o644)
+		if a.cfg.Nuclei.TechTagsOnly {
+			nucleiArgs = append(nucleiArgs, "-tags", strings.Join(tags, ","))
+			a.logger.Printf("%s: tech profile restricted the scan to %d tag(s)", StepNucleiScan, len(tags))
+		} else {
+			nucleiArgs = append(nucleiArgs, "-itags", strings.Join(tags, ","))
+			a.logger.Printf("%s: tech profile included %d tag(s) on top of the full template set", StepNucleiScan, len(tags))
+		}
+	}
+	stdout, err := a.runCommandCapture(ctx, "nuclei", nucleiArgs...)
 	_ = os.WriteFile(filepath.Join(outDir, "nuclei_stdout.log"), []byte(stdout), 0o644)
 	if err != nil {
 		a.logger.Printf("%s: nuclei execution error: %v", StepNucleiScan, err)
@@ -3090,6 +3198,8 @@ func (a *App) collectParamFuzzEndpoints(path string) []string {
 	}
 	sort.Strings(inScope)
 	sort.Strings(outScope)
+	roles := a.loadHostRoles()
+	siblings := a.loadClusterSiblingSkips()
 
 	seen := make(map[string]struct{})
 	var out []string
@@ -3112,6 +3222,12 @@ func (a *App) collectParamFuzzEndpoints(path string) []string {
 		if hostMatchesAny(host, outScope) {
 			continue
 		}
+		if hostRoleSkipped(roles, host) {
+			continue
+		}
+		if _, ok := siblings[host]; ok {
+			continue
+		}
 		clean := strings.TrimRight(u, "/")
 		if clean == "" {
 			continue
@@ -3122,8 +3238,7 @@ func (a *App) collectParamFuzzEndpoints(path string) []string {
 		seen[clean] = struct{}{}
 		out = append(out, clean)
 	}
-	sort.Strings(out)
-	return out
+	return dedupeByURLTemplate(a.filterByURLCorpus(out))
 }
 
 func collectHostsFromLists(paths ...string) map[string]struct{} {
@@ -3321,6 +3436,7 @@ type corsProbeResult struct {
 	AllowCredentials string
 	AllowMethods     string
 	Vary             string
+	Exchange         *Evidence
 }
 
 func writeMetricSummaryCSV(path string, keys []string, metrics map[string]int) error {
@@ -3422,14 +3538,17 @@ func (a *App) sendCORSProbeRequest(
 	if strings.TrimSpace(origin) != "" {
 		req.Header.Set("Origin", origin)
 	}
+	session := a.applySession(req)
 	resp, err := client.Do(req)
 	if err != nil {
 		return corsProbeResult{}, err
 	}
 	lastByHost[host] = time.Now()
-	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	body, truncated := readLimitedBody(resp.Body, 4096)
 	_ = resp.Body.Close()
+	a.observeSession(ctx, session, target, resp.StatusCode, resp.Header.Get("Location"), string(body))
 	return corsProbeResult{
+		Exchange:         captureEvidence(req, nil, resp, body, truncated),
 		StatusCode:       resp.StatusCode,
 		Length:           len(body),
 		AllowOrigin:      strings.TrimSpace(resp.Header.Get("Access-Control-Allow-Origin")),
@@ -4193,14 +4312,14 @@ func hasCSRFTokenSignals(obs paramFuzzObservation) bool {
 	return false
 }
 
-func csrfLooksAccepted(base, mutated paramFuzzObservation) bool {
+func csrfLooksAccepted(base *responseBaseline, mutated paramFuzzObservation) bool {
 	if base.StatusCode <= 0 || mutated.StatusCode <= 0 {
 		return false
 	}
 	if base.StatusCode >= 400 || mutated.StatusCode >= 400 {
 		return false
 	}
-	reasons := paramFuzzReasons(base, mutated)
+	reasons, _ := paramFuzzReasons(base, mutated, "")
 	return len(reasons) <= 1
 }
 
@@ -4420,7 +4539,7 @@ func (a *App) sendParamFuzzRequest(
 	for attempt := 1; attempt <= paramFuzzRetryCount; attempt++ {
 		if host != "" {
 			if last, ok := lastByHost[host]; ok {
-				wait := paramFuzzHostDelay - time.Since(last)
+				wait := a.hostRequestDelay(host) - time.Since(last)
 				if wait > 0 {
 					timer := time.NewTimer(wait)
 					select {
@@ -4447,6 +4566,7 @@ func (a *App) sendParamFuzzRequest(
 		if cookie != "" {
 			req.Header.Set("Cookie", cookie)
 		}
+		session := a.applySession(req)
 
 		start := time.Now()
 		resp, err := client.Do(req)
@@ -4459,14 +4579,22 @@ func (a *App) sendParamFuzzRequest(
 			return obs, err
 		}
 
-		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		bodyBytes, truncated := readLimitedBody(resp.Body, responseDiffBodyLimit)
 		_ = resp.Body.Close()
+		lower := strings.ToLower(string(bodyBytes))
 		obs = paramFuzzObservation{
 			StatusCode: resp.StatusCode,
 			DurationMS: time.Since(start).Milliseconds(),
 			Location:   strings.TrimSpace(resp.Header.Get("Location")),
-			Snippet:    strings.ToLower(string(bodyBytes)),
+			Snippet:    lower[:min(len(lower), 4096)],
+			Body:       lower,
+			Headers:    resp.Header.Clone(),
+			Exchange:   captureEvidence(req, body, resp, bodyBytes, truncated),
 		}
+		if session != nil {
+			obs.Session = session.name
+		}
+		a.observeSession(ctx, session, target, obs.StatusCode, obs.Location, obs.Snippet)
 		for k := range resp.Header {
 			lk := strings.ToLower(strings.TrimSpace(k))
 			if lk == "x-csrf-token" || lk == "x-xsrf-token" || lk == "csrf-token" {
@@ -4690,6 +4818,23 @@ func (a *App) robots(ctx context.Context, source string) error {
 	_ = os.WriteFile(robotsURLsFile, []byte{}, 0o644)
 	_ = os.WriteFile(a.cfg.Paths.SitemapsFile, []byte{}, 0o644)
 
+	sitemapEntriesPath := filepath.Join(a.cfg.Paths.RobotsDir, "sitemaps.jsonl")
+	firstSeen := loadSitemapFirstSeen(sitemapEntriesPath)
+	robotsJSONL, err := os.Create(filepath.Join(a.cfg.Paths.RobotsDir, "robots.jsonl"))
+	if err != nil {
+		return err
+	}
+	defer robotsJSONL.Close()
+	robotsWriter := bufio.NewWriter(robotsJSONL)
+	defer robotsWriter.Flush()
+	sitemapJSONL, err := os.Create(sitemapEntriesPath)
+	if err != nil {
+		return err
+	}
+	defer sitemapJSONL.Close()
+	sitemapWriter := bufio.NewWriter(sitemapJSONL)
+	defer sitemapWriter.Flush()
+
 	for _, target := range targets {
 		if target == "" {
 			continue
@@ -4709,8 +4854,9 @@ func (a *App) robots(ctx context.Context, source string) error {
 		if err != nil {
 			return err
 		}
+		a.applySession(req)
 
-		resp, err := a.httpClient.Do(req)
+		resp, err := a.newHTTPClient(0, true).Do(req)
 		if err != nil {
 			continue
 		}
@@ -4725,39 +4871,55 @@ func (a *App) robots(ctx context.Context, source string) error {
 			return err
 		}
 
-		disallows := extractDisallows(string(body))
-		sitemapURLs := extractSitemaps(string(body))
+		now := time.Now().UTC().Format(time.RFC3339)
+		record := robotsRecord{
+			Timestamp:  now,
+			Host:       extractHostCandidate(baseURL),
+			URL:        robotsURL,
+			StatusCode: resp.StatusCode,
+		}
+		if resp.StatusCode < 400 {
+			record.Groups, record.Sitemaps = parseRobotsTxt(string(body))
+		}
 
 		var endpointSet = make(map[string]struct{})
 		var robotsURLSet = make(map[string]struct{})
-		for _, disallow := range disallows {
-			disallow = strings.TrimSpace(disallow)
-			if disallow == "" {
-				continue
-			}
-			u := normalizeFFUFHitURL(baseURL + "/" + strings.TrimPrefix(disallow, "/"))
+		for _, rule := range robotsRulePaths(record.Groups) {
+			u := normalizeFFUFHitURL(baseURL + "/" + strings.TrimPrefix(rule, "/"))
 			if u != "" {
 				endpointSet[u] = struct{}{}
 				robotsURLSet[u] = struct{}{}
 			}
 		}
 
-		for _, sitemapURL := range sitemapURLs {
-			sitemapURL = strings.TrimSpace(sitemapURL)
-			if sitemapURL == "" {
-				continue
-			}
+		sitemapRoots := record.Sitemaps
+		if len(sitemapRoots) == 0 {
+			sitemapRoots = []string{baseURL + "/sitemap.xml"}
+		}
+		for _, sitemapURL := range record.Sitemaps {
 			if err := appendToFile(a.cfg.Paths.SitemapsFile, sitemapURL+"\n"); err != nil {
 				return err
 			}
-			locs, fetchErr := a.fetchSitemapLocs(ctx, sitemapURL)
-			if fetchErr != nil {
-				continue
+		}
+		crawl := a.crawlSitemaps(ctx, record.Host, sitemapRoots)
+		record.SitemapFetches = crawl.fetches
+		record.SitemapURLs = len(crawl.entries)
+		record.Truncated = crawl.truncated
+		for _, entry := range crawl.entries {
+			endpointSet[entry.Loc] = struct{}{}
+			entry.Timestamp = now
+			entry.FirstSeen = firstSeen[entry.Loc]
+			if entry.FirstSeen == "" {
+				entry.FirstSeen = now
+				// Everything is new on the first run; only flag additions once a baseline exists.
+				entry.New = len(firstSeen) > 0
 			}
-			for _, loc := range locs {
-				endpointSet[loc] = struct{}{}
+			if entry.New {
+				record.NewSitemapURLs++
 			}
+			_ = writeJSONLine(sitemapWriter, entry)
 		}
+		_ = writeJSONLine(robotsWriter, record)
 
 		var endpoints []string
 		for endpoint := range endpointSet {
@@ -4917,34 +5079,69 @@ func (a *App) runKatana(ctx context.Context, source string) error {
 	return nil
 }
 
-func (a *App) consolidateURLCorpus() error {
+func (a *App) consolidateURLCorpus(ctx context.Context) error {
 	reconDir := filepath.Join(filepath.Dir(a.cfg.Lists.Domains), "recon")
 	allURLs := filepath.Join(reconDir, "all_urls.txt")
 	if err := os.MkdirAll(reconDir, 0o755); err != nil {
 		return err
 	}
 
-	paths := []string{
-		filepath.Join(a.cfg.Paths.RobotsDir, "_hits.txt"),
-		filepath.Join(a.cfg.Paths.RobotsDir, "robots_urls.txt"),
-		filepath.Join(reconDir, "waybackurls_urls.txt"),
-		filepath.Join(reconDir, "katana_urls.txt"),
-		a.httpListOrDefault(a.cfg.Lists.Domains),
+	// Sources are read in order; robots rules come before _hits.txt so only the sitemap
+	// entries in _hits.txt are credited to sitemaps.
+	sources := []struct {
+		name string
+		path string
+	}{
+		{"robots", filepath.Join(a.cfg.Paths.RobotsDir, "robots_urls.txt")},
+		{"sitemap", filepath.Join(a.cfg.Paths.RobotsDir, "_hits.txt")},
+		{"wayback", filepath.Join(reconDir, "waybackurls_urls.txt")},
+		{"katana", filepath.Join(reconDir, "katana_urls.txt")},
+		{"js", filepath.Join(reconDir, "js_endpoints.txt")},
+		{"ffuf", filepath.Join(a.fuzzingDocsDir(), "doc_hits.txt")},
+		{"ffuf", filepath.Join(a.fuzzingFFUFDir(), "dir_hits.txt")},
+		{"httpx", a.httpListOrDefault(a.cfg.Lists.Domains)},
+	}
+
+	found := make(map[string]map[string]struct{})
+	add := func(u, source string) {
+		if found[u] == nil {
+			found[u] = make(map[string]struct{})
+		}
+		if source == "sitemap" {
+			if _, ok := found[u]["robots"]; ok {
+				return
+			}
+		}
+		found[u][source] = struct{}{}
 	}
-
-	set := make(map[string]struct{})
-	for _, p := range paths {
-		for _, line := range readSafeLines(p) {
+	for _, src := range sources {
+		for _, line := range readSafeLines(src.path) {
 			u := normalizeFFUFHitURL(strings.TrimSpace(line))
 			if u == "" {
 				continue
 			}
-			set[u] = struct{}{}
+			add(u, src.name)
+		}
+	}
+	params := make(map[string][]string)
+	for _, req := range readRequestCorpus(a.requestCorpusPath()) {
+		u := normalizeFFUFHitURL(req.URL)
+		if u == "" {
+			continue
+		}
+		add(u, "import")
+		for _, param := range req.Params {
+			if param.In == "path" || param.In == "header" {
+				continue
+			}
+			if name := normalizeParamName(param.Name); name != "" {
+				params[u] = append(params[u], name)
+			}
 		}
 	}
 
 	var urls []string
-	for u := range set {
+	for u := range found {
 		urls = append(urls, u)
 	}
 	sort.Strings(urls)
@@ -4952,7 +5149,7 @@ func (a *App) consolidateURLCorpus() error {
 		return err
 	}
 	a.logger.Printf("%s: total urls=%d (%s)", StepURLCorpus, len(urls), allURLs)
-	return nil
+	return a.updateURLCorpus(ctx, found, params)
 }
 
 func (a *App) generateDorkLinksIfNeeded(ctx context.Context) error {
@@ -5169,7 +5366,7 @@ func (a *App) buildHTTPDomains(ctx context.Context) (string, error) {
 			if u == "" {
 				continue
 			}
-			fallbackRows = append(fallbackRows, liveWebserverRecord{URL: u})
+			fallbackRows = append(fallbackRows, liveWebserverRecord{URL: u, Port: liveWebserverPort(u, "")})
 		}
 		if err := a.writeLiveWebserversJSONL(jsonlPath, fallbackRows); err != nil {
 			return "", err
@@ -5222,6 +5419,7 @@ func parseHTTPXJSONRecords(output string) []liveWebserverRecord {
 			WebServer:     asString(raw["webserver"]),
 			ContentLength: asInt(raw["content_length"]),
 		}
+		rec.Port = liveWebserverPort(rec.URL, asString(raw["port"]))
 
 		if techs := asStringSlice(raw["tech"]); len(techs) > 0 {
 			rec.Technologies = techs
@@ -5634,6 +5832,42 @@ func (a *App) withTorPrefix(name string, args ...string) (string, []string) {
 	return "torify", append([]string{name}, args...)
 }
 
+// ResolveNodeBinary finds a node runtime on PATH or in common version-manager installs.
+func ResolveNodeBinary() (string, error) {
+	if path, err := exec.LookPath("node"); err == nil {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.New("unable to resolve home directory")
+	}
+
+	patterns := []string{
+		filepath.Join(home, ".nvm", "versions", "node", "*", "bin", "node"),
+		filepath.Join(home, ".volta", "bin", "node"),
+		filepath.Join(home, ".asdf", "shims", "node"),
+	}
+	var candidates []string
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(pattern)
+		candidates = append(candidates, matches...)
+	}
+	sort.Strings(candidates)
+	for i := len(candidates) - 1; i >= 0; i-- {
+		candidate := candidates[i]
+		info, statErr := os.Stat(candidate)
+		if statErr != nil || info.IsDir() {
+			continue
+		}
+		if info.Mode()&0o111 == 0 {
+			continue
+		}
+		return candidate, nil
+	}
+	return "", errors.New("node binary not found")
+}
+
 func (a *App) networkEnv() []string {
 	env := os.Environ()
 	if a.torEnabled {
@@ -5786,7 +6020,7 @@ func (a *App) fetchCTLHostsRaw(ctx context.Context, seed string) ([]string, []by
 	if err != nil {
 		return nil, nil, err
 	}
-	resp, err := a.httpClient.Do(req)
+	resp, err := a.newHTTPClient(0, true).Do(req)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -6040,42 +6274,6 @@ func sanitizeFilename(input string) string {
 	return strings.ReplaceAll(s, " ", "_")
 }
 
-func extractDisallows(body string) []string {
-	var result []string
-	for _, line := range strings.Split(body, "\n") {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "Disallow:") {
-			result = append(result, strings.TrimSpace(strings.TrimPrefix(line, "Disallow:")))
-		}
-	}
-	return result
-}
-
-func extractSitemaps(body string) []string {
-	set := make(map[string]struct{})
-	for _, line := range strings.Split(body, "\n") {
-		trimmed := strings.TrimSpace(line)
-		if !strings.HasPrefix(strings.ToLower(trimmed), "sitemap:") {
-			continue
-		}
-		parts := strings.SplitN(trimmed, ":", 2)
-		if len(parts) < 2 {
-			continue
-		}
-		u := strings.TrimSpace(parts[1])
-		if normalizeFFUFHitURL(u) == "" {
-			continue
-		}
-		set[u] = struct{}{}
-	}
-	var out []string
-	for u := range set {
-		out = append(out, u)
-	}
-	sort.Strings(out)
-	return out
-}
-
 func extractSitemapLocs(body string) []string {
 	pattern := regexp.MustCompile(`(?is)<loc>\s*([^<\s]+)\s*</loc>`)
 	matches := pattern.FindAllStringSubmatch(body, -1)
@@ -6098,26 +6296,6 @@ func extractSitemapLocs(body string) []string {
 	return out
 }
 
-func (a *App) fetchSitemapLocs(ctx context.Context, sitemapURL string) ([]string, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sitemapURL, nil)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := a.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("sitemap returned status %d", resp.StatusCode)
-	}
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	return extractSitemapLocs(string(body)), nil
-}
-
 func moveRobotFiles(robotsPath, urlsPath, targetDir string) error {
 	if targetDir == "" {
 		return errors.New("empty target directory")
@@ -2,6 +2,7 @@ package app
 
 import (
 	"net/url"
+	"regexp"
 	"strings"
 )
 
@@ -16,74 +17,59 @@ func mutateURLQuery(rawURL, key, value string) string {
 	return parsed.String()
 }
 
-func paramFuzzReasons(base, mutated paramFuzzObservation) []string {
-	var reasons []string
-	if base.StatusCode != mutated.StatusCode {
-		baseClass := statusCodeClass(base.StatusCode)
-		mutatedClass := statusCodeClass(mutated.StatusCode)
-		if baseClass != mutatedClass || base.StatusCode >= 500 || mutated.StatusCode >= 500 {
-			reasons = append(reasons, "status_code_changed")
-		}
-	}
-	lenDiff := mutated.Length - base.Length
-	if lenDiff < 0 {
-		lenDiff = -lenDiff
-	}
-	lenThreshold := 80
-	if dynamic := int(float64(base.Length) * 0.35); dynamic > lenThreshold {
-		lenThreshold = dynamic
-	}
-	if lenDiff > lenThreshold {
-		reasons = append(reasons, "response_length_changed")
-	}
-	if base.Location != mutated.Location {
-		if redirectTargetMeaningfullyChanged(base.Location, mutated.Location) {
-			reasons = append(reasons, "redirect_target_changed")
-		}
-	}
-	if mutated.DurationMS > (base.DurationMS*3 + 1500) {
-		reasons = append(reasons, "timing_spike")
-	}
-	for _, kw := range paramFuzzSignalKeywords {
-		if strings.Contains(mutated.Snippet, kw) && !strings.Contains(base.Snippet, kw) {
-			reasons = append(reasons, "new_signal_keyword:"+kw)
-		}
-	}
-	return unique(reasons)
+// paramFuzzReasons lists how mutated departs from the baseline samples; probe is the
+// value that was injected, if any.
+func paramFuzzReasons(base *responseBaseline, mutated paramFuzzObservation, probe string) ([]string, responseDiff) {
+	return base.diff(mutated, probe)
 }
 
-func injectionReasons(base, mutated paramFuzzObservation, familyKeywords []string) []string {
-	reasons := paramFuzzReasons(base, mutated)
+func injectionReasons(base *responseBaseline, mutated paramFuzzObservation, payload string, familyKeywords []string, familyRegexes []*regexp.Regexp) ([]string, responseDiff) {
+	reasons, diff := paramFuzzReasons(base, mutated, payload)
+	if diff.Blocked != "" {
+		return nil, diff
+	}
 	if base.StatusCode < 500 && mutated.StatusCode >= 500 {
 		reasons = append(reasons, "server_error_on_payload")
 	}
+	text := mutated.text()
 	for _, kw := range familyKeywords {
 		kw = strings.ToLower(strings.TrimSpace(kw))
-		if kw == "" {
+		if kw == "" || strings.Contains(strings.ToLower(payload), kw) {
 			continue
 		}
-		if strings.Contains(mutated.Snippet, kw) && !strings.Contains(base.Snippet, kw) {
+		if strings.Contains(text, kw) && !base.anySampleContains(kw) {
 			reasons = append(reasons, "family_keyword:"+kw)
 		}
 	}
+	for _, re := range familyRegexes {
+		if re.MatchString(payload) {
+			continue
+		}
+		if match := re.FindString(text); match != "" && !base.anySampleMatches(re) {
+			reasons = append(reasons, "family_regex:"+strings.ToLower(match))
+		}
+	}
 	reasons = unique(reasons)
 	if len(reasons) == 0 {
-		return nil
+		return nil, diff
 	}
 	strong := hasReasonPrefix(reasons, "family_keyword:") ||
+		hasReasonPrefix(reasons, "family_regex:") ||
 		hasReasonPrefix(reasons, "new_signal_keyword:") ||
 		containsAny(reasons, "server_error_on_payload")
 	if strong {
-		return reasons
+		return reasons, diff
 	}
 	lenDiff := mutated.Length - base.Length
 	if lenDiff < 0 {
 		lenDiff = -lenDiff
 	}
-	if mutated.StatusCode >= 500 || lenDiff > 600 {
-		return reasons
+	// Weak signals alone need a large departure: an error page, a much longer or shorter
+	// body, or a body that no longer shares most of its stable structure.
+	if mutated.StatusCode >= 500 || lenDiff > 600 || (diff.Similarity < 0.5 && containsAny(reasons, "body_structure_changed")) {
+		return reasons, diff
 	}
-	return nil
+	return nil, diff
 }
 
 func hasReasonPrefix(reasons []string, prefix string) bool {
@@ -0,0 +1,223 @@
+package app
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	evidenceRequestBodyLimit = 64 << 10
+	evidenceStoreLimit       = 512 << 20
+	evidenceIDLength         = 32
+)
+
+// Evidence is the raw wire form of one request/response pair. Probes keep it in memory;
+// it reaches the store only once the probe backs a finding. Truncated marks a response
+// body cut at the probe's read limit.
+type Evidence struct {
+	Request   string `json:"request"`
+	Response  string `json:"response"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// captureEvidence renders req and resp as HTTP/1.1 text. body and respBody are what was
+// sent and read; resp.Body is already consumed by then.
+func captureEvidence(req *http.Request, body []byte, resp *http.Response, respBody []byte, truncated bool) *Evidence {
+	if len(body) > evidenceRequestBodyLimit {
+		body, truncated = body[:evidenceRequestBodyLimit], true
+	}
+	var respBuf bytes.Buffer
+	fmt.Fprintf(&respBuf, "%s %s\r\n", resp.Proto, resp.Status)
+	_ = resp.Header.Write(&respBuf)
+	respBuf.WriteString("\r\n")
+	respBuf.Write(respBody)
+	return &Evidence{Request: RawRequest(req, body), Response: respBuf.String(), Truncated: truncated}
+}
+
+// RawRequest renders req with body as HTTP/1.1 text, as it went on the wire.
+func RawRequest(req *http.Request, body []byte) string {
+	var buf bytes.Buffer
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	fmt.Fprintf(&buf, "%s %s HTTP/1.1\r\nHost: %s\r\n", req.Method, req.URL.RequestURI(), host)
+	_ = req.Header.Write(&buf)
+	if len(body) > 0 && req.Header.Get("Content-Length") == "" {
+		fmt.Fprintf(&buf, "Content-Length: %d\r\n", len(body))
+	}
+	buf.WriteString("\r\n")
+	buf.Write(body)
+	return buf.String()
+}
+
+// EvidenceDir is where findings' request/response pairs are stored under a data dir.
+func EvidenceDir(baseDir string) string {
+	return filepath.Join(baseDir, "evidence")
+}
+
+func evidencePath(dir, id string) string {
+	return filepath.Join(dir, id[:2], id+".json.gz")
+}
+
+// ReadEvidence loads one stored pair by id.
+func ReadEvidence(baseDir, id string) (Evidence, error) {
+	var ev Evidence
+	if len(id) != evidenceIDLength {
+		return ev, fmt.Errorf("invalid evidence id %q", id)
+	}
+	if _, err := hex.DecodeString(id); err != nil {
+		return ev, fmt.Errorf("invalid evidence id %q", id)
+	}
+	f, err := os.Open(evidencePath(EvidenceDir(baseDir), id))
+	if err != nil {
+		return ev, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return ev, err
+	}
+	defer zr.Close()
+	err = json.NewDecoder(zr).Decode(&ev)
+	return ev, err
+}
+
+// saveEvidence stores each pair under the hash of its content and returns the ids in
+// argument order (findings list the baseline first, then the mutated probe). Session
+// profile credentials are redacted first. Pairs seen before are not rewritten; once the store passes
+// evidenceStoreLimit new pairs are dropped and findings go out without ids.
+func (a *App) saveEvidence(items ...*Evidence) []string {
+	var ids []string
+	for _, ev := range items {
+		if ev == nil {
+			continue
+		}
+		if id, err := a.storeEvidence(a.redactEvidence(ev)); err != nil {
+			a.logger.Printf("evidence: %v", err)
+		} else if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// redactEvidence masks the credentials session profiles added to the request so they
+// never reach the store. The probe's own cookies and headers stay, so the evidence still
+// shows the payload, and the names stay, showing the probe was authenticated.
+func (a *App) redactEvidence(ev *Evidence) *Evidence {
+	profiles := a.appliedSessionProfiles()
+	if len(profiles) == 0 {
+		return ev
+	}
+	head, body, found := strings.Cut(ev.Request, "\r\n\r\n")
+	lines := strings.Split(head, "\r\n")
+	redacted := false
+	for i := 1; i < len(lines); i++ {
+		name, value, ok := strings.Cut(lines[i], ":")
+		if !ok {
+			continue
+		}
+		if masked, changed := redactSessionHeader(profiles, strings.TrimSpace(name), strings.TrimSpace(value)); changed {
+			lines[i] = name + ": " + masked
+			redacted = true
+		}
+	}
+	if !redacted {
+		return ev
+	}
+	out := *ev
+	out.Request = strings.Join(lines, "\r\n")
+	if found {
+		out.Request += "\r\n\r\n" + body
+	}
+	return &out
+}
+
+func (a *App) storeEvidence(ev *Evidence) (string, error) {
+	raw, err := json.Marshal(ev)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	id := hex.EncodeToString(sum[:])[:evidenceIDLength]
+	dir := EvidenceDir(filepath.Dir(a.cfg.Lists.Domains))
+	path := evidencePath(dir, id)
+
+	a.evidenceMu.Lock()
+	defer a.evidenceMu.Unlock()
+	if _, err := os.Stat(path); err == nil {
+		return id, nil
+	}
+	if !a.evidenceSized {
+		a.evidenceUsed, a.evidenceSized = evidenceDirSize(dir), true
+	}
+	if a.evidenceUsed >= evidenceStoreLimit {
+		if !a.evidenceFull {
+			a.evidenceFull = true
+			a.logger.Printf("evidence: store %s reached %d MB; new findings are saved without evidence", dir, evidenceStoreLimit>>20)
+		}
+		return "", nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(raw); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", err
+	}
+	a.evidenceUsed += int64(buf.Len())
+	return id, nil
+}
+
+func evidenceDirSize(dir string) int64 {
+	var total int64
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return nil
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".json.gz") {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
+
+// readLimitedBody reads up to limit bytes and reports whether the body went on past it.
+func readLimitedBody(r io.Reader, limit int64) ([]byte, bool) {
+	body, _ := io.ReadAll(io.LimitReader(r, limit+1))
+	if int64(len(body)) > limit {
+		return body[:limit], true
+	}
+	return body, false
+}
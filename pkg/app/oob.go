@@ -0,0 +1,288 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rojo/hack/web_bounty_flow/pkg/oob"
+)
+
+var oobManualActions = map[string]string{
+	"ssrf":           "The target fetched our URL. Confirm blind SSRF, then aim the same parameter at internal hosts and cloud metadata.",
+	"xxe":            "The XML parser resolved an external entity. Escalate to out-of-band file exfiltration with a parameter-entity DTD.",
+	"os_command":     "A command ran on the target and called back. Repeat with a fresh payload to rule out a caching proxy before reporting.",
+	"file_inclusion": "The target loaded a remote URL. Check whether the fetched content is executed (RFI) or only read (SSRF).",
+}
+
+// oobProbe is one payload sent with a correlation id, kept until its interactions come
+// in. Interactions arriving while the request is still in flight (SSRF fetches happen
+// before the response) wait in pending so the hit can carry the probe's evidence.
+type oobProbe struct {
+	Timestamp      string `json:"timestamp"`
+	ID             string `json:"correlation_id"`
+	Step           string `json:"step"`
+	Family         string `json:"family"`
+	Endpoint       string `json:"endpoint"`
+	Method         string `json:"method"`
+	Param          string `json:"param,omitempty"`
+	Vector         string `json:"vector"`
+	Payload        string `json:"payload"`
+	MutatedURL     string `json:"mutated_url"`
+	SessionProfile string `json:"session_profile,omitempty"`
+
+	exchange *Evidence
+	sent     bool
+	pending  []oob.Interaction
+}
+
+// oobHit is one line of fuzzing/oob/interactions.jsonl: an interaction tied back to the
+// probe that carried its correlation id.
+type oobHit struct {
+	Timestamp      string   `json:"timestamp"`
+	Family         string   `json:"family"`
+	Severity       string   `json:"severity"`
+	Step           string   `json:"step"`
+	Endpoint       string   `json:"endpoint"`
+	Method         string   `json:"method"`
+	Param          string   `json:"param,omitempty"`
+	Vector         string   `json:"vector"`
+	Payload        string   `json:"payload"`
+	MutatedURL     string   `json:"mutated_url"`
+	CorrelationID  string   `json:"correlation_id"`
+	Protocol       string   `json:"protocol"`
+	Remote         string   `json:"remote"`
+	SentAt         string   `json:"sent_at"`
+	Interaction    string   `json:"interaction"`
+	Reasons        []string `json:"reasons"`
+	ManualAction   string   `json:"manual_action"`
+	EvidenceIDs    []string `json:"evidence_ids,omitempty"`
+	SessionProfile string   `json:"session_profile,omitempty"`
+}
+
+func (a *App) oobDir() string {
+	return filepath.Join(a.fuzzingBaseDir(), "oob")
+}
+
+// startOOB starts the listener on first use and keeps it for the life of the process,
+// so callbacks that arrive after a step has finished are still recorded.
+func (a *App) startOOB() *oob.Listener {
+	a.oobMu.Lock()
+	defer a.oobMu.Unlock()
+	if a.oobListener != nil || a.oobTried {
+		return a.oobListener
+	}
+	a.oobTried = true
+	settings := a.cfg.OOB
+	if !settings.Enabled {
+		return nil
+	}
+	listener, err := oob.Start(oob.Config{
+		PublicHost: settings.PublicHost,
+		PublicPort: settings.PublicPort,
+		PublicIP:   settings.PublicIP,
+		HTTPListen: settings.HTTPListen,
+		DNSListen:  settings.DNSListen,
+	}, a.handleOOBInteraction)
+	if err != nil {
+		a.logger.Printf("oob: listener disabled: %v", err)
+		return nil
+	}
+	a.oobListener = listener
+	a.oobProbes = make(map[string]*oobProbe)
+	a.logger.Printf("oob: listening http=%s dns=%s public_host=%s", listener.HTTPAddr(), listener.DNSAddr(), settings.PublicHost)
+	return listener
+}
+
+// sendOOBProbes sends the family's {{oob}} templates with a fresh correlation id each:
+// one per parameter in the query, or once per endpoint as the body for xml-vector families.
+// It returns how many were sent.
+func (a *App) sendOOBProbes(ctx context.Context, client *http.Client, lastByHost map[string]time.Time, step string, family injectionFamilyConfig, endpoint string, params []string) int {
+	if len(family.OOB) == 0 {
+		return 0
+	}
+	listener := a.startOOB()
+	if listener == nil {
+		return 0
+	}
+	xmlBody := family.HasVector("xml")
+	if xmlBody {
+		params = []string{""}
+	}
+	sent := 0
+	for _, param := range params {
+		for _, tmpl := range family.OOB {
+			if ctx.Err() != nil {
+				return sent
+			}
+			id := oob.NewID()
+			payload, ok := listener.Expand(tmpl, id)
+			if !ok {
+				continue
+			}
+			probe := &oobProbe{
+				Timestamp: time.Now().UTC().Format(time.RFC3339),
+				ID:        id,
+				Step:      step,
+				Family:    family.Name,
+				Endpoint:  endpoint,
+				Method:    http.MethodGet,
+				Param:     param,
+				Vector:    "url-query",
+				Payload:   payload,
+			}
+			var headers map[string]string
+			var body []byte
+			if xmlBody {
+				probe.Method, probe.Vector, probe.MutatedURL = http.MethodPost, "xml-body", endpoint
+				headers = map[string]string{"Content-Type": "application/xml"}
+				for k, v := range family.Headers {
+					headers[k] = v
+				}
+				body = []byte(payload)
+			} else if probe.MutatedURL = mutateURLQuery(endpoint, param, payload); probe.MutatedURL == "" {
+				continue
+			}
+			a.registerOOBProbe(probe)
+			obs, err := a.sendParamFuzzRequest(ctx, client, lastByHost, probe.MutatedURL, probe.Method, headers, body, "")
+			a.oobProbeSent(probe, obs, err)
+			sent++
+		}
+	}
+	return sent
+}
+
+func (a *App) registerOOBProbe(probe *oobProbe) {
+	a.oobMu.Lock()
+	defer a.oobMu.Unlock()
+	a.oobProbes[probe.ID] = probe
+	if err := os.MkdirAll(a.oobDir(), 0o755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(filepath.Join(a.oobDir(), "payloads.jsonl"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		a.logger.Printf("oob: %v", err)
+		return
+	}
+	defer f.Close()
+	_ = writeJSONLine(bufio.NewWriter(f), probe)
+}
+
+func (a *App) oobProbeSent(probe *oobProbe, obs paramFuzzObservation, err error) {
+	a.oobMu.Lock()
+	probe.sent = true
+	if err == nil {
+		probe.exchange = obs.Exchange
+		probe.SessionProfile = obs.Session
+	}
+	pending := probe.pending
+	probe.pending = nil
+	a.oobMu.Unlock()
+	for _, in := range pending {
+		a.writeOOBHit(probe, in)
+	}
+}
+
+// handleOOBInteraction runs on the listener's goroutines. Ids no longer held in memory
+// (their step finished waiting, or an earlier run sent them) are looked up in
+// payloads.jsonl, so late callbacks still resolve.
+func (a *App) handleOOBInteraction(in oob.Interaction) {
+	a.oobMu.Lock()
+	probe := a.oobProbes[in.ID]
+	if probe == nil {
+		probe = a.loadOOBProbe(in.ID)
+	}
+	if probe == nil {
+		a.oobMu.Unlock()
+		a.logger.Printf("oob: %s interaction %s from %s matches no sent payload", in.Protocol, in.ID, in.Remote)
+		return
+	}
+	if !probe.sent {
+		probe.pending = append(probe.pending, in)
+		a.oobMu.Unlock()
+		return
+	}
+	a.oobMu.Unlock()
+	a.writeOOBHit(probe, in)
+}
+
+func (a *App) loadOOBProbe(id string) *oobProbe {
+	for _, line := range readSafeLines(filepath.Join(a.oobDir(), "payloads.jsonl")) {
+		var probe oobProbe
+		if json.Unmarshal([]byte(line), &probe) != nil || probe.ID != id {
+			continue
+		}
+		probe.sent = true
+		return &probe
+	}
+	return nil
+}
+
+func (a *App) writeOOBHit(probe *oobProbe, in oob.Interaction) {
+	hit := oobHit{
+		Timestamp:      in.Timestamp,
+		Family:         probe.Family,
+		Severity:       "high",
+		Step:           probe.Step,
+		Endpoint:       probe.Endpoint,
+		Method:         probe.Method,
+		Param:          probe.Param,
+		Vector:         probe.Vector,
+		Payload:        probe.Payload,
+		MutatedURL:     probe.MutatedURL,
+		CorrelationID:  probe.ID,
+		Protocol:       in.Protocol,
+		Remote:         in.Remote,
+		SentAt:         probe.Timestamp,
+		Interaction:    in.Raw,
+		Reasons:        []string{"oob_interaction:" + in.Protocol},
+		ManualAction:   oobManualActions[probe.Family],
+		EvidenceIDs:    a.saveEvidence(probe.exchange),
+		SessionProfile: probe.SessionProfile,
+	}
+	a.logger.Printf("oob: %s callback for %s %s param=%s from %s", in.Protocol, probe.Family, probe.Endpoint, probe.Param, in.Remote)
+
+	a.oobMu.Lock()
+	defer a.oobMu.Unlock()
+	if err := os.MkdirAll(a.oobDir(), 0o755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(filepath.Join(a.oobDir(), "interactions.jsonl"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		a.logger.Printf("oob: %v", err)
+		return
+	}
+	defer f.Close()
+	_ = writeJSONLine(bufio.NewWriter(f), hit)
+}
+
+// waitForOOB holds a step open for late callbacks after it sent probes, then drops the
+// step's probes from memory.
+func (a *App) waitForOOB(ctx context.Context, step string, sent int) {
+	defer a.evictOOBProbes(step)
+	wait := time.Duration(a.cfg.OOB.WaitSeconds) * time.Second
+	if sent == 0 || wait <= 0 {
+		return
+	}
+	a.logger.Printf("%s: sent %d oob probe(s); waiting %s for callbacks", step, sent, wait)
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+func (a *App) evictOOBProbes(step string) {
+	a.oobMu.Lock()
+	defer a.oobMu.Unlock()
+	for id, probe := range a.oobProbes {
+		if probe.Step == step && probe.sent {
+			delete(a.oobProbes, id)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rojo/hack/web_bounty_flow/pkg/config"
+)
+
+// TestOOBLoopback sends an {{oob}} probe to a target that fetches whatever URL it is
+// given, and checks the callback on 127.0.0.1 is tied back to the probe that carried it.
+func TestOOBLoopback(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{}
+	cfg.Lists.Domains = filepath.Join(dir, "domains.txt")
+	cfg.Paths.FuzzingDir = filepath.Join(dir, "fuzzing")
+	cfg.OOB = config.OOB{Enabled: true, PublicHost: "127.0.0.1", HTTPListen: "127.0.0.1:0"}
+	a := New(cfg, log.New(io.Discard, "", 0), io.Discard, nil, nil)
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if resp, err := http.Get(r.URL.Query().Get("url")); err == nil {
+			resp.Body.Close()
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	family := injectionFamilyConfig{Name: "ssrf", OOB: []string{"http://{{oob}}/ssrf"}}
+	sent := a.sendOOBProbes(context.Background(), target.Client(), map[string]time.Time{}, StepServerInputChk, family, target.URL+"/fetch?url=x", []string{"url"})
+	if a.oobListener == nil {
+		t.Fatal("listener did not start")
+	}
+	defer a.oobListener.Close()
+	if sent != 1 {
+		t.Fatalf("sent %d probes, want 1", sent)
+	}
+
+	lines := readSafeLines(filepath.Join(a.oobDir(), "interactions.jsonl"))
+	if len(lines) != 1 {
+		t.Fatalf("got %d interactions, want 1", len(lines))
+	}
+	var hit oobHit
+	if err := json.Unmarshal([]byte(lines[0]), &hit); err != nil {
+		t.Fatal(err)
+	}
+	if hit.Family != "ssrf" || hit.Param != "url" || hit.Protocol != "http" {
+		t.Fatalf("hit not tied to the probe: %+v", hit)
+	}
+	if hit.CorrelationID == "" || !strings.Contains(hit.Payload, hit.CorrelationID) || !strings.Contains(hit.Interaction, hit.CorrelationID) {
+		t.Fatalf("correlation id %q missing from payload %q or interaction", hit.CorrelationID, hit.Payload)
+	}
+	if len(hit.EvidenceIDs) == 0 {
+		t.Fatal("hit carries no evidence")
+	}
+
+	a.evictOOBProbes(StepServerInputChk)
+	if len(a.oobProbes) != 0 {
+		t.Fatalf("%d probes left after eviction", len(a.oobProbes))
+	}
+}
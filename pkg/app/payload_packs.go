@@ -0,0 +1,66 @@
+package app
+
+import (
+	"strings"
+
+	"github.com/rojo/hack/web_bounty_flow/pkg/payloads"
+)
+
+// payloadFamilies returns the step's families from the default pack and the packs
+// enabled in flow.yaml. config.Load has already validated the packs; if one changed on
+// disk since and no longer loads, the run falls back to the default pack.
+func (a *App) payloadFamilies(step string) []injectionFamilyConfig {
+	a.packsMu.Lock()
+	defer a.packsMu.Unlock()
+	if a.packs == nil {
+		set, err := payloads.Load(a.cfg.PayloadPacks.Dirs, a.cfg.PayloadPacks.AllowDestructive)
+		if err != nil {
+			a.logger.Printf("payload packs: %v; using the default pack only", err)
+			set, _ = payloads.Load(nil, false)
+		}
+		if len(set.Skipped) > 0 {
+			a.logger.Printf("payload packs: skipped destructive families %s (set payload_packs.allow_destructive to send them)", strings.Join(set.Skipped, ", "))
+		}
+		a.packs = set
+	}
+	return a.packs.Families(step)
+}
+
+// mutatePayloads adds the variants produced by the family's mutation chains when
+// mutation is on for host: on WAF-protected hosts with waf.evasion, or everywhere with
+// mutations.all_hosts. xml-vector families are never mutated since their payload is the
+// whole body. The returned map holds the transform chain behind each variant; original
+// payloads are absent from it.
+func (a *App) mutatePayloads(family injectionFamilyConfig, host string) ([]string, map[string][]string) {
+	if len(family.Mutations) == 0 || family.HasVector("xml") || !a.mutationsEnabled(host) {
+		return family.Payloads, nil
+	}
+	return payloads.Variants(family.Payloads, family.Mutations)
+}
+
+func (a *App) mutationsEnabled(host string) bool {
+	if a.cfg.Mutations.AllHosts {
+		return true
+	}
+	if !a.cfg.WAF.Evasion {
+		return false
+	}
+	state, ok := a.wafHostStates()[strings.ToLower(strings.TrimSpace(host))]
+	return ok && state.Protected
+}
+
+// headerSafePayload reports whether payload can be sent as a header or cookie value;
+// net/http refuses values carrying NUL, CR or LF, as null_byte and whitespace_newline
+// variants do.
+func headerSafePayload(payload string) bool {
+	return !strings.ContainsAny(payload, "\x00\r\n")
+}
+
+// specParamVector maps where a structured request carries a parameter to the pack vector
+// that covers it; JSON bodies are "body" in apiRequestParam.
+func specParamVector(in string) string {
+	if in == "body" {
+		return "json"
+	}
+	return in
+}
@@ -0,0 +1,392 @@
+package app
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	reflectionCanaryPrefix = "bflowfuzz"
+	reflectionMaxContexts  = 8
+)
+
+// reflectionProbeChars are sent in one request, each behind its own marker, to see which
+// survive unencoded. Characters that end a cookie value come last.
+var reflectionProbeChars = []string{"<", ">", `"`, "'", "`", "(", ")", "/", `\`, "{", "}", "$", ":", "-", "=", " ", ";"}
+
+// reflectionBreakouts lists, per context, the character sets that each allow leaving it.
+var reflectionBreakouts = map[string][][]string{
+	"html_text":               {{"<", ">"}},
+	"html_comment":            {{"-", ">"}},
+	"attribute_double_quoted": {{`"`}},
+	"attribute_single_quoted": {{"'"}},
+	"attribute_unquoted":      {{" "}, {">"}},
+	"tag":                     {{" "}, {">"}},
+	"script":                  {{"(", ")"}, {"<", "/"}},
+	"script_string_double":    {{`"`}, {"<", "/"}},
+	"script_string_single":    {{"'"}, {"<", "/"}},
+	"script_template":         {{"`"}, {"$", "{"}, {"<", "/"}},
+	"json":                    {{`"`}},
+}
+
+var reflectionPayloadHints = map[string]string{
+	"html_text":               `<svg onload=alert(1)>`,
+	"html_comment":            `--><svg onload=alert(1)>`,
+	"attribute_double_quoted": `" autofocus onfocus=alert(1) x="`,
+	"attribute_single_quoted": `' autofocus onfocus=alert(1) x='`,
+	"attribute_unquoted":      ` autofocus onfocus=alert(1) `,
+	"tag":                     ` autofocus onfocus=alert(1) `,
+	"script":                  `alert(1)`,
+	"script_string_double":    `";alert(1)//`,
+	"script_string_single":    `';alert(1)//`,
+	"script_template":         `${alert(1)}`,
+}
+
+var reflectionURLAttributes = []string{"href", "src", "action", "formaction", "data", "srcdoc", "poster"}
+
+// reflectionProbe describes where a canary was sent so the character probe can repeat
+// the request with a different value.
+type reflectionProbe struct {
+	endpoint string
+	method   string
+	param    string
+	vector   string
+	query    bool
+	send     func(value string) (paramFuzzObservation, error)
+}
+
+type reflectionHit struct {
+	Timestamp      string   `json:"timestamp"`
+	Mode           string   `json:"mode"`
+	Family         string   `json:"family"`
+	Severity       string   `json:"severity"`
+	Endpoint       string   `json:"endpoint"`
+	Method         string   `json:"method"`
+	Param          string   `json:"param"`
+	Vector         string   `json:"vector"`
+	MutatedURL     string   `json:"mutated_url"`
+	Canary         string   `json:"canary"`
+	Payload        string   `json:"payload"`
+	Contexts       []string `json:"contexts"`
+	Survived       []string `json:"survived_chars"`
+	Encoded        []string `json:"encoded_chars"`
+	StatusCode     int      `json:"status_code"`
+	ContentType    string   `json:"content_type,omitempty"`
+	Reasons        []string `json:"reasons"`
+	ManualAction   string   `json:"manual_action,omitempty"`
+	EvidenceIDs    []string `json:"evidence_ids,omitempty"`
+	SessionProfile string   `json:"session_profile,omitempty"`
+}
+
+// reflectionCanary returns a fresh lowercase marker; response bodies are compared
+// lowercased, and a per-request value ties every reflection to one parameter.
+func reflectionCanary() string {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return reflectionCanaryPrefix + fmt.Sprintf("%08x", time.Now().UnixNano()&0xffffffff)
+	}
+	return reflectionCanaryPrefix + hex.EncodeToString(buf)
+}
+
+// openReflectionHits recreates fuzzing/xss/reflected_hits.jsonl, keeping rows written by
+// the Playwright scan, which shares the file.
+func (a *App) openReflectionHits() (*os.File, *bufio.Writer, error) {
+	path := filepath.Join(a.fuzzingBaseDir(), "xss", "reflected_hits.jsonl")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, nil, err
+	}
+	var keep []string
+	for _, line := range readSafeLines(path) {
+		var row map[string]any
+		if err := json.Unmarshal([]byte(line), &row); err == nil && asString(row["mode"]) == StepParamFuzz {
+			continue
+		}
+		keep = append(keep, line)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	w := bufio.NewWriter(f)
+	for _, line := range keep {
+		_, _ = w.WriteString(line + "\n")
+	}
+	return f, w, nil
+}
+
+// checkReflection looks for canary in obs and, when it comes back, repeats the request
+// with the character probe to see which special characters survive in that context.
+func (a *App) checkReflection(probe reflectionProbe, obs paramFuzzObservation, canary string) (reflectionHit, bool) {
+	contexts := reflectionContexts(obs, canary)
+	if len(contexts) == 0 {
+		return reflectionHit{}, false
+	}
+	hit := reflectionHit{
+		Timestamp:      time.Now().UTC().Format(time.RFC3339),
+		Mode:           StepParamFuzz,
+		Family:         "reflected_xss",
+		Endpoint:       probe.endpoint,
+		Method:         probe.method,
+		Param:          probe.param,
+		Vector:         probe.vector,
+		MutatedURL:     probe.endpoint,
+		Canary:         canary,
+		Contexts:       contexts,
+		StatusCode:     obs.StatusCode,
+		ContentType:    strings.TrimSpace(strings.SplitN(obs.Headers.Get("Content-Type"), ";", 2)[0]),
+		SessionProfile: obs.Session,
+	}
+	for _, c := range contexts {
+		hit.Reasons = append(hit.Reasons, "reflected:"+c)
+	}
+
+	marker := reflectionCanary()
+	hit.Payload = reflectionCharProbe(marker)
+	if probe.query {
+		hit.MutatedURL = mutateURLQuery(probe.endpoint, probe.param, hit.Payload)
+	}
+	charObs, err := probe.send(hit.Payload)
+	switch {
+	case err != nil:
+		hit.Reasons = append(hit.Reasons, "char_probe_failed")
+	case !strings.Contains(charObs.Body, marker):
+		hit.Reasons = append(hit.Reasons, fmt.Sprintf("char_probe_not_reflected:%d", charObs.StatusCode))
+	default:
+		hit.Survived, hit.Encoded = reflectionSurvivors(charObs.Body, marker)
+	}
+
+	breakout, scripted := false, false
+	for _, c := range contexts {
+		base, attr, _ := strings.Cut(c, ":")
+		if reflectionCanBreakOut(base, hit.Survived) {
+			breakout = true
+			hit.Reasons = append(hit.Reasons, "breakout:"+base)
+		}
+		if strings.HasPrefix(base, "attribute_") && strings.HasPrefix(attr, "on") {
+			scripted = true
+			hit.Reasons = append(hit.Reasons, "event_handler_attribute:"+attr)
+		}
+		if strings.HasSuffix(c, "@start") && containsAnyString(hit.Survived, ":") {
+			scripted = true
+			hit.Reasons = append(hit.Reasons, "javascript_url_possible:"+strings.TrimSuffix(attr, "@start"))
+		}
+	}
+	hit.Reasons = unique(hit.Reasons)
+
+	switch {
+	case breakout || scripted:
+		hit.Severity = "high"
+	case reflectionInMarkup(contexts):
+		hit.Severity = "medium"
+	default:
+		hit.Severity = "low"
+	}
+	hit.ManualAction = reflectionManualAction(contexts, hit.Survived)
+	hit.EvidenceIDs = a.saveEvidence(obs.Exchange, charObs.Exchange)
+	return hit, true
+}
+
+// reflectionContexts classifies every place canary appears: response headers first,
+// then each body occurrence up to reflectionMaxContexts. Attribute contexts carry the
+// attribute name ("attribute_double_quoted:href"), with "@start" when the canary opens
+// the value.
+func reflectionContexts(obs paramFuzzObservation, canary string) []string {
+	canary = strings.ToLower(canary)
+	var out []string
+	for name, values := range obs.Headers {
+		for _, v := range values {
+			if strings.Contains(strings.ToLower(v), canary) {
+				out = append(out, "header:"+strings.ToLower(name))
+				break
+			}
+		}
+	}
+	contentType := strings.ToLower(obs.Headers.Get("Content-Type"))
+	body := obs.Body
+	for offset, found := 0, 0; found < reflectionMaxContexts; found++ {
+		idx := strings.Index(body[offset:], canary)
+		if idx < 0 {
+			break
+		}
+		pos := offset + idx
+		out = append(out, classifyReflection(body, pos, contentType))
+		offset = pos + len(canary)
+	}
+	return unique(out)
+}
+
+func classifyReflection(body string, pos int, contentType string) string {
+	switch {
+	case strings.Contains(contentType, "json"):
+		return "json"
+	case contentType != "" && !strings.Contains(contentType, "html") && !strings.Contains(contentType, "xml"):
+		return "text"
+	}
+	before := body[:pos]
+	if strings.LastIndex(before, "<!--") > strings.LastIndex(before, "-->") {
+		return "html_comment"
+	}
+	if open := strings.LastIndex(before, "<script"); open >= 0 && open > strings.LastIndex(before, "</script") {
+		if end := strings.Index(before[open:], ">"); end >= 0 {
+			return scriptReflectionContext(before[open+end+1:])
+		}
+	}
+	if lt := strings.LastIndex(before, "<"); lt >= 0 && lt > strings.LastIndex(before, ">") {
+		return attributeReflectionContext(before[lt:])
+	}
+	return "html_text"
+}
+
+// scriptReflectionContext reports whether the end of script sits inside a string
+// literal. Comments are not tracked, so an apostrophe in one can mislead it.
+func scriptReflectionContext(script string) string {
+	var quote byte
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		}
+	}
+	switch quote {
+	case '"':
+		return "script_string_double"
+	case '\'':
+		return "script_string_single"
+	case '`':
+		return "script_template"
+	}
+	return "script"
+}
+
+// attributeReflectionContext classifies a reflection inside an open tag; tag runs from
+// the "<" up to the canary.
+func attributeReflectionContext(tag string) string {
+	var quote byte
+	valueStart := -1
+	for i := 1; i < len(tag); i++ {
+		c := tag[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			valueStart = i
+		}
+	}
+	var context, head, value string
+	switch quote {
+	case '"':
+		context = "attribute_double_quoted"
+	case '\'':
+		context = "attribute_single_quoted"
+	}
+	if context != "" {
+		head, value = tag[:valueStart], tag[valueStart+1:]
+	} else {
+		token := tag[strings.LastIndexAny(tag, " \t\r\n/")+1:]
+		eq := strings.Index(token, "=")
+		if eq < 0 {
+			return "tag"
+		}
+		context = "attribute_unquoted"
+		head, value = tag[:len(tag)-len(token)+eq+1], token[eq+1:]
+	}
+	head = strings.TrimRight(strings.TrimSpace(head), "=")
+	name := strings.TrimSpace(head[strings.LastIndexAny(head, " \t\r\n/\"'")+1:])
+	if name == "" {
+		return context
+	}
+	if strings.TrimSpace(value) == "" && containsAnyString(reflectionURLAttributes, name) {
+		name += "@start"
+	}
+	return context + ":" + name
+}
+
+func reflectionCharProbe(marker string) string {
+	var b strings.Builder
+	for i, ch := range reflectionProbeChars {
+		b.WriteString(marker)
+		b.WriteByte('a' + byte(i))
+		b.WriteString(ch)
+	}
+	return b.String()
+}
+
+func reflectionSurvivors(body, marker string) (survived, encoded []string) {
+	for i, ch := range reflectionProbeChars {
+		if strings.Contains(body, marker+string(rune('a'+i))+ch) {
+			survived = append(survived, ch)
+		} else {
+			encoded = append(encoded, ch)
+		}
+	}
+	return survived, encoded
+}
+
+func reflectionCanBreakOut(context string, survived []string) bool {
+	for _, set := range reflectionBreakouts[context] {
+		ok := true
+		for _, ch := range set {
+			if !containsAnyString(survived, ch) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func reflectionInMarkup(contexts []string) bool {
+	for _, c := range contexts {
+		if strings.HasPrefix(c, "attribute_") || strings.HasPrefix(c, "script") || c == "tag" {
+			return true
+		}
+	}
+	return false
+}
+
+func reflectionManualAction(contexts, survived []string) string {
+	var hints []string
+	for _, c := range contexts {
+		base, _, _ := strings.Cut(c, ":")
+		if hint, ok := reflectionPayloadHints[base]; ok {
+			hints = append(hints, fmt.Sprintf("%s: %s", base, hint))
+		}
+		if strings.HasSuffix(c, "@start") {
+			hints = append(hints, "url attribute: javascript:alert(1)")
+		}
+		if base == "header" {
+			hints = append(hints, "header: try CRLF (%0d%0a) to inject headers")
+		}
+	}
+	sort.Strings(hints)
+	chars := "none"
+	if len(survived) > 0 {
+		chars = strings.Join(survived, " ")
+	}
+	action := fmt.Sprintf("Input reflects in %s; unencoded characters: %s.", strings.Join(contexts, ", "), chars)
+	if len(hints) > 0 {
+		action += " Confirm in a browser with a context-fit payload (" + strings.Join(unique(hints), "; ") + ")."
+	}
+	return action
+}
@@ -0,0 +1,420 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	requestImportMaxBody  = 64 << 10
+	requestImportMaxInput = 256 << 20
+)
+
+// requestImportDropHeaders are recomputed by the HTTP client or would turn replays into
+// conditional 304s; everything else, including Cookie and Authorization, is kept in the
+// corpus (replays drop credentials unless replay.captured_credentials is set).
+var requestImportDropHeaders = map[string]struct{}{
+	"host": {}, "content-length": {}, "connection": {}, "keep-alive": {}, "transfer-encoding": {},
+	"accept-encoding": {}, "upgrade": {}, "te": {}, "proxy-connection": {}, "proxy-authorization": {},
+	"if-none-match": {}, "if-modified-since": {}, "content-type": {},
+}
+
+// RequestImportSummary reports what an import added to the request corpus.
+type RequestImportSummary struct {
+	Format   string `json:"format"`
+	Parsed   int    `json:"parsed"`
+	Imported int    `json:"imported"`
+	Skipped  int    `json:"skipped"`
+	Total    int    `json:"total"`
+}
+
+type harDocument struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				Method  string `json:"method"`
+				URL     string `json:"url"`
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+				PostData *struct {
+					MimeType string `json:"mimeType"`
+					Text     string `json:"text"`
+					Params   []struct {
+						Name  string `json:"name"`
+						Value string `json:"value"`
+					} `json:"params"`
+				} `json:"postData"`
+			} `json:"request"`
+			Response struct {
+				Status int `json:"status"`
+			} `json:"response"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+type burpItems struct {
+	Items []struct {
+		URL      string `xml:"url"`
+		Method   string `xml:"method"`
+		Status   string `xml:"status"`
+		MimeType string `xml:"mimetype"`
+		Request  struct {
+			Base64 string `xml:"base64,attr"`
+			Data   string `xml:",chardata"`
+		} `xml:"request"`
+	} `xml:"item"`
+}
+
+// RequestCorpusPath is the imported request corpus under a data dir. Like spec uploads it
+// is operator input, so it lives outside recon/ and survives clearing results.
+func RequestCorpusPath(baseDir string) string {
+	return filepath.Join(baseDir, "inputs", "request_corpus.jsonl")
+}
+
+func (a *App) requestCorpusPath() string {
+	return RequestCorpusPath(filepath.Dir(a.cfg.Lists.Domains))
+}
+
+// requestCorpusURLs returns the in-scope URLs of imported requests, in corpus order,
+// keeping only those sent with one of methods when any are given.
+func (a *App) requestCorpusURLs(methods ...string) []string {
+	scoped := a.hostScopeFilter()
+	var out []string
+	for _, req := range readRequestCorpus(a.requestCorpusPath()) {
+		if len(methods) > 0 && !containsAnyString(methods, req.Method) {
+			continue
+		}
+		parsed, err := url.Parse(req.URL)
+		if err != nil || !scoped(strings.ToLower(parsed.Hostname())) {
+			continue
+		}
+		out = append(out, req.URL)
+	}
+	return unique(out)
+}
+
+// ImportRequests parses a HAR file or Burp XML export and merges its requests into the
+// request corpus. format may be "har", "burp" or empty to detect it from the content.
+func (a *App) ImportRequests(format string, r io.Reader, source string) (RequestImportSummary, error) {
+	summary := RequestImportSummary{Format: strings.ToLower(strings.TrimSpace(format))}
+	data, err := io.ReadAll(io.LimitReader(r, requestImportMaxInput))
+	if err != nil {
+		return summary, err
+	}
+	if summary.Format == "" {
+		summary.Format = detectRequestImportFormat(data)
+	}
+	source = summary.Format + ":" + filepath.Base(strings.TrimSpace(source))
+
+	var parsed []apiRequest
+	switch summary.Format {
+	case "har":
+		parsed, err = parseHARRequests(data, source)
+	case "burp":
+		parsed, err = parseBurpRequests(data, source)
+	default:
+		return summary, fmt.Errorf("unsupported import format %q (expected har or burp)", format)
+	}
+	if err != nil {
+		return summary, err
+	}
+	summary.Parsed = len(parsed)
+
+	existing := readRequestCorpus(a.requestCorpusPath())
+	seen := make(map[string]struct{}, len(existing))
+	for _, req := range existing {
+		seen[requestCorpusKey(req)] = struct{}{}
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	var urls []string
+	for _, req := range parsed {
+		key := requestCorpusKey(req)
+		if _, ok := seen[key]; ok {
+			summary.Skipped++
+			continue
+		}
+		seen[key] = struct{}{}
+		req.Timestamp = now
+		existing = append(existing, req)
+		urls = append(urls, req.URL)
+		summary.Imported++
+	}
+	summary.Total = len(existing)
+
+	if err := os.MkdirAll(filepath.Dir(a.requestCorpusPath()), 0o755); err != nil {
+		return summary, err
+	}
+	if err := writeRequestCorpus(a.requestCorpusPath(), existing); err != nil {
+		return summary, err
+	}
+	// url-corpus folds the corpus in on every run; merging now lets a resumed run see it too.
+	reconDir := filepath.Join(filepath.Dir(a.cfg.Lists.Domains), "recon")
+	if err := os.MkdirAll(reconDir, 0o755); err != nil {
+		return summary, err
+	}
+	if err := mergeLinesIntoFile(filepath.Join(reconDir, "all_urls.txt"), urls); err != nil {
+		return summary, err
+	}
+	if err := a.mergeURLCorpusSource("import", urls); err != nil {
+		return summary, err
+	}
+	a.logger.Printf("request import: format=%s parsed=%d imported=%d skipped=%d total=%d", summary.Format, summary.Parsed, summary.Imported, summary.Skipped, summary.Total)
+	return summary, nil
+}
+
+func detectRequestImportFormat(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return ""
+	}
+	if trimmed[0] == '{' {
+		return "har"
+	}
+	if trimmed[0] == '<' && bytes.Contains(trimmed[:min(len(trimmed), 4096)], []byte("<items")) {
+		return "burp"
+	}
+	return ""
+}
+
+func parseHARRequests(data []byte, source string) ([]apiRequest, error) {
+	var doc harDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid har: %w", err)
+	}
+	var out []apiRequest
+	for _, entry := range doc.Log.Entries {
+		headers := make(map[string]string)
+		contentType := ""
+		for _, h := range entry.Request.Headers {
+			if strings.EqualFold(h.Name, "content-type") {
+				contentType = h.Value
+			}
+			headers[h.Name] = h.Value
+		}
+		body := ""
+		if pd := entry.Request.PostData; pd != nil {
+			if contentType == "" {
+				contentType = pd.MimeType
+			}
+			body = pd.Text
+			if body == "" && len(pd.Params) > 0 {
+				form := url.Values{}
+				for _, p := range pd.Params {
+					form.Add(p.Name, p.Value)
+				}
+				body = form.Encode()
+			}
+		}
+		req, ok := buildImportedRequest(source, "har", entry.Request.Method, entry.Request.URL, headers, contentType, body)
+		if !ok {
+			continue
+		}
+		req.StatusCode = entry.Response.Status
+		out = append(out, req)
+	}
+	return out, nil
+}
+
+func parseBurpRequests(data []byte, source string) ([]apiRequest, error) {
+	var doc burpItems
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid burp xml: %w", err)
+	}
+	var out []apiRequest
+	for _, item := range doc.Items {
+		raw := []byte(item.Request.Data)
+		if strings.EqualFold(item.Request.Base64, "true") {
+			decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(item.Request.Data))
+			if err != nil {
+				continue
+			}
+			raw = decoded
+		}
+		method, headers, body := parseRawHTTPRequest(raw)
+		if method == "" {
+			method = item.Method
+		}
+		contentType := ""
+		for name, value := range headers {
+			if strings.EqualFold(name, "content-type") {
+				contentType = value
+			}
+		}
+		req, ok := buildImportedRequest(source, "burp", method, strings.TrimSpace(item.URL), headers, contentType, body)
+		if !ok {
+			continue
+		}
+		req.StatusCode, _ = strconv.Atoi(strings.TrimSpace(item.Status))
+		out = append(out, req)
+	}
+	return out, nil
+}
+
+// parseRawHTTPRequest splits a raw HTTP/1.x request into method, headers and body. The
+// URL is taken from the export item instead of the request line, which may be relative.
+func parseRawHTTPRequest(raw []byte) (string, map[string]string, string) {
+	head, body := raw, []byte(nil)
+	if i := bytes.Index(raw, []byte("\r\n\r\n")); i >= 0 {
+		head, body = raw[:i], raw[i+4:]
+	} else if i := bytes.Index(raw, []byte("\n\n")); i >= 0 {
+		head, body = raw[:i], raw[i+2:]
+	}
+	lines := strings.Split(strings.ReplaceAll(string(head), "\r\n", "\n"), "\n")
+	if len(lines) == 0 {
+		return "", nil, ""
+	}
+	method := ""
+	if fields := strings.Fields(lines[0]); len(fields) > 0 {
+		method = fields[0]
+	}
+	headers := make(map[string]string)
+	for _, line := range lines[1:] {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return method, headers, string(body)
+}
+
+func buildImportedRequest(source, format, method, rawURL string, headers map[string]string, contentType, body string) (apiRequest, bool) {
+	req := apiRequest{Source: source, Format: format, Method: strings.ToUpper(strings.TrimSpace(method))}
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || req.Method == "" {
+		return req, false
+	}
+	if _, ok := jsStaticExts[strings.ToLower(path.Ext(parsed.Path))]; ok {
+		return req, false
+	}
+	parsed.Fragment = ""
+	req.URL = parsed.String()
+	req.PathTemplate = parsed.EscapedPath()
+	req.ContentType = strings.TrimSpace(contentType)
+	if len(body) > requestImportMaxBody {
+		return req, false
+	}
+	req.Body = body
+
+	for name, value := range headers {
+		lower := strings.ToLower(strings.TrimSpace(name))
+		if lower == "" || strings.HasPrefix(lower, ":") {
+			continue
+		}
+		if _, drop := requestImportDropHeaders[lower]; drop {
+			continue
+		}
+		if lower == "cookie" || lower == "authorization" || apiSpecCredentialHeader(lower) {
+			req.Security = append(req.Security, lower)
+		}
+		if req.Headers == nil {
+			req.Headers = make(map[string]string)
+		}
+		req.Headers[name] = value
+	}
+	req.Security = unique(req.Security)
+	req.AuthRequired = len(req.Security) > 0
+
+	for name, values := range parsed.Query() {
+		example := ""
+		if len(values) > 0 {
+			example = values[0]
+		}
+		req.Params = append(req.Params, apiRequestParam{Name: name, In: "query", Example: example})
+	}
+	lowerType := strings.ToLower(req.ContentType)
+	switch {
+	case strings.Contains(lowerType, "json"):
+		var doc map[string]any
+		if err := json.Unmarshal([]byte(body), &doc); err == nil {
+			for _, name := range apiSpecSortedKeys(doc) {
+				req.Params = append(req.Params, apiRequestParam{
+					Name:    name,
+					In:      "body",
+					Type:    apiSpecValueType(doc[name]),
+					Example: apiSpecExampleString(doc[name], name),
+				})
+			}
+		}
+	case strings.Contains(lowerType, "x-www-form-urlencoded"):
+		if form, err := url.ParseQuery(body); err == nil {
+			for name, values := range form {
+				example := ""
+				if len(values) > 0 {
+					example = values[0]
+				}
+				req.Params = append(req.Params, apiRequestParam{Name: name, In: "form", Example: example})
+			}
+		}
+	}
+	sort.SliceStable(req.Params, func(i, j int) bool {
+		if req.Params[i].In != req.Params[j].In {
+			return req.Params[i].In < req.Params[j].In
+		}
+		return req.Params[i].Name < req.Params[j].Name
+	})
+	return req, true
+}
+
+func requestCorpusKey(req apiRequest) string {
+	return req.Method + " " + req.URL + " " + req.Body
+}
+
+// readRequestCorpus uses its own scanner because imported bodies can push a JSON line past
+// bufio.Scanner's default 64KB token limit, which readSafeLines would silently stop at.
+func readRequestCorpus(path string) []apiRequest {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var out []apiRequest
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64<<10), requestImportMaxBody*8)
+	for scanner.Scan() {
+		var req apiRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil || req.URL == "" || req.Method == "" {
+			continue
+		}
+		out = append(out, req)
+	}
+	return out
+}
+
+func writeRequestCorpus(path string, requests []apiRequest) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, req := range requests {
+		if err := writeJSONLine(w, req); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
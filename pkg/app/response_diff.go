@@ -0,0 +1,364 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	responseDiffSamples       = 3
+	responseDiffBodyLimit     = 32 << 10
+	responseDiffMinSimilarity = 0.8
+	responseDiffMaxNotes      = 6
+	responseDiffMinProbe      = 5
+)
+
+var (
+	responseDiffDynamicRes = []*regexp.Regexp{
+		regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`),
+		regexp.MustCompile(`\d{4}-\d{2}-\d{2}[t ]\d{2}:\d{2}(:\d{2})?(\.\d+)?(z|[+-]\d{2}:?\d{2})?`),
+		regexp.MustCompile(`\b\d{1,2}:\d{2}:\d{2}\b`),
+		regexp.MustCompile(`\b[0-9a-f]{16,}\b`),
+		regexp.MustCompile(`[a-z0-9+/_-]{24,}={0,2}`),
+		regexp.MustCompile(`\d{5,}`),
+	}
+	responseDiffSegmentRe = regexp.MustCompile(`\n|>\s*<|[{},\[\]]`)
+
+	// Headers that change between identical requests or only describe the transport.
+	responseDiffIgnoredHeaders = map[string]struct{}{
+		"date": {}, "age": {}, "expires": {}, "last-modified": {}, "etag": {}, "set-cookie": {},
+		"content-length": {}, "transfer-encoding": {}, "connection": {}, "keep-alive": {},
+		"cf-ray": {}, "x-request-id": {}, "x-amz-cf-id": {}, "x-amzn-requestid": {}, "x-amzn-trace-id": {},
+		"x-cache": {}, "x-cache-hits": {}, "x-served-by": {}, "x-timer": {}, "via": {}, "server-timing": {},
+		"x-runtime": {}, "x-correlation-id": {}, "traceparent": {}, "report-to": {}, "nel": {},
+	}
+)
+
+// responseBaseline is several samples of the same unmodified request. The embedded
+// observation is the first sample, so callers keep reading StatusCode, Length and so on;
+// the rest records what the endpoint varies on its own: body segments that differ
+// between samples, headers that come and go, and the spread of lengths and timings.
+type responseBaseline struct {
+	paramFuzzObservation
+	samples  []paramFuzzObservation
+	stable   map[uint64]struct{}
+	volatile map[uint64]struct{}
+	headers  map[string]string
+	statuses map[int]struct{}
+	lenMin   int
+	lenMax   int
+	durMean  float64
+	durStd   float64
+}
+
+// responseDiff explains how a mutated response departs from its baseline. Similarity is
+// the share of stable body segments both responses agree on (1 = same structure).
+// Blocked names the WAF whose block page answered the probe instead of the application.
+type responseDiff struct {
+	Similarity float64  `json:"similarity"`
+	Samples    int      `json:"baseline_samples"`
+	Volatile   int      `json:"volatile_segments,omitempty"`
+	Blocked    string   `json:"waf_blocked,omitempty"`
+	Notes      []string `json:"notes,omitempty"`
+}
+
+// sampleBaseline sends the unmodified request responseDiffSamples times. It fails only
+// when no sample comes back; the returned baseline is never nil.
+func (a *App) sampleBaseline(ctx context.Context, client *http.Client, lastByHost map[string]time.Time, target, method string, headers map[string]string, body []byte, cookie string) (*responseBaseline, error) {
+	var samples []paramFuzzObservation
+	var firstErr error
+	for i := 0; i < responseDiffSamples; i++ {
+		obs, err := a.sendParamFuzzRequest(ctx, client, lastByHost, target, method, headers, body, cookie)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		samples = append(samples, obs)
+	}
+	if len(samples) == 0 {
+		if firstErr == nil {
+			firstErr = fmt.Errorf("no baseline sample")
+		}
+		return newResponseBaseline(), firstErr
+	}
+	return newResponseBaseline(samples...), nil
+}
+
+func newResponseBaseline(samples ...paramFuzzObservation) *responseBaseline {
+	b := &responseBaseline{
+		samples:  samples,
+		stable:   make(map[uint64]struct{}),
+		volatile: make(map[uint64]struct{}),
+		headers:  make(map[string]string),
+		statuses: make(map[int]struct{}),
+	}
+	if len(samples) == 0 {
+		return b
+	}
+	b.paramFuzzObservation = samples[0]
+
+	counts := make(map[uint64]int)
+	headerSeen := make(map[string]int)
+	headerValues := make(map[string]map[string]struct{})
+	var durations []float64
+	b.lenMin, b.lenMax = samples[0].Length, samples[0].Length
+	for _, s := range samples {
+		for seg := range responseSegments(s.text(), "") {
+			counts[seg]++
+		}
+		for name, values := range s.Headers {
+			name = strings.ToLower(name)
+			headerSeen[name]++
+			if headerValues[name] == nil {
+				headerValues[name] = make(map[string]struct{})
+			}
+			headerValues[name][strings.Join(values, ",")] = struct{}{}
+		}
+		b.statuses[s.StatusCode] = struct{}{}
+		b.lenMin = min(b.lenMin, s.Length)
+		b.lenMax = max(b.lenMax, s.Length)
+		durations = append(durations, float64(s.DurationMS))
+	}
+	for seg, n := range counts {
+		if n == len(samples) {
+			b.stable[seg] = struct{}{}
+		} else {
+			b.volatile[seg] = struct{}{}
+		}
+	}
+	for name, n := range headerSeen {
+		if n != len(samples) {
+			continue
+		}
+		// A header whose value varies stays tracked by name only.
+		value := ""
+		if len(headerValues[name]) == 1 {
+			for v := range headerValues[name] {
+				value = v
+			}
+		}
+		b.headers[name] = value
+	}
+	for _, d := range durations {
+		b.durMean += d
+	}
+	b.durMean /= float64(len(durations))
+	for _, d := range durations {
+		b.durStd += (d - b.durMean) * (d - b.durMean)
+	}
+	b.durStd = math.Sqrt(b.durStd / float64(len(durations)))
+	return b
+}
+
+// diff compares mutated with the baseline. probe is the injected value: its echoes are
+// removed before the structural comparison so a plain reflection does not count as a
+// changed page (reflection is a separate signal). A WAF block page the baseline did not
+// get means the probe never reached the application, so it yields no reasons and only
+// sets Blocked.
+func (b *responseBaseline) diff(mutated paramFuzzObservation, probe string) ([]string, responseDiff) {
+	d := responseDiff{Similarity: 1, Samples: len(b.samples), Volatile: len(b.volatile)}
+	if len(b.samples) == 0 {
+		return nil, d
+	}
+	var reasons []string
+	note := func(format string, args ...any) {
+		if len(d.Notes) < responseDiffMaxNotes {
+			d.Notes = append(d.Notes, fmt.Sprintf(format, args...))
+		}
+	}
+	if vendor := wafBlockPageVendor(mutated.Snippet); vendor != "" && wafBlockPageVendor(b.Snippet) == "" {
+		d.Blocked = vendor
+		note("%s block page, status %d", vendor, mutated.StatusCode)
+		return nil, d
+	}
+
+	if _, seen := b.statuses[mutated.StatusCode]; !seen {
+		changed := mutated.StatusCode >= 500
+		for code := range b.statuses {
+			if statusCodeClass(code) != statusCodeClass(mutated.StatusCode) || code >= 500 {
+				changed = true
+			}
+		}
+		if changed {
+			reasons = append(reasons, "status_code_changed")
+			note("status %d, baseline %s", mutated.StatusCode, b.statusList())
+		}
+	}
+
+	lenThreshold := max(80, int(float64(b.lenMax)*0.35), 2*(b.lenMax-b.lenMin))
+	if outside := max(b.lenMin-mutated.Length, mutated.Length-b.lenMax, 0); outside > lenThreshold {
+		reasons = append(reasons, "response_length_changed")
+		note("length %d outside baseline %d-%d", mutated.Length, b.lenMin, b.lenMax)
+	}
+
+	locationChanged := true
+	for _, s := range b.samples {
+		if !redirectTargetMeaningfullyChanged(s.Location, mutated.Location) {
+			locationChanged = false
+			break
+		}
+	}
+	if locationChanged {
+		reasons = append(reasons, "redirect_target_changed")
+		note("redirect to %q", mutated.Location)
+	}
+
+	timingThreshold := max(b.durMean*3, b.durMean+4*b.durStd) + 1500
+	if float64(mutated.DurationMS) > timingThreshold {
+		reasons = append(reasons, "timing_spike")
+		note("took %dms, baseline mean %.0fms sd %.0fms", mutated.DurationMS, b.durMean, b.durStd)
+	}
+
+	text := mutated.text()
+	for _, kw := range paramFuzzSignalKeywords {
+		if strings.Contains(text, kw) && !b.anySampleContains(kw) && !strings.Contains(strings.ToLower(probe), kw) {
+			reasons = append(reasons, "new_signal_keyword:"+kw)
+		}
+	}
+
+	segments := responseSegments(text, probe)
+	shared, union := 0, 0
+	for seg := range segments {
+		if _, ok := b.volatile[seg]; ok {
+			continue
+		}
+		union++
+		if _, ok := b.stable[seg]; ok {
+			shared++
+		}
+	}
+	for seg := range b.stable {
+		if _, ok := segments[seg]; !ok {
+			union++
+		}
+	}
+	if union > 0 {
+		d.Similarity = math.Round(float64(shared)/float64(union)*1000) / 1000
+	}
+	if d.Similarity < responseDiffMinSimilarity {
+		reasons = append(reasons, "body_structure_changed")
+		note("body similarity %.2f over %d stable segment(s), %d volatile ignored", d.Similarity, len(b.stable), len(b.volatile))
+	}
+
+	mutatedHeaders := make(map[string]string, len(mutated.Headers))
+	for name, values := range mutated.Headers {
+		mutatedHeaders[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	for _, name := range sortedKeys(mutatedHeaders) {
+		if _, ignored := responseDiffIgnoredHeaders[name]; ignored || b.anySampleHasHeader(name) {
+			continue
+		}
+		reasons = append(reasons, "header_added:"+name)
+	}
+	for _, name := range sortedKeys(b.headers) {
+		if _, ignored := responseDiffIgnoredHeaders[name]; ignored {
+			continue
+		}
+		value, ok := mutatedHeaders[name]
+		if !ok {
+			reasons = append(reasons, "header_removed:"+name)
+			continue
+		}
+		if name == "content-type" && b.headers[name] != "" && !strings.EqualFold(b.headers[name], value) {
+			reasons = append(reasons, "content_type_changed")
+			note("content-type %q, baseline %q", value, b.headers[name])
+		}
+	}
+	return unique(reasons), d
+}
+
+func (b *responseBaseline) statusList() string {
+	var codes []string
+	for code := range b.statuses {
+		codes = append(codes, fmt.Sprint(code))
+	}
+	sort.Strings(codes)
+	return strings.Join(codes, "/")
+}
+
+func (b *responseBaseline) anySampleContains(keyword string) bool {
+	for _, s := range b.samples {
+		if strings.Contains(s.text(), keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *responseBaseline) anySampleMatches(re *regexp.Regexp) bool {
+	for _, s := range b.samples {
+		if re.MatchString(s.text()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *responseBaseline) anySampleHasHeader(name string) bool {
+	for _, s := range b.samples {
+		for key := range s.Headers {
+			if strings.EqualFold(key, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// text is the lowercased body kept for diffing, falling back to the snippet for
+// observations captured without one.
+func (o paramFuzzObservation) text() string {
+	if o.Body != "" {
+		return o.Body
+	}
+	return o.Snippet
+}
+
+// responseSegments splits a body into lines, tags and JSON members with dynamic tokens
+// (uuids, timestamps, hex ids, long tokens, counters) normalized away, and returns their
+// hashes. Echoes of probe, raw or encoded, are dropped first; probes shorter than
+// responseDiffMinProbe would also erase ordinary text and are left alone.
+func responseSegments(body, probe string) map[uint64]struct{} {
+	if probe = strings.ToLower(strings.TrimSpace(probe)); len(probe) >= responseDiffMinProbe {
+		for _, form := range []string{probe, html.EscapeString(probe), strings.ToLower(url.QueryEscape(probe))} {
+			body = strings.ReplaceAll(body, form, "")
+		}
+	}
+	for _, re := range responseDiffDynamicRes {
+		body = re.ReplaceAllString(body, "~")
+	}
+	out := make(map[uint64]struct{})
+	for _, part := range responseDiffSegmentRe.Split(body, -1) {
+		part = strings.Join(strings.Fields(part), " ")
+		if part == "" || part == "~" {
+			continue
+		}
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(part))
+		out[h.Sum64()] = struct{}{}
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
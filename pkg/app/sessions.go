@@ -0,0 +1,371 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rojo/hack/web_bounty_flow/pkg/configstore"
+)
+
+const (
+	sessionSuspectThreshold = 6
+	sessionCheckInterval    = 5 * time.Minute
+	sessionPausePoll        = 15 * time.Second
+	sessionPauseLimit       = 15 * time.Minute
+)
+
+var sessionLoginHints = []string{"login", "signin", "sign-in", "sign_in", "logon", "/sso", "/auth", "expired"}
+
+// sessionProfile is a loaded profile plus its logged-out tracking. mu is held for the
+// whole of a pause, which is what holds back every request using the profile; hosts and
+// applied have their own lock so requests to other hosts can still be matched, and
+// evidence redacted, during a pause. applied keeps every value the run sent, so evidence
+// captured before a restore is still redacted.
+type sessionProfile struct {
+	mu        sync.Mutex
+	id        string
+	name      string
+	value     string
+	profile   configstore.SessionProfile
+	expired   bool
+	suspect   int
+	lastCheck time.Time
+
+	hostsMu sync.RWMutex
+	hosts   []string
+	applied []configstore.SessionProfile
+}
+
+// sessionEvent is one line of recon/session_events.jsonl. Leads found on Hosts between
+// an "expired" event and the next "restored" or "loaded" one (a run starting with the
+// profile) are flagged by the server.
+type sessionEvent struct {
+	Timestamp string   `json:"timestamp"`
+	Profile   string   `json:"profile"`
+	Event     string   `json:"event"`
+	Hosts     []string `json:"hosts,omitempty"`
+	URL       string   `json:"url,omitempty"`
+	Reason    string   `json:"reason,omitempty"`
+}
+
+type sessionOffKey struct{}
+
+// withoutSession marks requests made under ctx as deliberately anonymous (authz checks
+// that must prove an endpoint answers without credentials).
+func withoutSession(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionOffKey{}, true)
+}
+
+// SetSessionProfile selects the session profile (label or key id) for the next run.
+// Empty falls back to every active profile in the config store.
+func (a *App) SetSessionProfile(name string) {
+	a.sessionName = strings.TrimSpace(name)
+}
+
+func (a *App) sessionEventsPath() string {
+	return filepath.Join(filepath.Dir(a.cfg.Lists.Domains), "recon", "session_events.jsonl")
+}
+
+func (a *App) loadSessionProfiles() error {
+	a.sessions = nil
+	if a.configStore == nil {
+		if a.sessionName != "" {
+			return fmt.Errorf("session profile %q requested but the config store is not available", a.sessionName)
+		}
+		return nil
+	}
+	cfg, err := a.configStore.LoadDecrypted()
+	if err != nil {
+		return err
+	}
+	provider := cfg.Providers[configstore.SessionProvider]
+	if provider == nil {
+		if a.sessionName != "" {
+			return fmt.Errorf("session profile %q not found", a.sessionName)
+		}
+		return nil
+	}
+	for _, key := range provider.Keys {
+		if a.sessionName != "" {
+			if key.ID != a.sessionName && !strings.EqualFold(key.Label, a.sessionName) {
+				continue
+			}
+		} else if !key.Active {
+			continue
+		}
+		profile, err := configstore.ParseSessionProfile(key.Value)
+		if err != nil {
+			a.logger.Printf("session profile %q skipped: %v", key.Label, err)
+			continue
+		}
+		a.sessions = append(a.sessions, &sessionProfile{id: key.ID, name: key.Label, value: key.Value, profile: profile, hosts: profile.Hosts, applied: []configstore.SessionProfile{profile}})
+	}
+	if a.sessionName != "" && len(a.sessions) == 0 {
+		return fmt.Errorf("session profile %q not found", a.sessionName)
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, s := range a.sessions {
+		hosts := "all hosts"
+		if len(s.profile.Hosts) > 0 {
+			hosts = strings.Join(s.profile.Hosts, ",")
+		}
+		a.logger.Printf("session profile %q applied to %s", s.name, hosts)
+		a.writeSessionEvent(sessionEvent{Timestamp: now, Profile: s.name, Event: "loaded", Hosts: s.profile.Hosts})
+	}
+	return nil
+}
+
+// applySession attaches the profile bound to the request host.
+func (a *App) applySession(req *http.Request) *sessionProfile {
+	if len(a.sessions) == 0 || req.Context().Value(sessionOffKey{}) != nil {
+		return nil
+	}
+	host := strings.ToLower(req.URL.Hostname())
+	for _, s := range a.sessions {
+		s.hostsMu.RLock()
+		scope := configstore.SessionProfile{Hosts: s.hosts}
+		s.hostsMu.RUnlock()
+		if !scope.AppliesTo(host) {
+			continue
+		}
+		s.mu.Lock()
+		profile := s.profile
+		s.mu.Unlock()
+		profile.Apply(req)
+		return s
+	}
+	return nil
+}
+
+// observeSession watches authenticated responses for signs of a dead session. A run of
+// logged-out looking responses, or the periodic check, triggers a CheckURL probe; without
+// a CheckURL the streak alone expires the session.
+func (a *App) observeSession(ctx context.Context, s *sessionProfile, target string, status int, location, snippet string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.expired {
+		s.mu.Unlock()
+		return
+	}
+	if sessionLooksLoggedOut(s.profile, status, location, snippet) {
+		s.suspect++
+	} else {
+		s.suspect = 0
+	}
+	verify := s.suspect >= sessionSuspectThreshold ||
+		(s.profile.CheckURL != "" && time.Since(s.lastCheck) > sessionCheckInterval)
+	profile := s.profile
+	suspect := s.suspect
+	s.mu.Unlock()
+	if !verify {
+		return
+	}
+
+	reason := fmt.Sprintf("%d consecutive logged-out responses", suspect)
+	if profile.CheckURL != "" {
+		alive, why := a.checkSessionAlive(ctx, profile)
+		s.mu.Lock()
+		s.lastCheck = time.Now()
+		if alive {
+			s.suspect = 0
+		}
+		s.mu.Unlock()
+		if alive || ctx.Err() != nil {
+			return
+		}
+		reason = why
+	}
+	a.expireSession(ctx, s, target, reason)
+}
+
+func sessionLooksLoggedOut(profile configstore.SessionProfile, status int, location, snippet string) bool {
+	if status == http.StatusUnauthorized {
+		return true
+	}
+	if status >= 300 && status < 400 {
+		location = strings.ToLower(location)
+		for _, hint := range sessionLoginHints {
+			if strings.Contains(location, hint) {
+				return true
+			}
+		}
+	}
+	snippet = strings.ToLower(snippet)
+	for _, marker := range profile.LoggedOutMarkers {
+		if marker = strings.ToLower(strings.TrimSpace(marker)); marker != "" && strings.Contains(snippet, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *App) checkSessionAlive(ctx context.Context, profile configstore.SessionProfile) (bool, string) {
+	client := a.newHTTPClient(paramFuzzRequestTimeout, false)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, profile.CheckURL, nil)
+	if err != nil {
+		return true, ""
+	}
+	profile.Apply(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		// An unreachable check page says nothing about the session.
+		return true, ""
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
+	location := strings.TrimSpace(resp.Header.Get("Location"))
+	if resp.StatusCode == http.StatusForbidden || sessionLooksLoggedOut(profile, resp.StatusCode, location, string(body)) {
+		return false, fmt.Sprintf("check_url answered %d %s", resp.StatusCode, location)
+	}
+	return true, ""
+}
+
+// expireSession flags the profile as logged out in the event log and the config store.
+// With on_logout "pause" it then holds the profile until its value is edited (a fresh
+// cookie or token) or sessionPauseLimit passes.
+func (a *App) expireSession(ctx context.Context, s *sessionProfile, target, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.expired {
+		return
+	}
+	s.expired = true
+	now := time.Now()
+	a.logger.Printf("session profile %q looks logged out (%s); results from now on are flagged", s.name, reason)
+	a.writeSessionEvent(sessionEvent{Timestamp: now.UTC().Format(time.RFC3339), Profile: s.name, Event: "expired", Hosts: s.profile.Hosts, URL: target, Reason: reason})
+	if a.configStore != nil {
+		_ = a.configStore.UpdateTokenUsage(configstore.SessionProvider, s.id, now, "logged out: "+reason)
+	}
+	if s.profile.OnLogout != "pause" || a.configStore == nil {
+		return
+	}
+
+	a.logger.Printf("session profile %q: pausing authenticated requests until the profile is updated (max %s)", s.name, sessionPauseLimit)
+	deadline := time.Now().Add(sessionPauseLimit)
+	for time.Now().Before(deadline) {
+		timer := time.NewTimer(sessionPausePoll)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		value, ok := a.storedSessionValue(s.id)
+		if !ok || value == s.value {
+			continue
+		}
+		profile, err := configstore.ParseSessionProfile(value)
+		if err != nil {
+			continue
+		}
+		s.profile, s.value = profile, value
+		s.expired, s.suspect, s.lastCheck = false, 0, time.Now()
+		s.hostsMu.Lock()
+		s.hosts = profile.Hosts
+		s.applied = append(s.applied, profile)
+		s.hostsMu.Unlock()
+		a.logger.Printf("session profile %q updated; resuming authenticated requests", s.name)
+		_ = a.configStore.UpdateTokenUsage(configstore.SessionProvider, s.id, time.Now(), "")
+		a.writeSessionEvent(sessionEvent{Timestamp: time.Now().UTC().Format(time.RFC3339), Profile: s.name, Event: "restored", Hosts: s.profile.Hosts})
+		return
+	}
+	a.logger.Printf("session profile %q was not updated within %s; continuing with flagged results", s.name, sessionPauseLimit)
+}
+
+func (a *App) storedSessionValue(id string) (string, bool) {
+	cfg, err := a.configStore.LoadDecrypted()
+	if err != nil {
+		return "", false
+	}
+	provider := cfg.Providers[configstore.SessionProvider]
+	if provider == nil {
+		return "", false
+	}
+	for _, key := range provider.Keys {
+		if key.ID == id {
+			return key.Value, true
+		}
+	}
+	return "", false
+}
+
+func (a *App) writeSessionEvent(event sessionEvent) {
+	path := a.sessionEventsPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		a.logger.Printf("session events: %v", err)
+		return
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	_ = writeJSONLine(w, event)
+}
+
+// appliedSessionProfiles returns every profile value sent during the run.
+func (a *App) appliedSessionProfiles() []configstore.SessionProfile {
+	var out []configstore.SessionProfile
+	for _, s := range a.sessions {
+		s.hostsMu.RLock()
+		out = append(out, s.applied...)
+		s.hostsMu.RUnlock()
+	}
+	return out
+}
+
+// redactSessionHeader masks what a session profile added to one request header: its
+// cookie pairs, its bearer token or one of its custom header values. Anything else, such
+// as a probe's own cookie or header payload, is kept.
+func redactSessionHeader(profiles []configstore.SessionProfile, name, value string) (string, bool) {
+	if strings.EqualFold(name, "Cookie") {
+		pairs := strings.Split(value, ";")
+		redacted := false
+		for i, pair := range pairs {
+			pairs[i] = strings.TrimSpace(pair)
+			for _, p := range profiles {
+				if sessionCookieHas(p.Cookie, pairs[i]) {
+					cookie, _, _ := strings.Cut(pairs[i], "=")
+					pairs[i] = cookie + "=[redacted]"
+					redacted = true
+					break
+				}
+			}
+		}
+		return strings.Join(pairs, "; "), redacted
+	}
+	for _, p := range profiles {
+		if p.Bearer != "" && strings.EqualFold(name, "Authorization") && value == "Bearer "+p.Bearer {
+			return "[redacted]", true
+		}
+		for header, v := range p.Headers {
+			if strings.EqualFold(header, name) && v == value {
+				return "[redacted]", true
+			}
+		}
+	}
+	return value, false
+}
+
+func sessionCookieHas(cookie, pair string) bool {
+	if pair == "" {
+		return false
+	}
+	for _, part := range strings.Split(cookie, ";") {
+		if strings.TrimSpace(part) == pair {
+			return true
+		}
+	}
+	return false
+}
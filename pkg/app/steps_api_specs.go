@@ -0,0 +1,1251 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	apiSpecMaxSpecs         = 40
+	apiSpecMaxRequests      = 1000
+	apiSpecBodyLimit        = 8 << 20
+	apiSpecSchemaDepth      = 5
+	apiSpecMaxFuzzRequests  = 60
+	apiSpecMaxAuthzRequests = 150
+)
+
+type apiRequestParam struct {
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Type     string `json:"type,omitempty"`
+	Required bool   `json:"required,omitempty"`
+	Example  string `json:"example"`
+}
+
+// apiRequest is one concrete request, either expanded from an OpenAPI, Swagger or Postman
+// document or imported from proxy history. URL, Headers and Body carry example or recorded
+// values; PathTemplate keeps any {name} placeholders so path parameters can be re-filled.
+type apiRequest struct {
+	Timestamp    string            `json:"timestamp"`
+	Source       string            `json:"source"`
+	Format       string            `json:"format"`
+	OperationID  string            `json:"operation_id,omitempty"`
+	Method       string            `json:"method"`
+	URL          string            `json:"url"`
+	PathTemplate string            `json:"path_template"`
+	Params       []apiRequestParam `json:"params,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	ContentType  string            `json:"content_type,omitempty"`
+	Body         string            `json:"body,omitempty"`
+	AuthRequired bool              `json:"auth_required"`
+	Security     []string          `json:"security,omitempty"`
+	StatusCode   int               `json:"status_code,omitempty"`
+}
+
+type apiSpecAuthzFinding struct {
+	Timestamp    string   `json:"timestamp"`
+	Endpoint     string   `json:"endpoint"`
+	Method       string   `json:"method"`
+	OperationID  string   `json:"operation_id,omitempty"`
+	Source       string   `json:"source"`
+	Security     []string `json:"security,omitempty"`
+	StatusCode   int      `json:"status_code"`
+	Length       int      `json:"length"`
+	Family       string   `json:"family"`
+	Severity     string   `json:"severity"`
+	Reasons      []string `json:"reasons"`
+	ManualAction string   `json:"manual_action"`
+	EvidenceIDs  []string `json:"evidence_ids,omitempty"`
+}
+
+var (
+	apiSpecURLRe       = regexp.MustCompile(`(?i)(swagger|openapi|api-docs|postman)[^/]*$|/v[0-9]+/api-docs(/|$)`)
+	apiSpecPathParamRe = regexp.MustCompile(`\{([^{}/]+)\}`)
+	apiSpecVariableRe  = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+	apiSpecMethods     = []string{"get", "put", "post", "delete", "options", "head", "patch"}
+)
+
+func (a *App) apiSpecsDir() string {
+	return filepath.Join(filepath.Dir(a.cfg.Lists.Domains), "recon", "api_specs")
+}
+
+// APISpecUploadsDir is where operator-uploaded spec documents live under a data dir. It
+// sits outside recon/ so clearing results keeps them.
+func APISpecUploadsDir(baseDir string) string {
+	return filepath.Join(baseDir, "inputs", "api_specs")
+}
+
+func (a *App) apiRequestsPath() string {
+	return filepath.Join(a.apiSpecsDir(), "api_requests.jsonl")
+}
+
+func (a *App) runAPISpecs(ctx context.Context) error {
+	requests, err := a.expandAPISpecs(ctx)
+	if err != nil {
+		return err
+	}
+	return a.checkAPISpecAuthz(ctx, requests)
+}
+
+// expandAPISpecs fetches spec documents found by fuzz-docs or seen in the URL corpus,
+// parses them together with operator uploads and rewrites api_requests.jsonl.
+func (a *App) expandAPISpecs(ctx context.Context) ([]apiRequest, error) {
+	specsDir := a.apiSpecsDir()
+	if err := os.MkdirAll(specsDir, 0o755); err != nil {
+		return nil, err
+	}
+	uploadsDir := APISpecUploadsDir(filepath.Dir(a.cfg.Lists.Domains))
+	reconDir := filepath.Dir(specsDir)
+	scoped := a.hostScopeFilter()
+
+	var specURLs []string
+	for _, line := range readSafeLines(filepath.Join(a.fuzzingDocsDir(), "doc_hits.txt")) {
+		if u := normalizeFFUFHitURL(line); u != "" {
+			specURLs = append(specURLs, u)
+		}
+	}
+	for _, line := range readSafeLines(filepath.Join(reconDir, "all_urls.txt")) {
+		u := normalizeFFUFHitURL(line)
+		if u == "" {
+			continue
+		}
+		if parsed, err := url.Parse(u); err == nil && apiSpecURLRe.MatchString(strings.TrimRight(parsed.Path, "/")) {
+			specURLs = append(specURLs, u)
+		}
+	}
+	specURLs = unique(specURLs)
+	if len(specURLs) > apiSpecMaxSpecs {
+		a.logger.Printf("%s: limiting spec urls from %d to %d", StepAPISpecs, len(specURLs), apiSpecMaxSpecs)
+		specURLs = specURLs[:apiSpecMaxSpecs]
+	}
+
+	metrics := map[string]int{"fetched": 0, "uploaded": 0, "parsed": 0, "requests": 0, "out_of_scope": 0}
+	var requests []apiRequest
+	client := a.newHTTPClient(paramFuzzRequestTimeout*2, true)
+	lastByHost := make(map[string]time.Time)
+	for _, specURL := range specURLs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		parsed, err := url.Parse(specURL)
+		if err != nil || !scoped(strings.ToLower(parsed.Hostname())) {
+			continue
+		}
+		host := strings.ToLower(parsed.Hostname())
+		if last, ok := lastByHost[host]; ok {
+			if wait := a.hostRequestDelay(host) - time.Since(last); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return nil, ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+		body, ok := fetchAPISpec(ctx, client, specURL)
+		lastByHost[host] = time.Now()
+		if !ok {
+			continue
+		}
+		metrics["fetched"]++
+		expanded, err := expandAPISpecDocument(body, specURL, parsed)
+		if err != nil {
+			continue
+		}
+		metrics["parsed"]++
+		requests = append(requests, expanded...)
+	}
+
+	entries, _ := os.ReadDir(uploadsDir)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		body, err := os.ReadFile(filepath.Join(uploadsDir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		metrics["uploaded"]++
+		expanded, err := expandAPISpecDocument(body, "upload:"+entry.Name(), nil)
+		if err != nil {
+			a.logger.Printf("%s: cannot parse uploaded spec %s: %v", StepAPISpecs, entry.Name(), err)
+			continue
+		}
+		metrics["parsed"]++
+		requests = append(requests, expanded...)
+	}
+
+	seen := make(map[string]struct{})
+	var kept []apiRequest
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, req := range requests {
+		parsed, err := url.Parse(req.URL)
+		if err != nil || parsed.Host == "" {
+			continue
+		}
+		if !scoped(strings.ToLower(parsed.Hostname())) {
+			metrics["out_of_scope"]++
+			continue
+		}
+		key := req.Method + " " + req.URL + " " + req.Body
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		req.Timestamp = now
+		kept = append(kept, req)
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		if kept[i].URL != kept[j].URL {
+			return kept[i].URL < kept[j].URL
+		}
+		return kept[i].Method < kept[j].Method
+	})
+	if len(kept) > apiSpecMaxRequests {
+		a.logger.Printf("%s: limiting expanded requests from %d to %d", StepAPISpecs, len(kept), apiSpecMaxRequests)
+		kept = kept[:apiSpecMaxRequests]
+	}
+	metrics["requests"] = len(kept)
+
+	f, err := os.Create(a.apiRequestsPath())
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	defer w.Flush()
+	for _, req := range kept {
+		_ = writeJSONLine(w, req)
+	}
+
+	a.logger.Printf("%s: spec_urls=%d fetched=%d uploaded=%d parsed=%d requests=%d out_of_scope=%d", StepAPISpecs, len(specURLs), metrics["fetched"], metrics["uploaded"], metrics["parsed"], metrics["requests"], metrics["out_of_scope"])
+	return kept, nil
+}
+
+// checkAPISpecAuthz replays GET operations the spec marks as secured without any
+// credentials. A 2xx with a body suggests the documented security is not enforced.
+func (a *App) checkAPISpecAuthz(ctx context.Context, requests []apiRequest) error {
+	outDir := filepath.Join(a.fuzzingBaseDir(), "api-specs")
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(outDir, "authz_findings.jsonl"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	defer w.Flush()
+
+	client := a.newHTTPClient(paramFuzzRequestTimeout, false)
+	lastByHost := make(map[string]time.Time)
+	checked := 0
+	findings := 0
+	for _, req := range requests {
+		if !req.AuthRequired || req.Method != http.MethodGet {
+			continue
+		}
+		if checked >= apiSpecMaxAuthzRequests {
+			a.logger.Printf("%s: authz check limit %d reached", StepAPISpecs, apiSpecMaxAuthzRequests)
+			break
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		checked++
+		obs, err := a.sendAPIRequest(withoutSession(ctx), client, lastByHost, req.withoutCredentials())
+		if err != nil || obs.StatusCode < 200 || obs.StatusCode >= 300 || obs.Length == 0 {
+			continue
+		}
+		if apiSpecLooksLikeLogin(obs.Snippet) {
+			continue
+		}
+		findings++
+		_ = writeJSONLine(w, apiSpecAuthzFinding{
+			Timestamp:    time.Now().UTC().Format(time.RFC3339),
+			Endpoint:     req.URL,
+			Method:       req.Method,
+			OperationID:  req.OperationID,
+			Source:       req.Source,
+			Security:     req.Security,
+			StatusCode:   obs.StatusCode,
+			Length:       obs.Length,
+			Family:       "spec_auth_not_enforced",
+			Severity:     "medium",
+			Reasons:      []string{"spec_requires_auth", "anonymous_2xx"},
+			ManualAction: "The spec declares this operation secured but it answered without credentials: compare the body with an authenticated response and confirm no private data is returned.",
+			EvidenceIDs:  a.saveEvidence(obs.Exchange),
+		})
+	}
+	a.logger.Printf("%s: authz_checked=%d authz_findings=%d", StepAPISpecs, checked, findings)
+	return nil
+}
+
+func apiSpecCredentialHeader(name string) bool {
+	switch strings.ToLower(name) {
+	case "authorization", "cookie", "x-api-key", "api-key", "x-auth-token", "x-access-token":
+		return true
+	}
+	return false
+}
+
+func apiSpecLooksLikeLogin(snippet string) bool {
+	for _, marker := range []string{"type=\"password\"", "unauthorized", "unauthenticated", "login required", "\"error\""} {
+		if strings.Contains(snippet, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func fetchAPISpec(ctx context.Context, client *http.Client, specURL string) ([]byte, bool) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, specURL, nil)
+	if err != nil {
+		return nil, false
+	}
+	req.Header.Set("Accept", "application/json, application/yaml;q=0.9, */*;q=0.5")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, false
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, apiSpecBodyLimit))
+	if err != nil {
+		return nil, false
+	}
+	return body, true
+}
+
+// loadStructuredRequests returns imported proxy-history requests followed by spec-expanded
+// ones, keeping only hosts still in scope and, unless replay.unsafe_methods is set, only
+// methods that should not change state. Imported requests come first because they carry
+// real bodies; their captured credentials are dropped unless replay.captured_credentials
+// is set.
+func (a *App) loadStructuredRequests() []apiRequest {
+	scoped := a.hostScopeFilter()
+	var out []apiRequest
+	unsafe := 0
+	for _, req := range append(readRequestCorpus(a.requestCorpusPath()), readRequestCorpus(a.apiRequestsPath())...) {
+		parsed, err := url.Parse(req.URL)
+		if err != nil || !scoped(strings.ToLower(parsed.Hostname())) {
+			continue
+		}
+		if !a.cfg.Replay.UnsafeMethods && !safeReplayMethod(req.Method) {
+			unsafe++
+			continue
+		}
+		if !a.cfg.Replay.CapturedCredentials {
+			req = req.withoutCredentials()
+		}
+		out = append(out, req)
+	}
+	if unsafe > 0 {
+		a.logger.Printf("structured requests: skipped %d with state-changing methods (set replay.unsafe_methods to send them)", unsafe)
+	}
+	return out
+}
+
+func safeReplayMethod(method string) bool {
+	switch strings.ToUpper(strings.TrimSpace(method)) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func (a *App) sendAPIRequest(ctx context.Context, client *http.Client, lastByHost map[string]time.Time, req apiRequest) (paramFuzzObservation, error) {
+	headers, body := req.wire()
+	return a.sendParamFuzzRequest(ctx, client, lastByHost, req.URL, req.Method, headers, body, "")
+}
+
+func (a *App) sampleAPIBaseline(ctx context.Context, client *http.Client, lastByHost map[string]time.Time, req apiRequest) (*responseBaseline, error) {
+	headers, body := req.wire()
+	return a.sampleBaseline(ctx, client, lastByHost, req.URL, req.Method, headers, body, "")
+}
+
+// withoutCredentials returns a copy of the request without Cookie, Authorization or API
+// key headers.
+func (r apiRequest) withoutCredentials() apiRequest {
+	out := r
+	out.Headers = nil
+	for name, value := range r.Headers {
+		if apiSpecCredentialHeader(strings.TrimSpace(name)) {
+			continue
+		}
+		if out.Headers == nil {
+			out.Headers = make(map[string]string)
+		}
+		out.Headers[name] = value
+	}
+	return out
+}
+
+// wire returns the headers and body sent for the request.
+func (req apiRequest) wire() (map[string]string, []byte) {
+	headers := make(map[string]string, len(req.Headers)+1)
+	for name, value := range req.Headers {
+		headers[name] = value
+	}
+	if req.ContentType != "" {
+		headers["Content-Type"] = req.ContentType
+	}
+	var body []byte
+	if req.Body != "" {
+		body = []byte(req.Body)
+	}
+	return headers, body
+}
+
+// withParam returns a copy of the request with one declared parameter set to value,
+// placed wherever the spec says it lives.
+func (r apiRequest) withParam(param apiRequestParam, value string) (apiRequest, bool) {
+	out := r
+	switch param.In {
+	case "query":
+		mutated := mutateURLQuery(r.URL, param.Name, value)
+		if mutated == "" {
+			return out, false
+		}
+		out.URL = mutated
+	case "path":
+		parsed, err := url.Parse(r.URL)
+		if err != nil {
+			return out, false
+		}
+		examples := make(map[string]string, len(r.Params))
+		for _, p := range r.Params {
+			if p.In == "path" {
+				examples[p.Name] = p.Example
+			}
+		}
+		examples[param.Name] = value
+		path := apiSpecPathParamRe.ReplaceAllStringFunc(r.PathTemplate, func(match string) string {
+			return url.PathEscape(examples[strings.Trim(match, "{}")])
+		})
+		unescaped, err := url.PathUnescape(path)
+		if err != nil {
+			return out, false
+		}
+		parsed.Path = unescaped
+		parsed.RawPath = path
+		out.URL = parsed.String()
+	case "header":
+		out.Headers = make(map[string]string, len(r.Headers)+1)
+		for name, v := range r.Headers {
+			out.Headers[name] = v
+		}
+		out.Headers[param.Name] = value
+	case "body":
+		var doc map[string]any
+		if err := json.Unmarshal([]byte(r.Body), &doc); err != nil || doc == nil {
+			doc = make(map[string]any)
+		}
+		doc[param.Name] = value
+		encoded, err := json.Marshal(doc)
+		if err != nil {
+			return out, false
+		}
+		out.Body = string(encoded)
+	case "form":
+		values, err := url.ParseQuery(r.Body)
+		if err != nil {
+			values = url.Values{}
+		}
+		values.Set(param.Name, value)
+		out.Body = values.Encode()
+	default:
+		return out, false
+	}
+	return out, true
+}
+
+// apiSpecFuzzTargets spreads the fuzz budget across spec sources and hosts by taking
+// requests round-robin per host.
+func apiSpecFuzzTargets(requests []apiRequest, limit int) []apiRequest {
+	byHost := make(map[string][]apiRequest)
+	var hosts []string
+	for _, req := range requests {
+		if len(req.Params) == 0 {
+			continue
+		}
+		parsed, err := url.Parse(req.URL)
+		if err != nil {
+			continue
+		}
+		host := strings.ToLower(parsed.Hostname())
+		if _, ok := byHost[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		byHost[host] = append(byHost[host], req)
+	}
+	sort.Strings(hosts)
+	var out []apiRequest
+	for len(out) < limit {
+		progressed := false
+		for _, host := range hosts {
+			if len(byHost[host]) == 0 || len(out) >= limit {
+				continue
+			}
+			out = append(out, byHost[host][0])
+			byHost[host] = byHost[host][1:]
+			progressed = true
+		}
+		if !progressed {
+			break
+		}
+	}
+	return out
+}
+
+// expandAPISpecDocument detects the document type and expands it. origin is the URL the
+// spec was served from and is nil for uploads; it resolves relative server URLs.
+func expandAPISpecDocument(body []byte, source string, origin *url.URL) ([]apiRequest, error) {
+	root, err := decodeAPISpec(body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case apiSpecString(root["openapi"]) != "":
+		return expandOpenAPI3(root, source, origin), nil
+	case apiSpecString(root["swagger"]) != "":
+		return expandSwagger2(root, source, origin), nil
+	case root["item"] != nil:
+		return expandPostman(root, source), nil
+	}
+	return nil, fmt.Errorf("not an openapi, swagger or postman document")
+}
+
+func decodeAPISpec(body []byte) (map[string]any, error) {
+	var root map[string]any
+	if err := json.Unmarshal(body, &root); err == nil && root != nil {
+		return root, nil
+	}
+	if err := yaml.Unmarshal(body, &root); err != nil {
+		return nil, err
+	}
+	if root == nil {
+		return nil, fmt.Errorf("empty document")
+	}
+	return root, nil
+}
+
+func expandOpenAPI3(root map[string]any, source string, origin *url.URL) []apiRequest {
+	base := ""
+	if servers, ok := root["servers"].([]any); ok {
+		for _, raw := range servers {
+			server, _ := raw.(map[string]any)
+			serverURL := apiSpecString(server["url"])
+			if serverURL == "" {
+				continue
+			}
+			if vars, ok := server["variables"].(map[string]any); ok {
+				for name, rawVar := range vars {
+					v, _ := rawVar.(map[string]any)
+					serverURL = strings.ReplaceAll(serverURL, "{"+name+"}", apiSpecString(v["default"]))
+				}
+			}
+			if resolved := apiSpecResolveBase(serverURL, origin); resolved != "" {
+				base = resolved
+				break
+			}
+		}
+	}
+	if base == "" && origin != nil {
+		base = origin.Scheme + "://" + origin.Host
+	}
+	if base == "" {
+		return nil
+	}
+	return expandAPISpecPaths(root, source, "openapi3", base, nil)
+}
+
+func expandSwagger2(root map[string]any, source string, origin *url.URL) []apiRequest {
+	host := apiSpecString(root["host"])
+	scheme := ""
+	if schemes, ok := root["schemes"].([]any); ok {
+		for _, raw := range schemes {
+			s := strings.ToLower(apiSpecString(raw))
+			if s == "https" || (s == "http" && scheme == "") {
+				scheme = s
+			}
+		}
+	}
+	if origin != nil {
+		if host == "" {
+			host = origin.Host
+		}
+		if scheme == "" {
+			scheme = origin.Scheme
+		}
+	}
+	if host == "" {
+		return nil
+	}
+	if scheme == "" {
+		scheme = "https"
+	}
+	base := scheme + "://" + host + "/" + strings.Trim(apiSpecString(root["basePath"]), "/")
+	var consumes []string
+	if list, ok := root["consumes"].([]any); ok {
+		for _, raw := range list {
+			consumes = append(consumes, apiSpecString(raw))
+		}
+	}
+	return expandAPISpecPaths(root, source, "swagger2", strings.TrimRight(base, "/"), consumes)
+}
+
+func apiSpecResolveBase(serverURL string, origin *url.URL) string {
+	parsed, err := url.Parse(strings.TrimSpace(serverURL))
+	if err != nil {
+		return ""
+	}
+	if !parsed.IsAbs() {
+		if origin == nil {
+			return ""
+		}
+		parsed = origin.ResolveReference(parsed)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return ""
+	}
+	return strings.TrimRight(parsed.String(), "/")
+}
+
+// expandAPISpecPaths walks paths for both OpenAPI 3 and Swagger 2. The two differ only in
+// where parameter schemas and request bodies live, which apiSpecOperationParams handles.
+func expandAPISpecPaths(root map[string]any, source, format, base string, consumes []string) []apiRequest {
+	paths, _ := root["paths"].(map[string]any)
+	pathKeys := make([]string, 0, len(paths))
+	for path := range paths {
+		pathKeys = append(pathKeys, path)
+	}
+	sort.Strings(pathKeys)
+
+	basePath := ""
+	if parsed, err := url.Parse(base); err == nil {
+		basePath = strings.TrimRight(parsed.Path, "/")
+	}
+
+	var out []apiRequest
+	for _, path := range pathKeys {
+		item := apiSpecResolve(root, paths[path])
+		if item == nil {
+			continue
+		}
+		shared, _ := item["parameters"].([]any)
+		for _, method := range apiSpecMethods {
+			op := apiSpecResolve(root, item[method])
+			if op == nil {
+				continue
+			}
+			opParams, _ := op["parameters"].([]any)
+			req := apiRequest{
+				Source:       source,
+				Format:       format,
+				OperationID:  apiSpecString(op["operationId"]),
+				Method:       strings.ToUpper(method),
+				PathTemplate: basePath + "/" + strings.TrimLeft(path, "/"),
+				Headers:      make(map[string]string),
+			}
+			security, ok := op["security"]
+			if !ok {
+				security = root["security"]
+			}
+			req.Security = apiSpecSecurityNames(security)
+			req.AuthRequired = len(req.Security) > 0
+
+			declared := make(map[string]struct{})
+			query := url.Values{}
+			pathValues := make(map[string]string)
+			form := url.Values{}
+			var bodyDoc any
+			// Operation-level parameters override path-level ones with the same name and location.
+			for _, raw := range append(append([]any(nil), opParams...), shared...) {
+				param := apiSpecResolve(root, raw)
+				if param == nil {
+					continue
+				}
+				name := apiSpecString(param["name"])
+				in := apiSpecString(param["in"])
+				if name == "" || in == "" {
+					continue
+				}
+				if _, ok := declared[in+":"+name]; ok {
+					continue
+				}
+				declared[in+":"+name] = struct{}{}
+				if in == "body" {
+					schema := apiSpecResolve(root, param["schema"])
+					bodyDoc = apiSchemaExample(root, schema, 0)
+					continue
+				}
+				schema := param
+				if s := apiSpecResolve(root, param["schema"]); s != nil {
+					schema = s
+				}
+				var example any
+				if v, ok := param["example"]; ok {
+					example = v
+				} else {
+					example = apiSchemaExample(root, schema, 0)
+				}
+				value := apiSpecExampleString(example, name)
+				entry := apiRequestParam{
+					Name:     name,
+					In:       in,
+					Type:     apiSpecSchemaType(schema),
+					Required: param["required"] == true,
+					Example:  value,
+				}
+				switch in {
+				case "query":
+					query.Set(name, value)
+				case "path":
+					pathValues[name] = value
+				case "header":
+					if apiSpecCredentialHeader(name) {
+						continue
+					}
+					req.Headers[name] = value
+				case "formData":
+					entry.In = "form"
+					form.Set(name, value)
+				default:
+					continue
+				}
+				req.Params = append(req.Params, entry)
+			}
+
+			if rb := apiSpecResolve(root, op["requestBody"]); rb != nil {
+				content, _ := rb["content"].(map[string]any)
+				for _, ct := range apiSpecPreferredContentTypes(content) {
+					media, _ := content[ct].(map[string]any)
+					schema := apiSpecResolve(root, media["schema"])
+					var example any
+					if v, ok := media["example"]; ok {
+						example = v
+					} else {
+						example = apiSchemaExample(root, schema, 0)
+					}
+					if strings.Contains(ct, "x-www-form-urlencoded") || strings.Contains(ct, "multipart/form-data") {
+						if doc, ok := example.(map[string]any); ok {
+							for name, v := range doc {
+								value := apiSpecExampleString(v, name)
+								form.Set(name, value)
+								req.Params = append(req.Params, apiRequestParam{Name: name, In: "form", Example: value})
+							}
+						}
+						req.ContentType = "application/x-www-form-urlencoded"
+					} else {
+						bodyDoc = example
+						req.ContentType = ct
+					}
+					break
+				}
+			}
+			if bodyDoc != nil {
+				encoded, err := json.Marshal(bodyDoc)
+				if err == nil {
+					req.Body = string(encoded)
+					if req.ContentType == "" {
+						req.ContentType = "application/json"
+						for _, ct := range consumes {
+							if strings.Contains(ct, "json") {
+								req.ContentType = ct
+								break
+							}
+						}
+					}
+				}
+				if doc, ok := bodyDoc.(map[string]any); ok {
+					for _, name := range apiSpecSortedKeys(doc) {
+						req.Params = append(req.Params, apiRequestParam{
+							Name:    name,
+							In:      "body",
+							Type:    apiSpecValueType(doc[name]),
+							Example: apiSpecExampleString(doc[name], name),
+						})
+					}
+				}
+			} else if len(form) > 0 {
+				req.Body = form.Encode()
+				req.ContentType = "application/x-www-form-urlencoded"
+			}
+
+			req.URL = apiSpecFillURL(base, path, pathValues, query)
+			if req.URL == "" {
+				continue
+			}
+			if len(req.Headers) == 0 {
+				req.Headers = nil
+			}
+			sort.SliceStable(req.Params, func(i, j int) bool {
+				if req.Params[i].In != req.Params[j].In {
+					return req.Params[i].In < req.Params[j].In
+				}
+				return req.Params[i].Name < req.Params[j].Name
+			})
+			out = append(out, req)
+		}
+	}
+	return out
+}
+
+func apiSpecFillURL(base, path string, pathValues map[string]string, query url.Values) string {
+	filled := apiSpecPathParamRe.ReplaceAllStringFunc(path, func(match string) string {
+		name := strings.Trim(match, "{}")
+		value, ok := pathValues[name]
+		if !ok {
+			value = apiSpecExampleString(nil, name)
+		}
+		return url.PathEscape(value)
+	})
+	parsed, err := url.Parse(base + "/" + strings.TrimLeft(filled, "/"))
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+	if len(query) > 0 {
+		parsed.RawQuery = query.Encode()
+	}
+	return parsed.String()
+}
+
+func apiSpecPreferredContentTypes(content map[string]any) []string {
+	var out []string
+	for _, preferred := range []string{"application/json", "application/x-www-form-urlencoded", "multipart/form-data"} {
+		if _, ok := content[preferred]; ok {
+			out = append(out, preferred)
+		}
+	}
+	for _, ct := range apiSpecSortedKeys(content) {
+		if strings.Contains(ct, "json") && !containsAnyString(out, ct) {
+			out = append(out, ct)
+		}
+	}
+	return out
+}
+
+func apiSpecSecurityNames(raw any) []string {
+	list, _ := raw.([]any)
+	var out []string
+	for _, entry := range list {
+		requirement, _ := entry.(map[string]any)
+		for name := range requirement {
+			out = append(out, name)
+		}
+	}
+	return unique(out)
+}
+
+// expandPostman flattens a v2 collection's nested folders into requests, substituting
+// collection variables. Unresolved variables in the host drop the request.
+func expandPostman(root map[string]any, source string) []apiRequest {
+	vars := make(map[string]string)
+	if list, ok := root["variable"].([]any); ok {
+		for _, raw := range list {
+			v, _ := raw.(map[string]any)
+			if key := apiSpecString(v["key"]); key != "" {
+				vars[key] = apiSpecString(v["value"])
+			}
+		}
+	}
+	collectionAuth := apiPostmanAuth(root["auth"])
+
+	var out []apiRequest
+	var walk func(items []any, auth []string)
+	walk = func(items []any, auth []string) {
+		for _, raw := range items {
+			item, _ := raw.(map[string]any)
+			if item == nil {
+				continue
+			}
+			itemAuth := auth
+			if _, ok := item["auth"]; ok {
+				itemAuth = apiPostmanAuth(item["auth"])
+			}
+			if children, ok := item["item"].([]any); ok {
+				walk(children, itemAuth)
+				continue
+			}
+			request, _ := item["request"].(map[string]any)
+			if request == nil {
+				continue
+			}
+			if _, ok := request["auth"]; ok {
+				itemAuth = apiPostmanAuth(request["auth"])
+			}
+			if req, ok := postmanRequest(request, vars, itemAuth, source); ok {
+				req.OperationID = apiSpecString(item["name"])
+				out = append(out, req)
+			}
+		}
+	}
+	items, _ := root["item"].([]any)
+	walk(items, collectionAuth)
+	return out
+}
+
+func postmanRequest(request map[string]any, vars map[string]string, auth []string, source string) (apiRequest, bool) {
+	substitute := func(s string) string {
+		return apiSpecVariableRe.ReplaceAllStringFunc(s, func(match string) string {
+			if v, ok := vars[strings.TrimSpace(strings.Trim(match, "{}"))]; ok {
+				return v
+			}
+			return match
+		})
+	}
+	req := apiRequest{
+		Source:   source,
+		Format:   "postman",
+		Method:   strings.ToUpper(apiSpecString(request["method"])),
+		Headers:  make(map[string]string),
+		Security: auth,
+	}
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+
+	var raw string
+	pathVars := make(map[string]string)
+	switch u := request["url"].(type) {
+	case string:
+		raw = u
+	case map[string]any:
+		raw = apiSpecString(u["raw"])
+		if list, ok := u["variable"].([]any); ok {
+			for _, rawVar := range list {
+				v, _ := rawVar.(map[string]any)
+				if key := apiSpecString(v["key"]); key != "" {
+					pathVars[key] = substitute(apiSpecString(v["value"]))
+				}
+			}
+		}
+	}
+	raw = substitute(strings.TrimSpace(raw))
+	if raw == "" {
+		return req, false
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" || strings.Contains(parsed.Host, "{{") {
+		return req, false
+	}
+
+	segments := strings.Split(parsed.Path, "/")
+	templateSegments := make([]string, len(segments))
+	for i, segment := range segments {
+		templateSegments[i] = segment
+		if strings.HasPrefix(segment, ":") && len(segment) > 1 {
+			name := segment[1:]
+			value := pathVars[name]
+			if value == "" {
+				value = apiSpecExampleString(nil, name)
+			}
+			templateSegments[i] = "{" + name + "}"
+			segments[i] = value
+			req.Params = append(req.Params, apiRequestParam{Name: name, In: "path", Example: value})
+		} else if apiSpecVariableRe.MatchString(segment) {
+			segments[i] = "1"
+		}
+	}
+	parsed.Path = strings.Join(segments, "/")
+	parsed.RawPath = ""
+	req.PathTemplate = strings.Join(templateSegments, "/")
+
+	query := parsed.Query()
+	for name, values := range query {
+		value := ""
+		if len(values) > 0 {
+			value = values[0]
+		}
+		if apiSpecVariableRe.MatchString(value) {
+			value = apiSpecExampleString(nil, name)
+			query.Set(name, value)
+		}
+		req.Params = append(req.Params, apiRequestParam{Name: name, In: "query", Example: value})
+	}
+	parsed.RawQuery = query.Encode()
+	req.URL = parsed.String()
+
+	if headers, ok := request["header"].([]any); ok {
+		for _, rawHeader := range headers {
+			h, _ := rawHeader.(map[string]any)
+			name := apiSpecString(h["key"])
+			if name == "" || h["disabled"] == true {
+				continue
+			}
+			value := substitute(apiSpecString(h["value"]))
+			if strings.EqualFold(name, "content-type") {
+				req.ContentType = value
+				continue
+			}
+			if apiSpecCredentialHeader(name) {
+				if len(req.Security) == 0 {
+					req.Security = []string{strings.ToLower(name)}
+				}
+				continue
+			}
+			req.Headers[name] = value
+			req.Params = append(req.Params, apiRequestParam{Name: name, In: "header", Example: value})
+		}
+	}
+
+	if body, ok := request["body"].(map[string]any); ok {
+		switch apiSpecString(body["mode"]) {
+		case "raw":
+			req.Body = substitute(apiSpecString(body["raw"]))
+			var doc map[string]any
+			if err := json.Unmarshal([]byte(req.Body), &doc); err == nil {
+				if req.ContentType == "" {
+					req.ContentType = "application/json"
+				}
+				for _, name := range apiSpecSortedKeys(doc) {
+					req.Params = append(req.Params, apiRequestParam{
+						Name:    name,
+						In:      "body",
+						Type:    apiSpecValueType(doc[name]),
+						Example: apiSpecExampleString(doc[name], name),
+					})
+				}
+			}
+		case "urlencoded", "formdata":
+			form := url.Values{}
+			fields, _ := body[apiSpecString(body["mode"])].([]any)
+			for _, rawField := range fields {
+				field, _ := rawField.(map[string]any)
+				name := apiSpecString(field["key"])
+				if name == "" || field["disabled"] == true || apiSpecString(field["type"]) == "file" {
+					continue
+				}
+				value := substitute(apiSpecString(field["value"]))
+				form.Set(name, value)
+				req.Params = append(req.Params, apiRequestParam{Name: name, In: "form", Example: value})
+			}
+			req.Body = form.Encode()
+			req.ContentType = "application/x-www-form-urlencoded"
+		}
+	}
+
+	req.AuthRequired = len(req.Security) > 0
+	if len(req.Headers) == 0 {
+		req.Headers = nil
+	}
+	return req, true
+}
+
+func apiPostmanAuth(raw any) []string {
+	auth, _ := raw.(map[string]any)
+	kind := apiSpecString(auth["type"])
+	if kind == "" || kind == "noauth" {
+		return nil
+	}
+	return []string{kind}
+}
+
+// apiSpecResolve follows local $ref pointers, giving up after a few hops so cyclic
+// schemas cannot loop.
+func apiSpecResolve(root map[string]any, node any) map[string]any {
+	current, _ := node.(map[string]any)
+	for hops := 0; current != nil && hops < 8; hops++ {
+		ref := apiSpecString(current["$ref"])
+		if ref == "" {
+			return current
+		}
+		if !strings.HasPrefix(ref, "#/") {
+			return nil
+		}
+		var target any = root
+		for _, part := range strings.Split(ref[2:], "/") {
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			m, _ := target.(map[string]any)
+			target = m[part]
+		}
+		current, _ = target.(map[string]any)
+	}
+	return current
+}
+
+func apiSchemaExample(root map[string]any, schema map[string]any, depth int) any {
+	schema = apiSpecResolve(root, schema)
+	if schema == nil || depth > apiSpecSchemaDepth {
+		return nil
+	}
+	for _, key := range []string{"example", "default", "x-example"} {
+		if v, ok := schema[key]; ok {
+			return v
+		}
+	}
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	if all, ok := schema["allOf"].([]any); ok {
+		merged := make(map[string]any)
+		for _, part := range all {
+			if doc, ok := apiSchemaExample(root, apiSpecResolve(root, part), depth+1).(map[string]any); ok {
+				for k, v := range doc {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if list, ok := schema[key].([]any); ok && len(list) > 0 {
+			return apiSchemaExample(root, apiSpecResolve(root, list[0]), depth+1)
+		}
+	}
+
+	switch apiSpecSchemaType(schema) {
+	case "integer":
+		return 1
+	case "number":
+		return 1.5
+	case "boolean":
+		return true
+	case "array":
+		item := apiSchemaExample(root, apiSpecResolve(root, schema["items"]), depth+1)
+		if item == nil {
+			return []any{}
+		}
+		return []any{item}
+	case "object":
+		doc := make(map[string]any)
+		props, _ := schema["properties"].(map[string]any)
+		for name, prop := range props {
+			if v := apiSchemaExample(root, apiSpecResolve(root, prop), depth+1); v != nil {
+				doc[name] = v
+			} else {
+				doc[name] = apiSpecExampleString(nil, name)
+			}
+		}
+		return doc
+	}
+	return apiSpecStringExample(apiSpecString(schema["format"]))
+}
+
+func apiSpecSchemaType(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, raw := range t {
+			if s := apiSpecString(raw); s != "" && s != "null" {
+				return s
+			}
+		}
+	}
+	if schema["properties"] != nil {
+		return "object"
+	}
+	if schema["items"] != nil {
+		return "array"
+	}
+	return ""
+}
+
+func apiSpecStringExample(format string) string {
+	switch strings.ToLower(format) {
+	case "uuid":
+		return "00000000-0000-4000-8000-000000000001"
+	case "date":
+		return "2024-01-01"
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "email":
+		return "test@example.com"
+	case "uri", "url":
+		return "https://example.com/"
+	case "ipv4":
+		return "127.0.0.1"
+	case "ipv6":
+		return "::1"
+	case "byte":
+		return "dGVzdA=="
+	case "password":
+		return "Passw0rd!"
+	}
+	return "test"
+}
+
+// apiSpecExampleString renders an example for a URL, header or form slot. Missing examples
+// fall back to a guess from the parameter name.
+func apiSpecExampleString(value any, name string) string {
+	switch v := value.(type) {
+	case nil:
+		lower := strings.ToLower(name)
+		switch {
+		case lower == "id" || strings.HasSuffix(lower, "_id") || strings.HasSuffix(name, "Id"):
+			return "1"
+		case strings.Contains(lower, "email"):
+			return "test@example.com"
+		case strings.Contains(lower, "page") || strings.Contains(lower, "limit") || strings.Contains(lower, "size"):
+			return "1"
+		}
+		return "test"
+	case string:
+		return v
+	case bool, int, int64, float64:
+		return fmt.Sprint(v)
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "test"
+	}
+	return string(encoded)
+}
+
+func apiSpecValueType(value any) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64:
+		return "integer"
+	case float64:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return ""
+}
+
+func apiSpecString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case int, int64, float64, bool:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+func apiSpecSortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
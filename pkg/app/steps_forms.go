@@ -0,0 +1,882 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"html"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	formMaxPages        = 250
+	formMaxForms        = 60
+	formMaxCasesPerForm = 24
+	formPageBodyLimit   = 2 << 20
+	formMaxRedirects    = 3
+	formOverflowCap     = 8192
+	formTamperMarker    = "bflowtamper"
+)
+
+var (
+	formBlockRe    = regexp.MustCompile(`(?is)<form\b([^>]*)>(.*?)</form\s*>`)
+	formFieldRe    = regexp.MustCompile(`(?is)<input\b([^>]*)>|<textarea\b([^>]*)>(.*?)</textarea\s*>|<select\b([^>]*)>(.*?)</select\s*>`)
+	formOptionRe   = regexp.MustCompile(`(?is)<option\b([^>]*)>([^<]*)`)
+	formAttrRe     = regexp.MustCompile(`(?s)([a-zA-Z_:][-a-zA-Z0-9_:.]*)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+)))?`)
+	formTokenRe    = regexp.MustCompile(`(?i)(csrf|xsrf|authenticity_token|requestverificationtoken|^_token$|nonce|__viewstate|__eventvalidation)`)
+	formDangerRe   = regexp.MustCompile(`(?i)(log[-_]?out|sign[-_]?out|delete|remove|destroy|unsubscribe|deactivate|close[-_]?account)`)
+	formPrivNameRe = regexp.MustCompile(`(?i)(role|group|admin|level|access|permission|plan|tier|type)`)
+	formPathHints  = []string{"login", "signin", "register", "signup", "account", "profile", "settings", "password", "checkout", "cart", "order", "payment", "contact", "search", "admin", "edit", "update", "subscribe", "feedback", "upload"}
+	formSkipExts   = map[string]struct{}{".js": {}, ".json": {}, ".xml": {}, ".txt": {}, ".pdf": {}, ".map": {}, ".zip": {}, ".gz": {}, ".csv": {}}
+)
+
+type formField struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Value     string   `json:"value,omitempty"`
+	Checked   bool     `json:"checked,omitempty"`
+	Hidden    bool     `json:"hidden,omitempty"`
+	Disabled  bool     `json:"disabled,omitempty"`
+	Readonly  bool     `json:"readonly,omitempty"`
+	Required  bool     `json:"required,omitempty"`
+	MaxLength int      `json:"maxlength,omitempty"`
+	MinLength int      `json:"minlength,omitempty"`
+	Pattern   string   `json:"pattern,omitempty"`
+	Min       string   `json:"min,omitempty"`
+	Max       string   `json:"max,omitempty"`
+	Options   []string `json:"options,omitempty"`
+}
+
+type htmlForm struct {
+	Page      string
+	Index     int
+	Action    string
+	Method    string
+	Enctype   string
+	TokenName string
+	Fields    []formField
+}
+
+type formRecord struct {
+	Timestamp string      `json:"timestamp"`
+	Page      string      `json:"page"`
+	Host      string      `json:"host"`
+	Action    string      `json:"action"`
+	Method    string      `json:"method"`
+	Enctype   string      `json:"enctype"`
+	TokenName string      `json:"token_field,omitempty"`
+	Fields    []formField `json:"fields"`
+	Cases     int         `json:"cases"`
+	Findings  int         `json:"findings"`
+	Skipped   string      `json:"skipped,omitempty"`
+}
+
+type formFinding struct {
+	Timestamp      string        `json:"timestamp"`
+	Page           string        `json:"page"`
+	Endpoint       string        `json:"endpoint"`
+	Host           string        `json:"host"`
+	Method         string        `json:"method"`
+	Enctype        string        `json:"enctype"`
+	Family         string        `json:"family"`
+	Severity       string        `json:"severity"`
+	Param          string        `json:"param"`
+	FieldType      string        `json:"field_type"`
+	Original       string        `json:"original_value,omitempty"`
+	Payload        string        `json:"payload"`
+	Reasons        []string      `json:"reasons"`
+	BaselineCode   int           `json:"baseline_status_code"`
+	MutatedCode    int           `json:"mutated_status_code"`
+	BaselineLen    int           `json:"baseline_length"`
+	MutatedLen     int           `json:"mutated_length"`
+	BaselineLoc    string        `json:"baseline_location"`
+	MutatedLoc     string        `json:"mutated_location"`
+	Diff           *responseDiff `json:"diff,omitempty"`
+	ManualAction   string        `json:"manual_action"`
+	EvidenceIDs    []string      `json:"evidence_ids,omitempty"`
+	SessionProfile string        `json:"session_profile,omitempty"`
+}
+
+// formCase is one tampered submission: a single field changed, re-enabled or omitted
+// relative to what a browser would send.
+type formCase struct {
+	family string
+	field  formField
+	value  string
+	omit   bool
+}
+
+type formPair struct {
+	name  string
+	value string
+}
+
+var formManualActions = map[string]string{
+	"form_hidden_tamper":    "The server acted differently on a tampered hidden value: replay it in a proxy and check whether the value is trusted (price, id, role, flags) rather than re-derived server-side.",
+	"form_readonly_tamper":  "A readonly field was changed and the response differed: confirm whether the new value is persisted or used for authorization.",
+	"form_disabled_field":   "A disabled field was submitted and changed the response: check whether the server processes fields the UI never sends.",
+	"form_maxlength_bypass": "A value past maxlength was accepted differently: check for truncation, storage of the long value, or errors that hint at a buffer or column limit.",
+	"form_pattern_bypass":   "A value breaking the client-side pattern changed the response: the server does not enforce the same validation; try injection payloads in this field.",
+	"form_range_bypass":     "A number outside min/max changed the response: test negative quantities, zero prices and overflow values on this field.",
+	"form_select_bypass":    "A value outside the select options changed the response: try other ids or privileged option values the UI does not offer.",
+	"form_type_bypass":      "A value of the wrong input type changed the response: server-side type validation is missing or leaks errors.",
+	"form_required_bypass":  "Omitting a required field changed the response: check for default values or error pages that expose internals.",
+}
+
+func (a *App) formsDir() string {
+	return filepath.Join(a.fuzzingBaseDir(), "forms")
+}
+
+func (a *App) runFormTamper(ctx context.Context) error {
+	outDir := a.formsDir()
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return err
+	}
+	formsFile, err := os.Create(filepath.Join(outDir, "forms.jsonl"))
+	if err != nil {
+		return err
+	}
+	defer formsFile.Close()
+	formsWriter := bufio.NewWriter(formsFile)
+	defer formsWriter.Flush()
+	findingsFile, err := os.Create(filepath.Join(outDir, "findings.jsonl"))
+	if err != nil {
+		return err
+	}
+	defer findingsFile.Close()
+	findingsWriter := bufio.NewWriter(findingsFile)
+	defer findingsWriter.Flush()
+
+	client := a.newHTTPClient(paramFuzzRequestTimeout, false)
+	lastByHost := make(map[string]time.Time)
+	scoped := a.hostScopeFilter()
+	metrics := map[string]int{"pages": 0, "forms": 0, "cases": 0, "findings": 0}
+
+	seenForms := make(map[string]struct{})
+	for _, page := range a.collectFormPages() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if metrics["forms"] >= formMaxForms {
+			a.logger.Printf("%s: form limit %d reached", StepFormTamper, formMaxForms)
+			break
+		}
+		jar := make(map[string]string)
+		finalURL, body, ok := a.fetchFormPage(ctx, client, lastByHost, page, jar)
+		if !ok {
+			continue
+		}
+		metrics["pages"]++
+		for _, form := range parseHTMLForms(finalURL, body) {
+			if metrics["forms"] >= formMaxForms {
+				break
+			}
+			// Site-wide search and newsletter forms repeat on every page; test each once.
+			key := form.signature()
+			if _, ok := seenForms[key]; ok {
+				continue
+			}
+			seenForms[key] = struct{}{}
+			metrics["forms"]++
+
+			host := strings.ToLower(extractHostCandidate(form.Action))
+			rec := formRecord{
+				Timestamp: time.Now().UTC().Format(time.RFC3339),
+				Page:      form.Page,
+				Host:      host,
+				Action:    form.Action,
+				Method:    form.Method,
+				Enctype:   form.Enctype,
+				TokenName: form.TokenName,
+				Fields:    form.Fields,
+			}
+			switch {
+			case !scoped(host):
+				rec.Skipped = "action_out_of_scope"
+			case formDangerRe.MatchString(form.Action):
+				rec.Skipped = "destructive_action"
+			case len(form.Fields) == 0:
+				rec.Skipped = "no_fields"
+			}
+			if rec.Skipped == "" {
+				findings := a.tamperForm(ctx, client, lastByHost, form, jar, &rec)
+				for _, f := range findings {
+					_ = writeJSONLine(findingsWriter, f)
+				}
+				metrics["cases"] += rec.Cases
+				metrics["findings"] += len(findings)
+			}
+			_ = writeJSONLine(formsWriter, rec)
+		}
+	}
+
+	a.logger.Printf("%s: pages=%d forms=%d cases=%d findings=%d", StepFormTamper, metrics["pages"], metrics["forms"], metrics["cases"], metrics["findings"])
+	return nil
+}
+
+// collectFormPages puts pages browsed in the imported request corpus first, then corpus
+// pages whose path suggests a form, then live host roots, then the remaining pages.
+func (a *App) collectFormPages() []string {
+	baseDir := filepath.Dir(a.cfg.Lists.Domains)
+	roles := a.loadHostRoles()
+	siblings := a.loadClusterSiblingSkips()
+	scoped := a.hostScopeFilter()
+
+	imported := make(map[string]struct{})
+	for _, u := range a.requestCorpusURLs(http.MethodGet) {
+		imported[u] = struct{}{}
+	}
+	var browsed, hinted, rest []string
+	for _, endpoint := range append(a.requestCorpusURLs(http.MethodGet), a.collectParamFuzzEndpoints(filepath.Join(baseDir, "recon", "all_urls.txt"))...) {
+		parsed, err := url.Parse(endpoint)
+		if err != nil {
+			continue
+		}
+		ext := strings.ToLower(path.Ext(parsed.Path))
+		if _, ok := jsStaticExts[ext]; ok {
+			continue
+		}
+		if _, ok := formSkipExts[ext]; ok {
+			continue
+		}
+		if _, ok := imported[endpoint]; ok {
+			browsed = append(browsed, endpoint)
+			continue
+		}
+		if formPathHinted(parsed.Path) {
+			hinted = append(hinted, endpoint)
+			continue
+		}
+		rest = append(rest, endpoint)
+	}
+
+	var roots []string
+	for _, row := range readLiveWebserverRecords(filepath.Join(baseDir, "live-webservers.jsonl")) {
+		target := normalizeLiveTarget(row.URL)
+		if target == "" {
+			continue
+		}
+		host := strings.ToLower(extractHostCandidate(target))
+		if !scoped(host) || hostRoleSkipped(roles, host) {
+			continue
+		}
+		if _, ok := siblings[host]; ok {
+			continue
+		}
+		roots = append(roots, strings.TrimRight(target, "/")+"/")
+	}
+
+	pages := unique(append(append(append(browsed, a.aimEndpointsAtRoles(StepFormTamper, hinted)...), roots...), rest...))
+	if len(pages) > formMaxPages {
+		a.logger.Printf("%s: limiting pages from %d to %d", StepFormTamper, len(pages), formMaxPages)
+		pages = pages[:formMaxPages]
+	}
+	return pages
+}
+
+// fetchFormPage follows same-host redirects by hand so login walls still yield their
+// form, and keeps the cookies the page sets for the submissions that follow.
+func (a *App) fetchFormPage(ctx context.Context, client *http.Client, lastByHost map[string]time.Time, target string, jar map[string]string) (string, string, bool) {
+	current := target
+	for hop := 0; hop <= formMaxRedirects; hop++ {
+		obs, body, header, err := a.formRequest(ctx, client, lastByHost, http.MethodGet, current, "", nil, formCookieHeader(jar))
+		if err != nil {
+			return "", "", false
+		}
+		for _, line := range header.Values("Set-Cookie") {
+			if c, err := http.ParseSetCookie(line); err == nil && c.Name != "" {
+				jar[c.Name] = c.Value
+			}
+		}
+		if obs.StatusCode >= 300 && obs.StatusCode < 400 && obs.Location != "" {
+			base, _ := url.Parse(current)
+			next, err := base.Parse(obs.Location)
+			if err != nil || !strings.EqualFold(next.Hostname(), base.Hostname()) {
+				return "", "", false
+			}
+			current = next.String()
+			continue
+		}
+		if obs.StatusCode >= 400 || !strings.Contains(strings.ToLower(body), "<form") {
+			return "", "", false
+		}
+		return current, body, true
+	}
+	return "", "", false
+}
+
+// formRequest is sendParamFuzzRequest with the whole body kept: forms sit anywhere in
+// a page and reflected values rarely land in the first few kilobytes.
+func (a *App) formRequest(ctx context.Context, client *http.Client, lastByHost map[string]time.Time, method, target, contentType string, body []byte, cookie string) (paramFuzzObservation, string, http.Header, error) {
+	var obs paramFuzzObservation
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return obs, "", nil, err
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if last, ok := lastByHost[host]; ok {
+		if wait := a.hostRequestDelay(host) - time.Since(last); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return obs, "", nil, ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return obs, "", nil, err
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if cookie != "" {
+		req.Header.Set("Cookie", cookie)
+	}
+	session := a.applySession(req)
+	start := time.Now()
+	resp, err := client.Do(req)
+	lastByHost[host] = time.Now()
+	if err != nil {
+		return obs, "", nil, err
+	}
+	defer resp.Body.Close()
+	data, truncated := readLimitedBody(resp.Body, formPageBodyLimit)
+	snippet := data
+	if len(snippet) > 4096 {
+		snippet = snippet[:4096]
+	}
+	obs = paramFuzzObservation{
+		StatusCode: resp.StatusCode,
+		Length:     len(data),
+		DurationMS: time.Since(start).Milliseconds(),
+		Location:   strings.TrimSpace(resp.Header.Get("Location")),
+		Snippet:    strings.ToLower(string(snippet)),
+		Body:       strings.ToLower(string(data[:min(len(data), responseDiffBodyLimit)])),
+		Headers:    resp.Header.Clone(),
+		Exchange:   captureEvidence(req, body, resp, data, truncated),
+	}
+	if session != nil {
+		obs.Session = session.name
+	}
+	a.observeSession(ctx, session, target, obs.StatusCode, obs.Location, obs.Snippet)
+	return obs, string(data), resp.Header, nil
+}
+
+func (a *App) tamperForm(ctx context.Context, client *http.Client, lastByHost map[string]time.Time, form htmlForm, jar map[string]string, rec *formRecord) []formFinding {
+	submit := func(f htmlForm, c *formCase) (paramFuzzObservation, string, bool) {
+		// Per-request tokens would make every replay after the first fail for the
+		// wrong reason, so forms carrying one are re-read before each submission.
+		if f.TokenName != "" {
+			if fresh, ok := a.refreshForm(ctx, client, lastByHost, f, jar); ok {
+				f = fresh
+			}
+		}
+		target, body, contentType := f.encode(f.pairs(c))
+		obs, text, header, err := a.formRequest(ctx, client, lastByHost, f.Method, target, contentType, body, formCookieHeader(jar))
+		if err != nil {
+			return obs, "", false
+		}
+		for _, line := range header.Values("Set-Cookie") {
+			if c, err := http.ParseSetCookie(line); err == nil && c.Name != "" {
+				jar[c.Name] = c.Value
+			}
+		}
+		return obs, strings.ToLower(text), true
+	}
+
+	first, baseBody, ok := submit(form, nil)
+	if !ok {
+		rec.Skipped = "baseline_failed"
+		return nil
+	}
+	// A second baseline tells apart differences caused by the tampering from ones the
+	// endpoint produces on its own (timestamps, rotating tokens, rate limiting).
+	samples := []paramFuzzObservation{first}
+	if again, _, ok := submit(form, nil); ok {
+		samples = append(samples, again)
+	}
+	baseline := newResponseBaseline(samples...)
+
+	cases := form.tamperCases()
+	if len(cases) > formMaxCasesPerForm {
+		cases = cases[:formMaxCasesPerForm]
+	}
+	var findings []formFinding
+	for i := range cases {
+		if ctx.Err() != nil {
+			break
+		}
+		c := cases[i]
+		obs, body, ok := submit(form, &c)
+		if !ok {
+			continue
+		}
+		rec.Cases++
+		reasons, diff := paramFuzzReasons(baseline, obs, c.value)
+		if baseline.StatusCode < 500 && obs.StatusCode >= 500 {
+			reasons = append(reasons, "server_error_on_tamper")
+		}
+		if marker := strings.ToLower(c.value); strings.Contains(marker, formTamperMarker) && strings.Contains(body, marker) && !strings.Contains(baseBody, marker) {
+			reasons = append(reasons, "tampered_value_reflected")
+		}
+		reasons = unique(reasons)
+		if len(reasons) == 0 {
+			continue
+		}
+		value := c.value
+		if c.omit {
+			value = "(omitted)"
+		} else if len(value) > 200 {
+			value = value[:200] + "..."
+		}
+		findings = append(findings, formFinding{
+			Timestamp:      rec.Timestamp,
+			Page:           form.Page,
+			Endpoint:       form.Action,
+			Host:           rec.Host,
+			Method:         form.Method,
+			Enctype:        form.Enctype,
+			Family:         c.family,
+			Severity:       formFindingSeverity(c.family, first, obs, reasons),
+			Param:          c.field.Name,
+			FieldType:      c.field.Type,
+			Original:       c.field.Value,
+			Payload:        value,
+			Reasons:        reasons,
+			BaselineCode:   baseline.StatusCode,
+			MutatedCode:    obs.StatusCode,
+			BaselineLen:    baseline.Length,
+			MutatedLen:     obs.Length,
+			BaselineLoc:    baseline.Location,
+			MutatedLoc:     obs.Location,
+			Diff:           &diff,
+			EvidenceIDs:    a.saveEvidence(baseline.Exchange, obs.Exchange),
+			ManualAction:   formManualActions[c.family],
+			SessionProfile: obs.Session,
+		})
+	}
+	rec.Findings = len(findings)
+	return findings
+}
+
+func (a *App) refreshForm(ctx context.Context, client *http.Client, lastByHost map[string]time.Time, form htmlForm, jar map[string]string) (htmlForm, bool) {
+	finalURL, body, ok := a.fetchFormPage(ctx, client, lastByHost, form.Page, jar)
+	if !ok {
+		return form, false
+	}
+	key := form.signature()
+	for _, fresh := range parseHTMLForms(finalURL, body) {
+		if fresh.signature() == key {
+			fresh.Page = form.Page
+			return fresh, true
+		}
+	}
+	return form, false
+}
+
+// formFindingSeverity rates a server that accepts (2xx/3xx) a changed hidden, readonly or
+// disabled value and echoes it back as medium; everything else stays a low lead.
+func formFindingSeverity(family string, base, mutated paramFuzzObservation, reasons []string) string {
+	if containsAny(reasons, "server_error_on_tamper") {
+		return "medium"
+	}
+	switch family {
+	case "form_hidden_tamper", "form_readonly_tamper", "form_disabled_field":
+		accepted := mutated.StatusCode >= 200 && mutated.StatusCode < 400 && base.StatusCode < 400
+		if accepted && containsAny(reasons, "tampered_value_reflected") {
+			return "medium"
+		}
+	}
+	return "low"
+}
+
+func parseHTMLForms(pageURL, body string) []htmlForm {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	var out []htmlForm
+	for i, match := range formBlockRe.FindAllStringSubmatch(body, -1) {
+		attrs := parseHTMLAttrs(match[1])
+		form := htmlForm{
+			Page:    pageURL,
+			Index:   i,
+			Method:  strings.ToUpper(strings.TrimSpace(attrs["method"])),
+			Enctype: strings.ToLower(strings.TrimSpace(attrs["enctype"])),
+		}
+		if form.Method != http.MethodPost {
+			form.Method = http.MethodGet
+		}
+		switch form.Enctype {
+		case "multipart/form-data", "text/plain":
+		default:
+			form.Enctype = "application/x-www-form-urlencoded"
+		}
+		action, err := base.Parse(strings.TrimSpace(attrs["action"]))
+		if err != nil || (action.Scheme != "http" && action.Scheme != "https") {
+			continue
+		}
+		action.Fragment = ""
+		form.Action = action.String()
+
+		for _, field := range formFieldRe.FindAllStringSubmatch(match[2], -1) {
+			var f formField
+			tag := strings.ToLower(field[0])
+			switch {
+			case strings.HasPrefix(tag, "<input"):
+				attrs := parseHTMLAttrs(field[1])
+				f = newFormField(attrs, strings.ToLower(strings.TrimSpace(attrs["type"])))
+				if f.Type == "" {
+					f.Type = "text"
+				}
+				f.Value = attrs["value"]
+				_, f.Checked = attrs["checked"]
+			case strings.HasPrefix(tag, "<textarea"):
+				f = newFormField(parseHTMLAttrs(field[2]), "textarea")
+				f.Value = html.UnescapeString(strings.TrimSpace(field[3]))
+			default:
+				f = newFormField(parseHTMLAttrs(field[4]), "select")
+				for _, option := range formOptionRe.FindAllStringSubmatch(field[5], -1) {
+					optAttrs := parseHTMLAttrs(option[1])
+					value, ok := optAttrs["value"]
+					if !ok {
+						value = html.UnescapeString(strings.TrimSpace(option[2]))
+					}
+					f.Options = append(f.Options, value)
+					if _, selected := optAttrs["selected"]; selected || len(f.Options) == 1 {
+						f.Value = value
+					}
+				}
+			}
+			if f.Name == "" {
+				continue
+			}
+			switch f.Type {
+			case "submit", "button", "reset", "image", "file":
+				continue
+			}
+			f.Hidden = f.Type == "hidden"
+			if form.TokenName == "" && f.Hidden && formTokenRe.MatchString(f.Name) {
+				form.TokenName = f.Name
+			}
+			form.Fields = append(form.Fields, f)
+		}
+		out = append(out, form)
+	}
+	return out
+}
+
+func newFormField(attrs map[string]string, fieldType string) formField {
+	f := formField{
+		Name:    strings.TrimSpace(attrs["name"]),
+		Type:    fieldType,
+		Pattern: attrs["pattern"],
+		Min:     strings.TrimSpace(attrs["min"]),
+		Max:     strings.TrimSpace(attrs["max"]),
+	}
+	_, f.Disabled = attrs["disabled"]
+	_, f.Readonly = attrs["readonly"]
+	_, f.Required = attrs["required"]
+	f.MaxLength, _ = strconv.Atoi(strings.TrimSpace(attrs["maxlength"]))
+	f.MinLength, _ = strconv.Atoi(strings.TrimSpace(attrs["minlength"]))
+	return f
+}
+
+func parseHTMLAttrs(raw string) map[string]string {
+	out := make(map[string]string)
+	for _, m := range formAttrRe.FindAllStringSubmatch(raw, -1) {
+		name := strings.ToLower(m[1])
+		if _, ok := out[name]; ok {
+			continue
+		}
+		out[name] = html.UnescapeString(m[2] + m[3] + m[4])
+	}
+	return out
+}
+
+func (f htmlForm) signature() string {
+	names := make([]string, 0, len(f.Fields))
+	for _, field := range f.Fields {
+		names = append(names, field.Name)
+	}
+	sort.Strings(names)
+	action := f.Action
+	if parsed, err := url.Parse(action); err == nil {
+		parsed.RawQuery = ""
+		action = parsed.String()
+	}
+	return f.Method + " " + action + " " + strings.Join(names, ",")
+}
+
+// pairs returns what a browser would submit, with c applied on top. Disabled fields
+// and unchecked boxes are left out; empty inputs get a value that passes their
+// client-side constraints so the baseline is a valid submission.
+func (f htmlForm) pairs(c *formCase) []formPair {
+	var out []formPair
+	radios := make(map[string]bool)
+	for _, field := range f.Fields {
+		if c != nil && c.field.Name == field.Name && c.field.Type == field.Type {
+			if !c.omit {
+				out = append(out, formPair{name: field.Name, value: c.value})
+			}
+			continue
+		}
+		if field.Disabled {
+			continue
+		}
+		switch field.Type {
+		case "checkbox":
+			if field.Checked {
+				out = append(out, formPair{name: field.Name, value: formValueOr(field.Value, "on")})
+			}
+			continue
+		case "radio":
+			if field.Checked && !radios[field.Name] {
+				radios[field.Name] = true
+				out = append(out, formPair{name: field.Name, value: formValueOr(field.Value, "on")})
+			}
+			continue
+		}
+		value := field.Value
+		if value == "" && !field.Hidden {
+			value = formFillValue(field)
+		}
+		out = append(out, formPair{name: field.Name, value: value})
+	}
+	return out
+}
+
+func (f htmlForm) encode(pairs []formPair) (string, []byte, string) {
+	if f.Method == http.MethodGet {
+		parsed, err := url.Parse(f.Action)
+		if err != nil {
+			return f.Action, nil, ""
+		}
+		parsed.RawQuery = encodeFormPairs(pairs)
+		return parsed.String(), nil, ""
+	}
+	switch f.Enctype {
+	case "multipart/form-data":
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		for _, p := range pairs {
+			_ = w.WriteField(p.name, p.value)
+		}
+		_ = w.Close()
+		return f.Action, buf.Bytes(), w.FormDataContentType()
+	case "text/plain":
+		var b strings.Builder
+		for _, p := range pairs {
+			b.WriteString(p.name + "=" + p.value + "\r\n")
+		}
+		return f.Action, []byte(b.String()), "text/plain"
+	}
+	return f.Action, []byte(encodeFormPairs(pairs)), "application/x-www-form-urlencoded"
+}
+
+func encodeFormPairs(pairs []formPair) string {
+	parts := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		parts = append(parts, url.QueryEscape(p.name)+"="+url.QueryEscape(p.value))
+	}
+	return strings.Join(parts, "&")
+}
+
+func (f htmlForm) tamperCases() []formCase {
+	var out []formCase
+	for _, field := range f.Fields {
+		if field.Name == f.TokenName {
+			continue
+		}
+		if field.Type == "checkbox" || field.Type == "radio" {
+			if field.Disabled {
+				out = append(out, formCase{family: "form_disabled_field", field: field, value: formValueOr(field.Value, "on")})
+			}
+			continue
+		}
+		switch {
+		case field.Disabled:
+			out = append(out, formCase{family: "form_disabled_field", field: field, value: formValueOr(field.Value, formFillValue(field))})
+		case field.Hidden:
+			// ASP.NET state blobs are large and signed; tampering only produces MAC errors.
+			if formTokenRe.MatchString(field.Name) {
+				continue
+			}
+			for _, value := range formTamperValues(field) {
+				out = append(out, formCase{family: "form_hidden_tamper", field: field, value: value})
+			}
+		case field.Readonly:
+			for _, value := range formTamperValues(field) {
+				out = append(out, formCase{family: "form_readonly_tamper", field: field, value: value})
+			}
+		}
+		if field.Hidden {
+			continue
+		}
+		if field.MaxLength > 0 {
+			size := field.MaxLength*2 + 16
+			if size > formOverflowCap {
+				size = formOverflowCap
+			}
+			out = append(out, formCase{family: "form_maxlength_bypass", field: field, value: formTamperMarker + strings.Repeat("A", size)})
+		}
+		if field.Pattern != "" {
+			if value, ok := formPatternViolation(field.Pattern); ok {
+				out = append(out, formCase{family: "form_pattern_bypass", field: field, value: value})
+			}
+		}
+		if min, err := strconv.ParseFloat(field.Min, 64); err == nil {
+			out = append(out, formCase{family: "form_range_bypass", field: field, value: strconv.FormatFloat(min-1, 'f', -1, 64)})
+		}
+		if max, err := strconv.ParseFloat(field.Max, 64); err == nil {
+			out = append(out, formCase{family: "form_range_bypass", field: field, value: strconv.FormatFloat(max+1, 'f', -1, 64)})
+		}
+		switch field.Type {
+		case "select":
+			if len(field.Options) > 0 {
+				out = append(out, formCase{family: "form_select_bypass", field: field, value: formTamperMarker})
+			}
+		case "email", "number", "url", "date", "tel":
+			out = append(out, formCase{family: "form_type_bypass", field: field, value: formTamperMarker + "'\"<>"})
+		}
+		if field.Required {
+			out = append(out, formCase{family: "form_required_bypass", field: field, omit: true})
+		}
+	}
+	return out
+}
+
+// formTamperValues picks a few values likely to matter for the field: adjacent and
+// negative numbers, a flipped boolean, a privileged role, or a marked string.
+func formTamperValues(field formField) []string {
+	orig := strings.TrimSpace(field.Value)
+	lower := strings.ToLower(orig)
+	var out []string
+	if n, err := strconv.ParseInt(orig, 10, 64); err == nil {
+		out = append(out, strconv.FormatInt(n+1, 10), "-1")
+		if n != 0 {
+			out = append(out, "0")
+		}
+		return out
+	}
+	if n, err := strconv.ParseFloat(orig, 64); err == nil {
+		return []string{"0.01", "0", strconv.FormatFloat(-n, 'f', -1, 64)}
+	}
+	flips := map[string]string{"true": "false", "false": "true", "yes": "no", "no": "yes", "on": "off", "off": "on", "y": "n", "n": "y"}
+	if flipped, ok := flips[lower]; ok {
+		return []string{flipped}
+	}
+	if formPrivNameRe.MatchString(field.Name) {
+		out = append(out, "admin")
+	}
+	if orig == "" {
+		return append(out, "1", formTamperMarker)
+	}
+	return append(out, orig+formTamperMarker)
+}
+
+func formPatternViolation(pattern string) (string, bool) {
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	candidates := []string{formTamperMarker + "'\"<>", "0", "-1", strings.Repeat("9", 40), formTamperMarker}
+	if err != nil {
+		return candidates[0], true
+	}
+	for _, value := range candidates {
+		if !re.MatchString(value) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func formFillValue(field formField) string {
+	var candidates []string
+	switch field.Type {
+	case "email":
+		candidates = []string{"bflow@example.com"}
+	case "number", "range":
+		candidates = []string{formValueOr(field.Min, "1")}
+	case "url":
+		candidates = []string{"https://example.com/"}
+	case "tel":
+		candidates = []string{"5555550100"}
+	case "date":
+		candidates = []string{"2024-01-01"}
+	case "password":
+		candidates = []string{"Bflow-Passw0rd!"}
+	case "select":
+		if len(field.Options) > 0 {
+			return field.Options[0]
+		}
+		candidates = []string{"1"}
+	default:
+		candidates = []string{"bflow", "1", "A1", "bflow@example.com"}
+	}
+	value := candidates[0]
+	if field.Pattern != "" {
+		if re, err := regexp.Compile("^(?:" + field.Pattern + ")$"); err == nil {
+			for _, candidate := range candidates {
+				if re.MatchString(candidate) {
+					value = candidate
+					break
+				}
+			}
+		}
+	}
+	for len(value) < field.MinLength {
+		value += "x"
+	}
+	if field.MaxLength > 0 && len(value) > field.MaxLength {
+		value = value[:field.MaxLength]
+	}
+	return value
+}
+
+func formPathHinted(p string) bool {
+	lower := strings.ToLower(p)
+	for _, hint := range formPathHints {
+		if strings.Contains(lower, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+func formValueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func formCookieHeader(jar map[string]string) string {
+	names := make([]string, 0, len(jar))
+	for name := range jar {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+"="+jar[name])
+	}
+	return strings.Join(parts, "; ")
+}
@@ -50,12 +50,7 @@ func (a *App) runParamFuzz(ctx context.Context) error {
 		}
 	}()
 
-	clients := &http.Client{
-		Timeout: paramFuzzRequestTimeout,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
-	}
+	clients := a.newHTTPClient(paramFuzzRequestTimeout, false)
 
 	allURLsPath := filepath.Join(reconDir, "all_urls.txt")
 	endpoints := a.collectParamFuzzEndpoints(allURLsPath)
@@ -70,9 +65,27 @@ func (a *App) runParamFuzz(ctx context.Context) error {
 	}
 
 	endpointParams, globalParams := extractParamCandidates(endpoints)
+	// Spec-derived and imported requests are fuzzed in their own shape after the URL
+	// endpoints; their parameter names also seed the global candidate list.
+	specTargets := apiSpecFuzzTargets(a.loadStructuredRequests(), apiSpecMaxFuzzRequests)
+	for _, req := range specTargets {
+		for _, param := range req.Params {
+			if param.In == "path" || param.In == "header" {
+				continue
+			}
+			if name := normalizeParamName(param.Name); name != "" {
+				globalParams[name] = struct{}{}
+			}
+		}
+	}
 	for _, key := range paramFuzzCommonParams {
 		globalParams[key] = struct{}{}
 	}
+	for _, key := range readSafeLines(filepath.Join(reconDir, "js_params.txt")) {
+		if name := normalizeParamName(key); name != "" {
+			globalParams[name] = struct{}{}
+		}
+	}
 	a.discoverParamsWithArjun(ctx, endpoints, rawDir, endpointParams, globalParams)
 	a.discoverParamsWithX8(ctx, endpoints, rawDir, endpointParams, globalParams)
 
@@ -96,6 +109,22 @@ func (a *App) runParamFuzz(ctx context.Context) error {
 	}
 	lastByHost := make(map[string]time.Time)
 
+	xssFile, xssWriter, err := a.openReflectionHits()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = xssWriter.Flush()
+		_ = xssFile.Close()
+	}()
+	reflections := 0
+	reflected := func(probe reflectionProbe, obs paramFuzzObservation, canary string) {
+		if hit, ok := a.checkReflection(probe, obs, canary); ok {
+			reflections++
+			_ = writeJSONLine(xssWriter, hit)
+		}
+	}
+
 	for _, endpoint := range endpoints {
 		params := sortedParamKeys(endpointParams[endpoint])
 		if len(params) == 0 {
@@ -105,123 +134,148 @@ func (a *App) runParamFuzz(ctx context.Context) error {
 			params = params[:paramFuzzMaxParamsPerEndpoint]
 		}
 
-		baseGET, err := a.sendParamFuzzRequest(ctx, clients, lastByHost, endpoint, http.MethodGet, nil, nil, "")
+		baseGET, err := a.sampleBaseline(ctx, clients, lastByHost, endpoint, http.MethodGet, nil, nil, "")
 		if err != nil {
 			a.logger.Printf("%s: baseline GET failed for %s: %v", StepParamFuzz, endpoint, err)
 			continue
 		}
-		basePOSTForm, _ := a.sendParamFuzzRequest(ctx, clients, lastByHost, endpoint, http.MethodPost, map[string]string{
+		basePOSTForm, _ := a.sampleBaseline(ctx, clients, lastByHost, endpoint, http.MethodPost, map[string]string{
 			"Content-Type": "application/x-www-form-urlencoded",
 		}, []byte(""), "")
-		basePOSTJSON, _ := a.sendParamFuzzRequest(ctx, clients, lastByHost, endpoint, http.MethodPost, map[string]string{
+		basePOSTJSON, _ := a.sampleBaseline(ctx, clients, lastByHost, endpoint, http.MethodPost, map[string]string{
 			"Content-Type": "application/json",
 		}, []byte(`{}`), "")
 
 		for _, key := range params {
-			mutatedURL := mutateURLQuery(endpoint, key, "BFLOWFUZZ123")
+			canary := reflectionCanary()
+			mutatedURL := mutateURLQuery(endpoint, key, canary)
 			if mutatedURL != "" {
 				obs, err := a.sendParamFuzzRequest(ctx, clients, lastByHost, mutatedURL, http.MethodGet, nil, nil, "")
 				if err == nil {
+					reflected(reflectionProbe{endpoint: endpoint, method: http.MethodGet, param: key, vector: "url-query", query: true, send: func(value string) (paramFuzzObservation, error) {
+						return a.sendParamFuzzRequest(ctx, clients, lastByHost, mutateURLQuery(endpoint, key, value), http.MethodGet, nil, nil, "")
+					}}, obs, canary)
 					metrics["query"] = struct {
 						requests int
 						hits     int
 					}{requests: metrics["query"].requests + 1, hits: metrics["query"].hits}
-					if reasons := paramFuzzReasons(baseGET, obs); len(reasons) > 0 {
+					if reasons, diff := paramFuzzReasons(baseGET, obs, canary); len(reasons) > 0 {
 						metrics["query"] = struct {
 							requests int
 							hits     int
 						}{requests: metrics["query"].requests, hits: metrics["query"].hits + 1}
 						_ = writeJSONLine(modeWriters["query"], paramFuzzHit{
-							Timestamp:    time.Now().UTC().Format(time.RFC3339),
-							Mode:         "query",
-							Endpoint:     endpoint,
-							Method:       http.MethodGet,
-							Param:        key,
-							Vector:       "url-query",
-							MutatedURL:   mutatedURL,
-							Reasons:      reasons,
-							BaselineCode: baseGET.StatusCode,
-							MutatedCode:  obs.StatusCode,
-							BaselineLen:  baseGET.Length,
-							MutatedLen:   obs.Length,
-							BaselineMS:   baseGET.DurationMS,
-							MutatedMS:    obs.DurationMS,
-							BaselineLoc:  baseGET.Location,
-							MutatedLoc:   obs.Location,
+							Timestamp:      time.Now().UTC().Format(time.RFC3339),
+							Mode:           "query",
+							Endpoint:       endpoint,
+							Method:         http.MethodGet,
+							Param:          key,
+							Payload:        canary,
+							Vector:         "url-query",
+							MutatedURL:     mutatedURL,
+							Reasons:        reasons,
+							BaselineCode:   baseGET.StatusCode,
+							MutatedCode:    obs.StatusCode,
+							BaselineLen:    baseGET.Length,
+							MutatedLen:     obs.Length,
+							BaselineMS:     baseGET.DurationMS,
+							MutatedMS:      obs.DurationMS,
+							BaselineLoc:    baseGET.Location,
+							MutatedLoc:     obs.Location,
+							Diff:           &diff,
+							EvidenceIDs:    a.saveEvidence(baseGET.Exchange, obs.Exchange),
+							SessionProfile: obs.Session,
 						})
 					}
 				}
 			}
 
-			bodyForm := []byte(url.Values{key: []string{"BFLOWFUZZ123"}}.Encode())
 			if basePOSTForm.StatusCode > 0 {
-				obs, err := a.sendParamFuzzRequest(ctx, clients, lastByHost, endpoint, http.MethodPost, map[string]string{
-					"Content-Type": "application/x-www-form-urlencoded",
-				}, bodyForm, "")
+				canary := reflectionCanary()
+				sendForm := func(value string) (paramFuzzObservation, error) {
+					return a.sendParamFuzzRequest(ctx, clients, lastByHost, endpoint, http.MethodPost, map[string]string{
+						"Content-Type": "application/x-www-form-urlencoded",
+					}, []byte(url.Values{key: []string{value}}.Encode()), "")
+				}
+				obs, err := sendForm(canary)
 				if err == nil {
+					reflected(reflectionProbe{endpoint: endpoint, method: http.MethodPost, param: key, vector: "x-www-form-urlencoded", send: sendForm}, obs, canary)
 					metrics["body"] = struct {
 						requests int
 						hits     int
 					}{requests: metrics["body"].requests + 1, hits: metrics["body"].hits}
-					if reasons := paramFuzzReasons(basePOSTForm, obs); len(reasons) > 0 {
+					if reasons, diff := paramFuzzReasons(basePOSTForm, obs, canary); len(reasons) > 0 {
 						metrics["body"] = struct {
 							requests int
 							hits     int
 						}{requests: metrics["body"].requests, hits: metrics["body"].hits + 1}
 						_ = writeJSONLine(modeWriters["body"], paramFuzzHit{
-							Timestamp:    time.Now().UTC().Format(time.RFC3339),
-							Mode:         "body",
-							Endpoint:     endpoint,
-							Method:       http.MethodPost,
-							Param:        key,
-							Vector:       "x-www-form-urlencoded",
-							MutatedURL:   endpoint,
-							Reasons:      reasons,
-							BaselineCode: basePOSTForm.StatusCode,
-							MutatedCode:  obs.StatusCode,
-							BaselineLen:  basePOSTForm.Length,
-							MutatedLen:   obs.Length,
-							BaselineMS:   basePOSTForm.DurationMS,
-							MutatedMS:    obs.DurationMS,
-							BaselineLoc:  basePOSTForm.Location,
-							MutatedLoc:   obs.Location,
+							Timestamp:      time.Now().UTC().Format(time.RFC3339),
+							Mode:           "body",
+							Endpoint:       endpoint,
+							Method:         http.MethodPost,
+							Param:          key,
+							Payload:        canary,
+							Vector:         "x-www-form-urlencoded",
+							MutatedURL:     endpoint,
+							Reasons:        reasons,
+							BaselineCode:   basePOSTForm.StatusCode,
+							MutatedCode:    obs.StatusCode,
+							BaselineLen:    basePOSTForm.Length,
+							MutatedLen:     obs.Length,
+							BaselineMS:     basePOSTForm.DurationMS,
+							MutatedMS:      obs.DurationMS,
+							BaselineLoc:    basePOSTForm.Location,
+							MutatedLoc:     obs.Location,
+							Diff:           &diff,
+							EvidenceIDs:    a.saveEvidence(basePOSTForm.Exchange, obs.Exchange),
+							SessionProfile: obs.Session,
 						})
 					}
 				}
 			}
 
 			if basePOSTJSON.StatusCode > 0 {
-				jsonBody, _ := json.Marshal(map[string]string{key: "BFLOWFUZZ123"})
-				obs, err := a.sendParamFuzzRequest(ctx, clients, lastByHost, endpoint, http.MethodPost, map[string]string{
-					"Content-Type": "application/json",
-				}, jsonBody, "")
+				canary := reflectionCanary()
+				sendJSON := func(value string) (paramFuzzObservation, error) {
+					jsonBody, _ := json.Marshal(map[string]string{key: value})
+					return a.sendParamFuzzRequest(ctx, clients, lastByHost, endpoint, http.MethodPost, map[string]string{
+						"Content-Type": "application/json",
+					}, jsonBody, "")
+				}
+				obs, err := sendJSON(canary)
 				if err == nil {
+					reflected(reflectionProbe{endpoint: endpoint, method: http.MethodPost, param: key, vector: "json", send: sendJSON}, obs, canary)
 					metrics["body"] = struct {
 						requests int
 						hits     int
 					}{requests: metrics["body"].requests + 1, hits: metrics["body"].hits}
-					if reasons := paramFuzzReasons(basePOSTJSON, obs); len(reasons) > 0 {
+					if reasons, diff := paramFuzzReasons(basePOSTJSON, obs, canary); len(reasons) > 0 {
 						metrics["body"] = struct {
 							requests int
 							hits     int
 						}{requests: metrics["body"].requests, hits: metrics["body"].hits + 1}
 						_ = writeJSONLine(modeWriters["body"], paramFuzzHit{
-							Timestamp:    time.Now().UTC().Format(time.RFC3339),
-							Mode:         "body",
-							Endpoint:     endpoint,
-							Method:       http.MethodPost,
-							Param:        key,
-							Vector:       "json",
-							MutatedURL:   endpoint,
-							Reasons:      reasons,
-							BaselineCode: basePOSTJSON.StatusCode,
-							MutatedCode:  obs.StatusCode,
-							BaselineLen:  basePOSTJSON.Length,
-							MutatedLen:   obs.Length,
-							BaselineMS:   basePOSTJSON.DurationMS,
-							MutatedMS:    obs.DurationMS,
-							BaselineLoc:  basePOSTJSON.Location,
-							MutatedLoc:   obs.Location,
+							Timestamp:      time.Now().UTC().Format(time.RFC3339),
+							Mode:           "body",
+							Endpoint:       endpoint,
+							Method:         http.MethodPost,
+							Param:          key,
+							Payload:        canary,
+							Vector:         "json",
+							MutatedURL:     endpoint,
+							Reasons:        reasons,
+							BaselineCode:   basePOSTJSON.StatusCode,
+							MutatedCode:    obs.StatusCode,
+							BaselineLen:    basePOSTJSON.Length,
+							MutatedLen:     obs.Length,
+							BaselineMS:     basePOSTJSON.DurationMS,
+							MutatedMS:      obs.DurationMS,
+							BaselineLoc:    basePOSTJSON.Location,
+							MutatedLoc:     obs.Location,
+							Diff:           &diff,
+							EvidenceIDs:    a.saveEvidence(basePOSTJSON.Exchange, obs.Exchange),
+							SessionProfile: obs.Session,
 						})
 					}
 				}
@@ -229,38 +283,47 @@ func (a *App) runParamFuzz(ctx context.Context) error {
 		}
 
 		for _, headerKey := range paramFuzzHeaderKeys {
-			obs, err := a.sendParamFuzzRequest(ctx, clients, lastByHost, endpoint, http.MethodGet, map[string]string{
-				headerKey: "BFLOWFUZZ123",
-			}, nil, "")
+			canary := reflectionCanary()
+			sendHeader := func(value string) (paramFuzzObservation, error) {
+				return a.sendParamFuzzRequest(ctx, clients, lastByHost, endpoint, http.MethodGet, map[string]string{
+					headerKey: value,
+				}, nil, "")
+			}
+			obs, err := sendHeader(canary)
 			if err != nil {
 				continue
 			}
+			reflected(reflectionProbe{endpoint: endpoint, method: http.MethodGet, param: headerKey, vector: "request-header", send: sendHeader}, obs, canary)
 			metrics["header"] = struct {
 				requests int
 				hits     int
 			}{requests: metrics["header"].requests + 1, hits: metrics["header"].hits}
-			if reasons := paramFuzzReasons(baseGET, obs); len(reasons) > 0 {
+			if reasons, diff := paramFuzzReasons(baseGET, obs, canary); len(reasons) > 0 {
 				metrics["header"] = struct {
 					requests int
 					hits     int
 				}{requests: metrics["header"].requests, hits: metrics["header"].hits + 1}
 				_ = writeJSONLine(modeWriters["header"], paramFuzzHit{
-					Timestamp:    time.Now().UTC().Format(time.RFC3339),
-					Mode:         "header",
-					Endpoint:     endpoint,
-					Method:       http.MethodGet,
-					Param:        headerKey,
-					Vector:       "request-header",
-					MutatedURL:   endpoint,
-					Reasons:      reasons,
-					BaselineCode: baseGET.StatusCode,
-					MutatedCode:  obs.StatusCode,
-					BaselineLen:  baseGET.Length,
-					MutatedLen:   obs.Length,
-					BaselineMS:   baseGET.DurationMS,
-					MutatedMS:    obs.DurationMS,
-					BaselineLoc:  baseGET.Location,
-					MutatedLoc:   obs.Location,
+					Timestamp:      time.Now().UTC().Format(time.RFC3339),
+					Mode:           "header",
+					Endpoint:       endpoint,
+					Method:         http.MethodGet,
+					Param:          headerKey,
+					Payload:        canary,
+					Vector:         "request-header",
+					MutatedURL:     endpoint,
+					Reasons:        reasons,
+					BaselineCode:   baseGET.StatusCode,
+					MutatedCode:    obs.StatusCode,
+					BaselineLen:    baseGET.Length,
+					MutatedLen:     obs.Length,
+					BaselineMS:     baseGET.DurationMS,
+					MutatedMS:      obs.DurationMS,
+					BaselineLoc:    baseGET.Location,
+					MutatedLoc:     obs.Location,
+					Diff:           &diff,
+					EvidenceIDs:    a.saveEvidence(baseGET.Exchange, obs.Exchange),
+					SessionProfile: obs.Session,
 				})
 			}
 		}
@@ -281,44 +344,128 @@ func (a *App) runParamFuzz(ctx context.Context) error {
 			cookieList = cookieList[:paramFuzzMaxParamsPerEndpoint]
 		}
 		for _, cookieName := range cookieList {
-			obs, err := a.sendParamFuzzRequest(ctx, clients, lastByHost, endpoint, http.MethodGet, nil, nil, cookieName+"=BFLOWFUZZ123")
+			canary := reflectionCanary()
+			sendCookie := func(value string) (paramFuzzObservation, error) {
+				return a.sendParamFuzzRequest(ctx, clients, lastByHost, endpoint, http.MethodGet, nil, nil, cookieName+"="+value)
+			}
+			obs, err := sendCookie(canary)
 			if err != nil {
 				continue
 			}
+			reflected(reflectionProbe{endpoint: endpoint, method: http.MethodGet, param: cookieName, vector: "cookie", send: sendCookie}, obs, canary)
 			metrics["cookie"] = struct {
 				requests int
 				hits     int
 			}{requests: metrics["cookie"].requests + 1, hits: metrics["cookie"].hits}
-			if reasons := paramFuzzReasons(baseGET, obs); len(reasons) > 0 {
+			if reasons, diff := paramFuzzReasons(baseGET, obs, canary); len(reasons) > 0 {
 				metrics["cookie"] = struct {
 					requests int
 					hits     int
 				}{requests: metrics["cookie"].requests, hits: metrics["cookie"].hits + 1}
 				_ = writeJSONLine(modeWriters["cookie"], paramFuzzHit{
-					Timestamp:    time.Now().UTC().Format(time.RFC3339),
-					Mode:         "cookie",
-					Endpoint:     endpoint,
-					Method:       http.MethodGet,
-					Param:        cookieName,
-					Vector:       "cookie",
-					MutatedURL:   endpoint,
-					Reasons:      reasons,
-					BaselineCode: baseGET.StatusCode,
-					MutatedCode:  obs.StatusCode,
-					BaselineLen:  baseGET.Length,
-					MutatedLen:   obs.Length,
-					BaselineMS:   baseGET.DurationMS,
-					MutatedMS:    obs.DurationMS,
-					BaselineLoc:  baseGET.Location,
-					MutatedLoc:   obs.Location,
+					Timestamp:      time.Now().UTC().Format(time.RFC3339),
+					Mode:           "cookie",
+					Endpoint:       endpoint,
+					Method:         http.MethodGet,
+					Param:          cookieName,
+					Payload:        canary,
+					Vector:         "cookie",
+					MutatedURL:     endpoint,
+					Reasons:        reasons,
+					BaselineCode:   baseGET.StatusCode,
+					MutatedCode:    obs.StatusCode,
+					BaselineLen:    baseGET.Length,
+					MutatedLen:     obs.Length,
+					BaselineMS:     baseGET.DurationMS,
+					MutatedMS:      obs.DurationMS,
+					BaselineLoc:    baseGET.Location,
+					MutatedLoc:     obs.Location,
+					Diff:           &diff,
+					EvidenceIDs:    a.saveEvidence(baseGET.Exchange, obs.Exchange),
+					SessionProfile: obs.Session,
 				})
 			}
 		}
 	}
 
+	for _, req := range specTargets {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		base, err := a.sampleAPIBaseline(ctx, clients, lastByHost, req)
+		if err != nil {
+			a.logger.Printf("%s: baseline %s failed for %s: %v", StepParamFuzz, req.Method, req.URL, err)
+			continue
+		}
+		params := req.Params
+		if len(params) > paramFuzzMaxParamsPerEndpoint {
+			params = params[:paramFuzzMaxParamsPerEndpoint]
+		}
+		for _, param := range params {
+			mode := "query"
+			switch param.In {
+			case "header":
+				mode = "header"
+			case "body", "form":
+				mode = "body"
+			}
+			canary := reflectionCanary()
+			mutated, ok := req.withParam(param, canary)
+			if !ok {
+				continue
+			}
+			obs, err := a.sendAPIRequest(ctx, clients, lastByHost, mutated)
+			if err != nil {
+				continue
+			}
+			reflected(reflectionProbe{endpoint: req.URL, method: req.Method, param: param.Name, vector: "spec-" + param.In, query: param.In == "query", send: func(value string) (paramFuzzObservation, error) {
+				next, _ := req.withParam(param, value)
+				return a.sendAPIRequest(ctx, clients, lastByHost, next)
+			}}, obs, canary)
+			metrics[mode] = struct {
+				requests int
+				hits     int
+			}{requests: metrics[mode].requests + 1, hits: metrics[mode].hits}
+			reasons, diff := paramFuzzReasons(base, obs, canary)
+			if len(reasons) == 0 {
+				continue
+			}
+			metrics[mode] = struct {
+				requests int
+				hits     int
+			}{requests: metrics[mode].requests, hits: metrics[mode].hits + 1}
+			_ = writeJSONLine(modeWriters[mode], paramFuzzHit{
+				Timestamp:      time.Now().UTC().Format(time.RFC3339),
+				Mode:           mode,
+				Endpoint:       req.URL,
+				Method:         req.Method,
+				Param:          param.Name,
+				Payload:        canary,
+				Vector:         "spec-" + param.In,
+				MutatedURL:     mutated.URL,
+				Reasons:        reasons,
+				BaselineCode:   base.StatusCode,
+				MutatedCode:    obs.StatusCode,
+				BaselineLen:    base.Length,
+				MutatedLen:     obs.Length,
+				BaselineMS:     base.DurationMS,
+				MutatedMS:      obs.DurationMS,
+				BaselineLoc:    base.Location,
+				MutatedLoc:     obs.Location,
+				Diff:           &diff,
+				EvidenceIDs:    a.saveEvidence(base.Exchange, obs.Exchange),
+				SessionProfile: obs.Session,
+			})
+		}
+	}
+	if len(specTargets) > 0 {
+		a.logger.Printf("%s: fuzzed %d structured request(s) from api specs and imports", StepParamFuzz, len(specTargets))
+	}
+
 	for mode, data := range metrics {
 		a.logger.Printf("%s: mode=%s requests=%d hits=%d", StepParamFuzz, mode, data.requests, data.hits)
 	}
+	a.logger.Printf("%s: reflections=%d", StepParamFuzz, reflections)
 	return nil
 }
 
@@ -330,11 +477,10 @@ func (a *App) runInjectionChecks(ctx context.Context) error {
 		return err
 	}
 
-	outputFiles := map[string]string{
-		"sqli":   filepath.Join(injectionDir, "sqli_hits.jsonl"),
-		"nosqli": filepath.Join(injectionDir, "nosqli_hits.jsonl"),
-		"xpath":  filepath.Join(injectionDir, "xpath_hits.jsonl"),
-		"ldap":   filepath.Join(injectionDir, "ldap_hits.jsonl"),
+	stepFamilies := a.payloadFamilies(StepInjectionCheck)
+	outputFiles := make(map[string]string, len(stepFamilies))
+	for _, family := range stepFamilies {
+		outputFiles[family.Name] = filepath.Join(injectionDir, family.Name+"_hits.jsonl")
 	}
 	writers := make(map[string]*bufio.Writer, len(outputFiles))
 	files := make(map[string]*os.File, len(outputFiles))
@@ -355,7 +501,7 @@ func (a *App) runInjectionChecks(ctx context.Context) error {
 		}
 	}()
 
-	endpoints := a.collectParamFuzzEndpoints(filepath.Join(reconDir, "all_urls.txt"))
+	endpoints := a.aimEndpointsAtRoles(StepInjectionCheck, a.collectParamFuzzEndpoints(filepath.Join(reconDir, "all_urls.txt")))
 	if len(endpoints) > injectionMaxEndpoints {
 		a.logger.Printf("%s: limiting endpoints from %d to %d", StepInjectionCheck, len(endpoints), injectionMaxEndpoints)
 		endpoints = endpoints[:injectionMaxEndpoints]
@@ -367,21 +513,19 @@ func (a *App) runInjectionChecks(ctx context.Context) error {
 		sort.Strings(globalParams)
 	}
 
-	clients := &http.Client{
-		Timeout: paramFuzzRequestTimeout,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
-	}
+	clients := a.newHTTPClient(paramFuzzRequestTimeout, false)
+	techProfile := a.loadTechProfile()
+	familySkips := 0
 	lastByHost := make(map[string]time.Time)
-	metrics := map[string]struct {
+	metrics := make(map[string]struct {
 		requests int
 		hits     int
-	}{
-		"sqli":   {},
-		"nosqli": {},
-		"xpath":  {},
-		"ldap":   {},
+	}, len(stepFamilies))
+	for _, family := range stepFamilies {
+		metrics[family.Name] = struct {
+			requests int
+			hits     int
+		}{}
 	}
 
 	for _, endpoint := range endpoints {
@@ -407,56 +551,123 @@ func (a *App) runInjectionChecks(ctx context.Context) error {
 			continue
 		}
 
-		baseGET, err := a.sendParamFuzzRequest(ctx, clients, lastByHost, endpoint, http.MethodGet, nil, nil, "")
+		baseGET, err := a.sampleBaseline(ctx, clients, lastByHost, endpoint, http.MethodGet, nil, nil, "")
 		if err != nil {
 			a.logger.Printf("%s: baseline GET failed for %s: %v", StepInjectionCheck, endpoint, err)
 			continue
 		}
-		basePOSTJSON, _ := a.sendParamFuzzRequest(ctx, clients, lastByHost, endpoint, http.MethodPost, map[string]string{
+		basePOSTJSON, _ := a.sampleBaseline(ctx, clients, lastByHost, endpoint, http.MethodPost, map[string]string{
 			"Content-Type": "application/json",
 		}, []byte(`{}`), "")
 
-		for _, family := range injectionFamilies {
+		families, skipped := selectFamiliesForHost(stepFamilies, techProfile, parsed.Hostname())
+		familySkips += len(skipped)
+		for _, family := range families {
+			payloads, transforms := a.mutatePayloads(family, parsed.Hostname())
 			for _, param := range params {
-				for _, payload := range family.Payloads {
+				if family.HasVector("query") {
+					if hit, sent, ok := a.timeBasedHit(family, endpoint, http.MethodGet, param, "url-query", baseGET, func(payload string) string {
+						return mutateURLQuery(endpoint, param, payload)
+					}, func(payload string) (paramFuzzObservation, error) {
+						return a.sendParamFuzzRequest(ctx, clients, lastByHost, mutateURLQuery(endpoint, param, payload), http.MethodGet, nil, nil, "")
+					}); sent > 0 {
+						metrics[family.Name] = struct {
+							requests int
+							hits     int
+						}{requests: metrics[family.Name].requests + sent, hits: metrics[family.Name].hits}
+						if ok {
+							metrics[family.Name] = struct {
+								requests int
+								hits     int
+							}{requests: metrics[family.Name].requests, hits: metrics[family.Name].hits + 1}
+							_ = writeJSONLine(writers[family.Name], hit)
+						}
+					}
+				}
+				for _, payload := range payloads {
 					mutatedURL := mutateURLQuery(endpoint, param, payload)
-					if mutatedURL != "" {
+					if mutatedURL != "" && family.HasVector("query") {
 						obs, reqErr := a.sendParamFuzzRequest(ctx, clients, lastByHost, mutatedURL, http.MethodGet, nil, nil, "")
 						if reqErr == nil {
 							metrics[family.Name] = struct {
 								requests int
 								hits     int
 							}{requests: metrics[family.Name].requests + 1, hits: metrics[family.Name].hits}
-							reasons := injectionReasons(baseGET, obs, family.Keywords)
+							reasons, diff := injectionReasons(baseGET, obs, payload, family.Keywords, family.Compiled)
 							if len(reasons) > 0 {
 								metrics[family.Name] = struct {
 									requests int
 									hits     int
 								}{requests: metrics[family.Name].requests, hits: metrics[family.Name].hits + 1}
 								_ = writeJSONLine(writers[family.Name], injectionHit{
-									Timestamp:    time.Now().UTC().Format(time.RFC3339),
-									Family:       family.Name,
-									Endpoint:     endpoint,
-									Method:       http.MethodGet,
-									Param:        param,
-									Payload:      payload,
-									Vector:       "url-query",
-									MutatedURL:   mutatedURL,
-									Reasons:      reasons,
-									BaselineCode: baseGET.StatusCode,
-									MutatedCode:  obs.StatusCode,
-									BaselineLen:  baseGET.Length,
-									MutatedLen:   obs.Length,
-									BaselineMS:   baseGET.DurationMS,
-									MutatedMS:    obs.DurationMS,
-									BaselineLoc:  baseGET.Location,
-									MutatedLoc:   obs.Location,
+									Timestamp:      time.Now().UTC().Format(time.RFC3339),
+									Family:         family.Name,
+									Endpoint:       endpoint,
+									Method:         http.MethodGet,
+									Param:          param,
+									Payload:        payload,
+									Vector:         "url-query",
+									MutatedURL:     mutatedURL,
+									Reasons:        reasons,
+									BaselineCode:   baseGET.StatusCode,
+									MutatedCode:    obs.StatusCode,
+									BaselineLen:    baseGET.Length,
+									MutatedLen:     obs.Length,
+									BaselineMS:     baseGET.DurationMS,
+									MutatedMS:      obs.DurationMS,
+									BaselineLoc:    baseGET.Location,
+									MutatedLoc:     obs.Location,
+									Transforms:     transforms[payload],
+									Diff:           &diff,
+									EvidenceIDs:    a.saveEvidence(baseGET.Exchange, obs.Exchange),
+									SessionProfile: obs.Session,
 								})
 							}
 						}
 					}
 
-					if family.Name == "nosqli" && basePOSTJSON.StatusCode > 0 {
+					if family.HasVector("cookie") && headerSafePayload(payload) {
+						cookie := param + "=" + payload
+						obs, reqErr := a.sendParamFuzzRequest(ctx, clients, lastByHost, endpoint, http.MethodGet, nil, nil, cookie)
+						if reqErr == nil {
+							metrics[family.Name] = struct {
+								requests int
+								hits     int
+							}{requests: metrics[family.Name].requests + 1, hits: metrics[family.Name].hits}
+							reasons, diff := injectionReasons(baseGET, obs, payload, family.Keywords, family.Compiled)
+							if len(reasons) > 0 {
+								metrics[family.Name] = struct {
+									requests int
+									hits     int
+								}{requests: metrics[family.Name].requests, hits: metrics[family.Name].hits + 1}
+								_ = writeJSONLine(writers[family.Name], injectionHit{
+									Timestamp:      time.Now().UTC().Format(time.RFC3339),
+									Family:         family.Name,
+									Endpoint:       endpoint,
+									Method:         http.MethodGet,
+									Param:          param,
+									Payload:        payload,
+									Vector:         "cookie",
+									MutatedURL:     endpoint,
+									Reasons:        reasons,
+									BaselineCode:   baseGET.StatusCode,
+									MutatedCode:    obs.StatusCode,
+									BaselineLen:    baseGET.Length,
+									MutatedLen:     obs.Length,
+									BaselineMS:     baseGET.DurationMS,
+									MutatedMS:      obs.DurationMS,
+									BaselineLoc:    baseGET.Location,
+									MutatedLoc:     obs.Location,
+									Transforms:     transforms[payload],
+									Diff:           &diff,
+									EvidenceIDs:    a.saveEvidence(baseGET.Exchange, obs.Exchange),
+									SessionProfile: obs.Session,
+								})
+							}
+						}
+					}
+
+					if family.HasVector("json") && basePOSTJSON.StatusCode > 0 {
 						body, _ := json.Marshal(map[string]string{param: payload})
 						obs, reqErr := a.sendParamFuzzRequest(ctx, clients, lastByHost, endpoint, http.MethodPost, map[string]string{
 							"Content-Type": "application/json",
@@ -466,30 +677,34 @@ func (a *App) runInjectionChecks(ctx context.Context) error {
 								requests int
 								hits     int
 							}{requests: metrics[family.Name].requests + 1, hits: metrics[family.Name].hits}
-							reasons := injectionReasons(basePOSTJSON, obs, family.Keywords)
+							reasons, diff := injectionReasons(basePOSTJSON, obs, payload, family.Keywords, family.Compiled)
 							if len(reasons) > 0 {
 								metrics[family.Name] = struct {
 									requests int
 									hits     int
 								}{requests: metrics[family.Name].requests, hits: metrics[family.Name].hits + 1}
 								_ = writeJSONLine(writers[family.Name], injectionHit{
-									Timestamp:    time.Now().UTC().Format(time.RFC3339),
-									Family:       family.Name,
-									Endpoint:     endpoint,
-									Method:       http.MethodPost,
-									Param:        param,
-									Payload:      payload,
-									Vector:       "json-body",
-									MutatedURL:   endpoint,
-									Reasons:      reasons,
-									BaselineCode: basePOSTJSON.StatusCode,
-									MutatedCode:  obs.StatusCode,
-									BaselineLen:  basePOSTJSON.Length,
-									MutatedLen:   obs.Length,
-									BaselineMS:   basePOSTJSON.DurationMS,
-									MutatedMS:    obs.DurationMS,
-									BaselineLoc:  basePOSTJSON.Location,
-									MutatedLoc:   obs.Location,
+									Timestamp:      time.Now().UTC().Format(time.RFC3339),
+									Family:         family.Name,
+									Endpoint:       endpoint,
+									Method:         http.MethodPost,
+									Param:          param,
+									Payload:        payload,
+									Vector:         "json-body",
+									MutatedURL:     endpoint,
+									Reasons:        reasons,
+									BaselineCode:   basePOSTJSON.StatusCode,
+									MutatedCode:    obs.StatusCode,
+									BaselineLen:    basePOSTJSON.Length,
+									MutatedLen:     obs.Length,
+									BaselineMS:     basePOSTJSON.DurationMS,
+									MutatedMS:      obs.DurationMS,
+									BaselineLoc:    basePOSTJSON.Location,
+									MutatedLoc:     obs.Location,
+									Transforms:     transforms[payload],
+									Diff:           &diff,
+									EvidenceIDs:    a.saveEvidence(basePOSTJSON.Exchange, obs.Exchange),
+									SessionProfile: obs.Session,
 								})
 							}
 						}
@@ -499,12 +714,113 @@ func (a *App) runInjectionChecks(ctx context.Context) error {
 		}
 	}
 
+	skips, err := a.fuzzStructuredRequests(ctx, StepInjectionCheck, stepFamilies, techProfile, clients, lastByHost, writers, metrics)
+	if err != nil {
+		return err
+	}
+	familySkips += skips
+
 	for family, row := range metrics {
 		a.logger.Printf("%s: family=%s requests=%d hits=%d", StepInjectionCheck, family, row.requests, row.hits)
 	}
+	if familySkips > 0 {
+		a.logger.Printf("%s: skipped %d family run(s) conflicting with the host tech profile", StepInjectionCheck, familySkips)
+	}
 	return nil
 }
 
+// fuzzStructuredRequests sends the step's families through spec-derived and imported
+// requests. Each request keeps its method, body and headers; payloads go into each known
+// parameter wherever the request carries it, for families whose vectors cover that spot.
+// It returns how many family runs conflicted with the host tech profile.
+func (a *App) fuzzStructuredRequests(ctx context.Context, step string, stepFamilies []injectionFamilyConfig, techProfile map[string]map[string]*techEntry, clients *http.Client, lastByHost map[string]time.Time, writers map[string]*bufio.Writer, metrics map[string]struct {
+	requests int
+	hits     int
+}) (int, error) {
+	specTargets := apiSpecFuzzTargets(a.loadStructuredRequests(), apiSpecMaxFuzzRequests)
+	familySkips := 0
+	for _, req := range specTargets {
+		if ctx.Err() != nil {
+			return familySkips, ctx.Err()
+		}
+		parsed, err := url.Parse(req.URL)
+		if err != nil {
+			continue
+		}
+		base, err := a.sampleAPIBaseline(ctx, clients, lastByHost, req)
+		if err != nil {
+			a.logger.Printf("%s: baseline %s failed for %s: %v", step, req.Method, req.URL, err)
+			continue
+		}
+		params := req.Params
+		if len(params) > injectionMaxParamsPerEndpoint {
+			params = params[:injectionMaxParamsPerEndpoint]
+		}
+		families, skipped := selectFamiliesForHost(stepFamilies, techProfile, parsed.Hostname())
+		familySkips += len(skipped)
+		for _, family := range families {
+			payloads, transforms := a.mutatePayloads(family, parsed.Hostname())
+			for _, param := range params {
+				if !family.HasVector(specParamVector(param.In)) {
+					continue
+				}
+				for _, payload := range payloads {
+					if param.In == "header" && !headerSafePayload(payload) {
+						continue
+					}
+					mutated, ok := req.withParam(param, payload)
+					if !ok {
+						continue
+					}
+					obs, reqErr := a.sendAPIRequest(ctx, clients, lastByHost, mutated)
+					if reqErr != nil {
+						continue
+					}
+					metrics[family.Name] = struct {
+						requests int
+						hits     int
+					}{requests: metrics[family.Name].requests + 1, hits: metrics[family.Name].hits}
+					reasons, diff := injectionReasons(base, obs, payload, family.Keywords, family.Compiled)
+					if len(reasons) == 0 {
+						continue
+					}
+					metrics[family.Name] = struct {
+						requests int
+						hits     int
+					}{requests: metrics[family.Name].requests, hits: metrics[family.Name].hits + 1}
+					_ = writeJSONLine(writers[family.Name], injectionHit{
+						Timestamp:      time.Now().UTC().Format(time.RFC3339),
+						Family:         family.Name,
+						Endpoint:       req.URL,
+						Method:         req.Method,
+						Param:          param.Name,
+						Payload:        payload,
+						Vector:         "spec-" + param.In,
+						MutatedURL:     mutated.URL,
+						Reasons:        reasons,
+						BaselineCode:   base.StatusCode,
+						MutatedCode:    obs.StatusCode,
+						BaselineLen:    base.Length,
+						MutatedLen:     obs.Length,
+						BaselineMS:     base.DurationMS,
+						MutatedMS:      obs.DurationMS,
+						BaselineLoc:    base.Location,
+						MutatedLoc:     obs.Location,
+						Transforms:     transforms[payload],
+						Diff:           &diff,
+						EvidenceIDs:    a.saveEvidence(base.Exchange, obs.Exchange),
+						SessionProfile: obs.Session,
+					})
+				}
+			}
+		}
+	}
+	if len(specTargets) > 0 {
+		a.logger.Printf("%s: checked %d structured request(s) from api specs and imports", step, len(specTargets))
+	}
+	return familySkips, nil
+}
+
 func (a *App) runServerInputChecks(ctx context.Context) error {
 	baseDir := filepath.Dir(a.cfg.Lists.Domains)
 	reconDir := filepath.Join(baseDir, "recon")
@@ -513,10 +829,10 @@ func (a *App) runServerInputChecks(ctx context.Context) error {
 		return err
 	}
 
-	outputFiles := map[string]string{
-		"os_command":     filepath.Join(outDir, "os_command_hits.jsonl"),
-		"path_traversal": filepath.Join(outDir, "path_traversal_hits.jsonl"),
-		"file_inclusion": filepath.Join(outDir, "file_inclusion_hits.jsonl"),
+	stepFamilies := a.payloadFamilies(StepServerInputChk)
+	outputFiles := make(map[string]string, len(stepFamilies))
+	for _, family := range stepFamilies {
+		outputFiles[family.Name] = filepath.Join(outDir, family.Name+"_hits.jsonl")
 	}
 	writers := make(map[string]*bufio.Writer, len(outputFiles))
 	files := make(map[string]*os.File, len(outputFiles))
@@ -537,7 +853,7 @@ func (a *App) runServerInputChecks(ctx context.Context) error {
 		}
 	}()
 
-	endpoints := a.collectParamFuzzEndpoints(filepath.Join(reconDir, "all_urls.txt"))
+	endpoints := a.aimEndpointsAtRoles(StepServerInputChk, a.collectParamFuzzEndpoints(filepath.Join(reconDir, "all_urls.txt")))
 	if len(endpoints) > serverInputMaxEndpoints {
 		a.logger.Printf("%s: limiting endpoints from %d to %d", StepServerInputChk, len(endpoints), serverInputMaxEndpoints)
 		endpoints = endpoints[:serverInputMaxEndpoints]
@@ -549,21 +865,21 @@ func (a *App) runServerInputChecks(ctx context.Context) error {
 		sort.Strings(globalParams)
 	}
 
-	clients := &http.Client{
-		Timeout: paramFuzzRequestTimeout,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
-	}
+	clients := a.newHTTPClient(paramFuzzRequestTimeout, false)
+	techProfile := a.loadTechProfile()
+	familySkips := 0
 	lastByHost := make(map[string]time.Time)
-	metrics := map[string]struct {
+	metrics := make(map[string]struct {
 		requests int
 		hits     int
-	}{
-		"os_command":     {},
-		"path_traversal": {},
-		"file_inclusion": {},
+	}, len(stepFamilies))
+	for _, family := range stepFamilies {
+		metrics[family.Name] = struct {
+			requests int
+			hits     int
+		}{}
 	}
+	oobSent := 0
 
 	for _, endpoint := range endpoints {
 		parsed, err := url.Parse(endpoint)
@@ -588,15 +904,42 @@ func (a *App) runServerInputChecks(ctx context.Context) error {
 			continue
 		}
 
-		baseGET, err := a.sendParamFuzzRequest(ctx, clients, lastByHost, endpoint, http.MethodGet, nil, nil, "")
+		baseGET, err := a.sampleBaseline(ctx, clients, lastByHost, endpoint, http.MethodGet, nil, nil, "")
 		if err != nil {
 			a.logger.Printf("%s: baseline GET failed for %s: %v", StepServerInputChk, endpoint, err)
 			continue
 		}
 
-		for _, family := range serverInputFamilies {
+		families, skipped := selectFamiliesForHost(stepFamilies, techProfile, parsed.Hostname())
+		familySkips += len(skipped)
+		for _, family := range families {
+			payloads, transforms := a.mutatePayloads(family, parsed.Hostname())
+			if sent := a.sendOOBProbes(ctx, clients, lastByHost, StepServerInputChk, family, endpoint, params); sent > 0 {
+				oobSent += sent
+				metrics[family.Name] = struct {
+					requests int
+					hits     int
+				}{requests: metrics[family.Name].requests + sent, hits: metrics[family.Name].hits}
+			}
 			for _, param := range params {
-				for _, payload := range family.Payloads {
+				if hit, sent, ok := a.timeBasedHit(family, endpoint, http.MethodGet, param, "url-query", baseGET, func(payload string) string {
+					return mutateURLQuery(endpoint, param, payload)
+				}, func(payload string) (paramFuzzObservation, error) {
+					return a.sendParamFuzzRequest(ctx, clients, lastByHost, mutateURLQuery(endpoint, param, payload), http.MethodGet, nil, nil, "")
+				}); sent > 0 {
+					metrics[family.Name] = struct {
+						requests int
+						hits     int
+					}{requests: metrics[family.Name].requests + sent, hits: metrics[family.Name].hits}
+					if ok {
+						metrics[family.Name] = struct {
+							requests int
+							hits     int
+						}{requests: metrics[family.Name].requests, hits: metrics[family.Name].hits + 1}
+						_ = writeJSONLine(writers[family.Name], hit)
+					}
+				}
+				for _, payload := range payloads {
 					mutatedURL := mutateURLQuery(endpoint, param, payload)
 					if mutatedURL == "" {
 						continue
@@ -609,7 +952,7 @@ func (a *App) runServerInputChecks(ctx context.Context) error {
 						requests int
 						hits     int
 					}{requests: metrics[family.Name].requests + 1, hits: metrics[family.Name].hits}
-					reasons := injectionReasons(baseGET, obs, family.Keywords)
+					reasons, diff := injectionReasons(baseGET, obs, payload, family.Keywords, family.Compiled)
 					if len(reasons) == 0 {
 						continue
 					}
@@ -618,32 +961,46 @@ func (a *App) runServerInputChecks(ctx context.Context) error {
 						hits     int
 					}{requests: metrics[family.Name].requests, hits: metrics[family.Name].hits + 1}
 					_ = writeJSONLine(writers[family.Name], injectionHit{
-						Timestamp:    time.Now().UTC().Format(time.RFC3339),
-						Family:       family.Name,
-						Endpoint:     endpoint,
-						Method:       http.MethodGet,
-						Param:        param,
-						Payload:      payload,
-						Vector:       "url-query",
-						MutatedURL:   mutatedURL,
-						Reasons:      reasons,
-						BaselineCode: baseGET.StatusCode,
-						MutatedCode:  obs.StatusCode,
-						BaselineLen:  baseGET.Length,
-						MutatedLen:   obs.Length,
-						BaselineMS:   baseGET.DurationMS,
-						MutatedMS:    obs.DurationMS,
-						BaselineLoc:  baseGET.Location,
-						MutatedLoc:   obs.Location,
+						Timestamp:      time.Now().UTC().Format(time.RFC3339),
+						Family:         family.Name,
+						Endpoint:       endpoint,
+						Method:         http.MethodGet,
+						Param:          param,
+						Payload:        payload,
+						Vector:         "url-query",
+						MutatedURL:     mutatedURL,
+						Reasons:        reasons,
+						BaselineCode:   baseGET.StatusCode,
+						MutatedCode:    obs.StatusCode,
+						BaselineLen:    baseGET.Length,
+						MutatedLen:     obs.Length,
+						BaselineMS:     baseGET.DurationMS,
+						MutatedMS:      obs.DurationMS,
+						BaselineLoc:    baseGET.Location,
+						MutatedLoc:     obs.Location,
+						Transforms:     transforms[payload],
+						Diff:           &diff,
+						EvidenceIDs:    a.saveEvidence(baseGET.Exchange, obs.Exchange),
+						SessionProfile: obs.Session,
 					})
 				}
 			}
 		}
 	}
 
+	skips, err := a.fuzzStructuredRequests(ctx, StepServerInputChk, stepFamilies, techProfile, clients, lastByHost, writers, metrics)
+	if err != nil {
+		return err
+	}
+	familySkips += skips
+
+	a.waitForOOB(ctx, StepServerInputChk, oobSent)
 	for family, row := range metrics {
 		a.logger.Printf("%s: family=%s requests=%d hits=%d", StepServerInputChk, family, row.requests, row.hits)
 	}
+	if familySkips > 0 {
+		a.logger.Printf("%s: skipped %d family run(s) conflicting with the host tech profile", StepServerInputChk, familySkips)
+	}
 	return nil
 }
 
@@ -655,11 +1012,10 @@ func (a *App) runAdvancedInjectionChecks(ctx context.Context) error {
 		return err
 	}
 
-	outputFiles := map[string]string{
-		"xxe":  filepath.Join(outDir, "xxe_hits.jsonl"),
-		"soap": filepath.Join(outDir, "soap_hits.jsonl"),
-		"ssrf": filepath.Join(outDir, "ssrf_hits.jsonl"),
-		"smtp": filepath.Join(outDir, "smtp_hits.jsonl"),
+	stepFamilies := a.payloadFamilies(StepAdvInjection)
+	outputFiles := make(map[string]string, len(stepFamilies))
+	for _, family := range stepFamilies {
+		outputFiles[family.Name] = filepath.Join(outDir, family.Name+"_hits.jsonl")
 	}
 	writers := make(map[string]*bufio.Writer, len(outputFiles))
 	files := make(map[string]*os.File, len(outputFiles))
@@ -680,7 +1036,7 @@ func (a *App) runAdvancedInjectionChecks(ctx context.Context) error {
 		}
 	}()
 
-	endpoints := a.collectParamFuzzEndpoints(filepath.Join(reconDir, "all_urls.txt"))
+	endpoints := a.aimEndpointsAtRoles(StepAdvInjection, a.collectParamFuzzEndpoints(filepath.Join(reconDir, "all_urls.txt")))
 	if len(endpoints) > advInjectionMaxEndpoints {
 		a.logger.Printf("%s: limiting endpoints from %d to %d", StepAdvInjection, len(endpoints), advInjectionMaxEndpoints)
 		endpoints = endpoints[:advInjectionMaxEndpoints]
@@ -692,22 +1048,21 @@ func (a *App) runAdvancedInjectionChecks(ctx context.Context) error {
 		sort.Strings(globalParams)
 	}
 
-	clients := &http.Client{
-		Timeout: paramFuzzRequestTimeout,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
-	}
+	clients := a.newHTTPClient(paramFuzzRequestTimeout, false)
+	techProfile := a.loadTechProfile()
+	familySkips := 0
 	lastByHost := make(map[string]time.Time)
-	metrics := map[string]struct {
+	metrics := make(map[string]struct {
 		requests int
 		hits     int
-	}{
-		"xxe":  {},
-		"soap": {},
-		"ssrf": {},
-		"smtp": {},
+	}, len(stepFamilies))
+	for _, family := range stepFamilies {
+		metrics[family.Name] = struct {
+			requests int
+			hits     int
+		}{}
 	}
+	oobSent := 0
 
 	for _, endpoint := range endpoints {
 		parsed, err := url.Parse(endpoint)
@@ -732,15 +1087,25 @@ func (a *App) runAdvancedInjectionChecks(ctx context.Context) error {
 			continue
 		}
 
-		baseGET, err := a.sendParamFuzzRequest(ctx, clients, lastByHost, endpoint, http.MethodGet, nil, nil, "")
+		baseGET, err := a.sampleBaseline(ctx, clients, lastByHost, endpoint, http.MethodGet, nil, nil, "")
 		if err != nil {
 			a.logger.Printf("%s: baseline GET failed for %s: %v", StepAdvInjection, endpoint, err)
 			continue
 		}
 
-		for _, family := range advInjectionFamilies {
+		families, skipped := selectFamiliesForHost(stepFamilies, techProfile, parsed.Hostname())
+		familySkips += len(skipped)
+		for _, family := range families {
+			payloads, transforms := a.mutatePayloads(family, parsed.Hostname())
+			if sent := a.sendOOBProbes(ctx, clients, lastByHost, StepAdvInjection, family, endpoint, params); sent > 0 {
+				oobSent += sent
+				metrics[family.Name] = struct {
+					requests int
+					hits     int
+				}{requests: metrics[family.Name].requests + sent, hits: metrics[family.Name].hits}
+			}
 			for _, param := range params {
-				for _, payload := range family.Payloads {
+				for _, payload := range payloads {
 					mutatedURL := mutateURLQuery(endpoint, param, payload)
 					if mutatedURL == "" {
 						continue
@@ -752,15 +1117,15 @@ func (a *App) runAdvancedInjectionChecks(ctx context.Context) error {
 					vector := "url-query"
 					targetURL := mutatedURL
 
-					if family.Name == "xxe" || family.Name == "soap" {
+					if family.HasVector("xml") {
 						method = http.MethodPost
 						vector = "xml-body"
 						targetURL = endpoint
 						headers = map[string]string{"Content-Type": "application/xml"}
-						body = []byte(payload)
-						if family.Name == "soap" {
-							headers["SOAPAction"] = "urn:bflow:probe"
+						for k, v := range family.Headers {
+							headers[k] = v
 						}
+						body = []byte(payload)
 					}
 
 					obs, reqErr := a.sendParamFuzzRequest(ctx, clients, lastByHost, targetURL, method, headers, body, "")
@@ -771,7 +1136,7 @@ func (a *App) runAdvancedInjectionChecks(ctx context.Context) error {
 						requests int
 						hits     int
 					}{requests: metrics[family.Name].requests + 1, hits: metrics[family.Name].hits}
-					reasons := injectionReasons(baseGET, obs, family.Keywords)
+					reasons, diff := injectionReasons(baseGET, obs, payload, family.Keywords, family.Compiled)
 					if len(reasons) == 0 {
 						continue
 					}
@@ -780,31 +1145,45 @@ func (a *App) runAdvancedInjectionChecks(ctx context.Context) error {
 						hits     int
 					}{requests: metrics[family.Name].requests, hits: metrics[family.Name].hits + 1}
 					_ = writeJSONLine(writers[family.Name], injectionHit{
-						Timestamp:    time.Now().UTC().Format(time.RFC3339),
-						Family:       family.Name,
-						Endpoint:     endpoint,
-						Method:       method,
-						Param:        param,
-						Payload:      payload,
-						Vector:       vector,
-						MutatedURL:   targetURL,
-						Reasons:      reasons,
-						BaselineCode: baseGET.StatusCode,
-						MutatedCode:  obs.StatusCode,
-						BaselineLen:  baseGET.Length,
-						MutatedLen:   obs.Length,
-						BaselineMS:   baseGET.DurationMS,
-						MutatedMS:    obs.DurationMS,
-						BaselineLoc:  baseGET.Location,
-						MutatedLoc:   obs.Location,
+						Timestamp:      time.Now().UTC().Format(time.RFC3339),
+						Family:         family.Name,
+						Endpoint:       endpoint,
+						Method:         method,
+						Param:          param,
+						Payload:        payload,
+						Vector:         vector,
+						MutatedURL:     targetURL,
+						Reasons:        reasons,
+						BaselineCode:   baseGET.StatusCode,
+						MutatedCode:    obs.StatusCode,
+						BaselineLen:    baseGET.Length,
+						MutatedLen:     obs.Length,
+						BaselineMS:     baseGET.DurationMS,
+						MutatedMS:      obs.DurationMS,
+						BaselineLoc:    baseGET.Location,
+						MutatedLoc:     obs.Location,
+						Transforms:     transforms[payload],
+						Diff:           &diff,
+						EvidenceIDs:    a.saveEvidence(baseGET.Exchange, obs.Exchange),
+						SessionProfile: obs.Session,
 					})
 				}
 			}
 		}
 	}
 
+	skips, err := a.fuzzStructuredRequests(ctx, StepAdvInjection, stepFamilies, techProfile, clients, lastByHost, writers, metrics)
+	if err != nil {
+		return err
+	}
+	familySkips += skips
+
+	a.waitForOOB(ctx, StepAdvInjection, oobSent)
 	for family, row := range metrics {
 		a.logger.Printf("%s: family=%s requests=%d hits=%d", StepAdvInjection, family, row.requests, row.hits)
 	}
+	if familySkips > 0 {
+		a.logger.Printf("%s: skipped %d family run(s) conflicting with the host tech profile", StepAdvInjection, familySkips)
+	}
 	return nil
 }
@@ -0,0 +1,1013 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	graphqlMaxHosts          = 150
+	graphqlMaxEndpoints      = 40
+	graphqlBodyLimit         = 5 << 20
+	graphqlBatchSize         = 10
+	graphqlAliasCount        = 100
+	graphqlDepthProbe        = 12
+	graphqlSuggestionChunk   = 20
+	graphqlMaxAuthzFields    = 15
+	graphqlMaxAuthzSubfields = 5
+)
+
+var graphqlCommonPaths = []string{
+	"/graphql", "/api/graphql", "/graphql/v1", "/v1/graphql", "/api/v1/graphql", "/v2/graphql",
+	"/gql", "/query", "/graphiql", "/playground", "/graphql/console", "/api",
+}
+
+// graphqlEngineSignatures follow graphw00f's approach: malformed queries produce error text
+// specific to each server implementation. Order matters where markers overlap.
+var graphqlEngineSignatures = []struct {
+	engine string
+	marker string
+}{
+	{"hasura", "x-hasura"},
+	{"hasura", "validation-failed"},
+	{"hotchocolate", "\"code\":\"hc0"},
+	{"hotchocolate", "unexpected execution error"},
+	{"graphql-java", "validation error of type"},
+	{"graphql-java", "invalid syntax : offending token"},
+	{"graphene", "syntax error graphql ("},
+	{"ariadne", "the query must be a string"},
+	{"strawberry", "strawberry"},
+	{"sangria", "syntax error while parsing graphql query"},
+	{"juniper", "unexpected \"queryy\""},
+	{"graphql-php", "\"category\":\"graphql\""},
+	{"dgraph", "dgraph"},
+	{"gqlgen", "expected at least one definition"},
+	{"apollo", "graphql_validation_failed"},
+	{"apollo", "graphql_parse_failed"},
+	{"graphql-js", "syntax error: unexpected name"},
+}
+
+// graphqlFieldWordlist seeds suggestion-based schema recovery when introspection is off.
+var graphqlFieldWordlist = []string{
+	"user", "users", "me", "viewer", "account", "accounts", "profile", "admin", "admins", "node", "nodes",
+	"search", "order", "orders", "product", "products", "item", "items", "customer", "customers", "invoice",
+	"invoices", "payment", "payments", "transaction", "transactions", "settings", "config", "configuration",
+	"organization", "organizations", "team", "teams", "project", "projects", "file", "files", "upload",
+	"message", "messages", "comment", "comments", "post", "posts", "role", "roles", "permission",
+	"permissions", "token", "tokens", "apiKey", "apiKeys", "session", "sessions", "log", "logs", "audit",
+	"auditLogs", "debug", "internal", "system", "health", "version", "employee", "employees", "member",
+	"members", "group", "groups", "billing", "subscription", "subscriptions", "notification", "notifications",
+}
+
+var graphqlMutationWordlist = []string{
+	"login", "logout", "signup", "register", "createUser", "updateUser", "deleteUser", "resetPassword",
+	"changePassword", "updateProfile", "createOrder", "updateOrder", "deleteOrder", "verifyOtp", "verifyEmail",
+	"createToken", "createApiKey", "addMember", "removeMember", "updateSettings", "upload", "uploadFile",
+}
+
+var graphqlSensitiveFieldTerms = []string{
+	"user", "admin", "account", "email", "password", "token", "secret", "internal", "config", "setting",
+	"private", "billing", "payment", "invoice", "order", "customer", "employee", "member", "role",
+	"permission", "apikey", "session", "audit", "log", "debug", "system",
+}
+
+var graphqlBruteForceTerms = []string{"login", "signin", "otp", "2fa", "mfa", "verify", "reset", "password", "token"}
+
+var (
+	graphqlSuggestionRe  = regexp.MustCompile(`Cannot query field "([^"]+)" on type "([^"]+)"\.(?: Did you mean ([^?]+)\?)?`)
+	graphqlQuotedRe      = regexp.MustCompile(`"([_A-Za-z][_0-9A-Za-z]*)"`)
+	graphqlSubfieldsRe   = regexp.MustCompile(`Field "([^"]+)" of type "([^"]+)" must have a selection of subfields`)
+	graphqlRequiredArgRe = regexp.MustCompile(`Field "([^"]+)" argument "([^"]+)" of type "[^"]+" is required`)
+	graphqlDepthErrorRe  = regexp.MustCompile(`(?i)(depth|complexity|too (deep|complex)|max(imum)?[ _-]?(query)?[ _-]?(depth|cost))`)
+)
+
+const graphqlIntrospectionQuery = `query IntrospectionQuery{__schema{queryType{name}mutationType{name}subscriptionType{name}types{kind name fields(includeDeprecated:true){name args{name type{...TypeRef}}type{...TypeRef}}}}}fragment TypeRef on __Type{kind name ofType{kind name ofType{kind name ofType{kind name ofType{kind name ofType{kind name ofType{kind name}}}}}}}`
+
+type graphqlEndpointRecord struct {
+	Timestamp        string   `json:"timestamp"`
+	Endpoint         string   `json:"endpoint"`
+	Host             string   `json:"host"`
+	Source           string   `json:"source"`
+	Engine           string   `json:"engine,omitempty"`
+	EngineEvidence   string   `json:"engine_evidence,omitempty"`
+	Introspection    bool     `json:"introspection"`
+	IntrospectionVia string   `json:"introspection_via,omitempty"`
+	SchemaFile       string   `json:"schema_file,omitempty"`
+	Suggestions      bool     `json:"suggestions"`
+	QueryType        string   `json:"query_type,omitempty"`
+	MutationType     string   `json:"mutation_type,omitempty"`
+	QueryFields      []string `json:"query_fields,omitempty"`
+	MutationFields   []string `json:"mutation_fields,omitempty"`
+	GETQueries       bool     `json:"get_queries"`
+	Batching         bool     `json:"batching"`
+	Aliasing         bool     `json:"aliasing"`
+	MaxDepthAccepted int      `json:"max_depth_accepted,omitempty"`
+}
+
+type graphqlFinding struct {
+	Timestamp    string   `json:"timestamp"`
+	Endpoint     string   `json:"endpoint"`
+	Host         string   `json:"host"`
+	Engine       string   `json:"engine,omitempty"`
+	Family       string   `json:"family"`
+	Severity     string   `json:"severity"`
+	Field        string   `json:"field,omitempty"`
+	Query        string   `json:"query,omitempty"`
+	StatusCode   int      `json:"status_code"`
+	Reasons      []string `json:"reasons"`
+	ManualAction string   `json:"manual_action"`
+	EvidenceIDs  []string `json:"evidence_ids,omitempty"`
+}
+
+type graphqlResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+type graphqlTypeRef struct {
+	Kind   string          `json:"kind"`
+	Name   *string         `json:"name"`
+	OfType *graphqlTypeRef `json:"ofType"`
+}
+
+type graphqlIntrospection struct {
+	Data struct {
+		Schema *struct {
+			QueryType *struct {
+				Name string `json:"name"`
+			} `json:"queryType"`
+			MutationType *struct {
+				Name string `json:"name"`
+			} `json:"mutationType"`
+			Types []struct {
+				Kind   string `json:"kind"`
+				Name   string `json:"name"`
+				Fields []struct {
+					Name string `json:"name"`
+					Args []struct {
+						Name string         `json:"name"`
+						Type graphqlTypeRef `json:"type"`
+					} `json:"args"`
+					Type graphqlTypeRef `json:"type"`
+				} `json:"fields"`
+			} `json:"types"`
+		} `json:"__schema"`
+	} `json:"data"`
+}
+
+// graphqlSchema is the subset of a schema the checks need, whether it came from
+// introspection or was pieced together from field suggestions.
+type graphqlSchema struct {
+	queryType    string
+	mutationType string
+	types        map[string]map[string]graphqlField
+}
+
+type graphqlField struct {
+	typeName     string
+	kind         string
+	requiredArgs bool
+}
+
+type graphqlTarget struct {
+	endpoint string
+	source   string
+}
+
+func (a *App) graphqlDir() string {
+	return filepath.Join(a.fuzzingBaseDir(), "graphql")
+}
+
+func (a *App) runGraphQL(ctx context.Context) error {
+	outDir := a.graphqlDir()
+	schemaDir := filepath.Join(outDir, "schemas")
+	if err := os.MkdirAll(schemaDir, 0o755); err != nil {
+		return err
+	}
+	endpointsFile, err := os.Create(filepath.Join(outDir, "endpoints.jsonl"))
+	if err != nil {
+		return err
+	}
+	defer endpointsFile.Close()
+	endpointsWriter := bufio.NewWriter(endpointsFile)
+	defer endpointsWriter.Flush()
+	findingsFile, err := os.Create(filepath.Join(outDir, "findings.jsonl"))
+	if err != nil {
+		return err
+	}
+	defer findingsFile.Close()
+	findingsWriter := bufio.NewWriter(findingsFile)
+	defer findingsWriter.Flush()
+
+	client := a.newHTTPClient(paramFuzzRequestTimeout*2, false)
+	lastByHost := make(map[string]time.Time)
+	metrics := map[string]int{"candidates": 0, "endpoints": 0, "introspection": 0, "suggestions": 0, "findings": 0}
+
+	candidates := a.collectGraphQLCandidates()
+	metrics["candidates"] = len(candidates)
+	confirmed := make(map[string]struct{})
+	for _, candidate := range candidates {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if metrics["endpoints"] >= graphqlMaxEndpoints {
+			a.logger.Printf("%s: endpoint limit %d reached", StepGraphQL, graphqlMaxEndpoints)
+			break
+		}
+		parsed, err := url.Parse(candidate.endpoint)
+		if err != nil {
+			continue
+		}
+		host := strings.ToLower(parsed.Hostname())
+		// One endpoint per host is enough; /graphql and /api/graphql often front the same server.
+		if _, ok := confirmed[host]; ok {
+			continue
+		}
+		status, _, ok := a.detectGraphQL(ctx, client, lastByHost, candidate.endpoint)
+		if !ok {
+			continue
+		}
+		confirmed[host] = struct{}{}
+		metrics["endpoints"]++
+
+		rec := graphqlEndpointRecord{
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Endpoint:  candidate.endpoint,
+			Host:      host,
+			Source:    candidate.source,
+		}
+		var findings []graphqlFinding
+		addFinding := func(f graphqlFinding, exchanges ...*Evidence) {
+			f.EvidenceIDs = a.saveEvidence(exchanges...)
+			f.Timestamp = rec.Timestamp
+			f.Endpoint = rec.Endpoint
+			f.Host = rec.Host
+			f.Engine = rec.Engine
+			if f.StatusCode == 0 {
+				f.StatusCode = status
+			}
+			findings = append(findings, f)
+		}
+
+		rec.Engine, rec.EngineEvidence = a.fingerprintGraphQL(ctx, client, lastByHost, candidate.endpoint)
+
+		schema, raw, via, introspection := a.introspectGraphQL(ctx, client, lastByHost, candidate.endpoint)
+		if schema != nil {
+			metrics["introspection"]++
+			rec.Introspection = true
+			rec.IntrospectionVia = via
+			sum := sha1.Sum([]byte(candidate.endpoint))
+			schemaPath := filepath.Join(schemaDir, sanitizeFilename(host)+"_"+hex.EncodeToString(sum[:4])+".json")
+			if err := os.WriteFile(schemaPath, raw, 0o644); err == nil {
+				rec.SchemaFile = schemaPath
+			}
+			addFinding(graphqlFinding{
+				Family:       "graphql_introspection",
+				Severity:     "low",
+				Query:        via,
+				Reasons:      []string{"introspection_enabled:" + via},
+				ManualAction: "Introspection is enabled: load the saved schema into a GraphQL client, look for admin/internal types and mutations, and report only if the program treats schema exposure as in scope.",
+			}, introspection)
+		} else {
+			var suggestion *Evidence
+			schema, suggestion = a.recoverGraphQLSchema(ctx, client, lastByHost, candidate.endpoint, &rec)
+			if rec.Suggestions {
+				metrics["suggestions"]++
+				addFinding(graphqlFinding{
+					Family:       "graphql_field_suggestions",
+					Severity:     "low",
+					Reasons:      []string{"introspection_disabled", "field_suggestions_enabled"},
+					ManualAction: "Introspection is off but \"Did you mean\" suggestions leak field names: extend recovery with a larger wordlist (clairvoyance) and test the recovered fields.",
+				}, suggestion)
+			}
+		}
+		if schema != nil {
+			rec.QueryType = schema.queryType
+			rec.MutationType = schema.mutationType
+			rec.QueryFields = schema.fieldNames(schema.queryType)
+			rec.MutationFields = schema.fieldNames(schema.mutationType)
+		}
+
+		a.checkGraphQLBatching(ctx, client, lastByHost, &rec, addFinding)
+		a.checkGraphQLDepth(ctx, client, lastByHost, schema, &rec, addFinding)
+		a.checkGraphQLCSRF(ctx, client, lastByHost, schema, &rec, addFinding)
+		a.checkGraphQLFieldAuthz(ctx, client, lastByHost, schema, &rec, addFinding)
+
+		_ = writeJSONLine(endpointsWriter, rec)
+		for _, f := range findings {
+			_ = writeJSONLine(findingsWriter, f)
+		}
+		metrics["findings"] += len(findings)
+	}
+
+	a.logger.Printf("%s: candidates=%d endpoints=%d introspection=%d suggestions=%d findings=%d", StepGraphQL, metrics["candidates"], metrics["endpoints"], metrics["introspection"], metrics["suggestions"], metrics["findings"])
+	return nil
+}
+
+// collectGraphQLCandidates ranks corpus and JS hints ahead of blind path guesses on live hosts.
+func (a *App) collectGraphQLCandidates() []graphqlTarget {
+	baseDir := filepath.Dir(a.cfg.Lists.Domains)
+	reconDir := filepath.Join(baseDir, "recon")
+	scoped := a.hostScopeFilter()
+	roles := a.loadHostRoles()
+	siblings := a.loadClusterSiblingSkips()
+	eligible := func(raw string) (string, bool) {
+		parsed, err := url.Parse(raw)
+		if err != nil || parsed.Host == "" {
+			return "", false
+		}
+		host := strings.ToLower(parsed.Hostname())
+		if !scoped(host) || hostRoleSkipped(roles, host) {
+			return "", false
+		}
+		if _, ok := siblings[host]; ok {
+			return "", false
+		}
+		parsed.RawQuery = ""
+		parsed.Fragment = ""
+		return strings.TrimRight(parsed.String(), "/"), true
+	}
+
+	seen := make(map[string]struct{})
+	var out []graphqlTarget
+	add := func(raw, source string) {
+		endpoint, ok := eligible(raw)
+		if !ok {
+			return
+		}
+		if _, ok := seen[endpoint]; ok {
+			return
+		}
+		seen[endpoint] = struct{}{}
+		out = append(out, graphqlTarget{endpoint: endpoint, source: source})
+	}
+	isGraphQLPath := func(raw string) bool {
+		parsed, err := url.Parse(raw)
+		if err != nil {
+			return false
+		}
+		path := strings.ToLower(parsed.Path)
+		return strings.Contains(path, "graphql") || strings.HasSuffix(path, "/gql")
+	}
+
+	// Imported traffic shows GraphQL calls even behind paths that do not say so.
+	for _, req := range readRequestCorpus(a.requestCorpusPath()) {
+		operation := false
+		for _, param := range req.Params {
+			if param.In == "body" && (param.Name == "operationName" || param.Name == "variables") {
+				operation = true
+			}
+		}
+		if operation || isGraphQLPath(req.URL) || strings.Contains(strings.ToLower(req.ContentType), "graphql") {
+			add(req.URL, "import")
+		}
+	}
+	for _, line := range readSafeLines(filepath.Join(reconDir, "all_urls.txt")) {
+		if u := normalizeFFUFHitURL(line); u != "" && isGraphQLPath(u) {
+			add(u, "corpus")
+		}
+	}
+	for _, line := range readSafeLines(filepath.Join(reconDir, "js_intel.jsonl")) {
+		var rec jsIntelRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			continue
+		}
+		hinted := false
+		for _, endpoint := range rec.Endpoints {
+			if isGraphQLPath(endpoint) {
+				add(endpoint, "js")
+				hinted = true
+			}
+		}
+		if !hinted && len(rec.GraphQL) > 0 {
+			if parsed, err := url.Parse(rec.URL); err == nil {
+				add(parsed.Scheme+"://"+parsed.Host+"/graphql", "js")
+			}
+		}
+	}
+
+	var hosts []string
+	for _, row := range readLiveWebserverRecords(filepath.Join(baseDir, "live-webservers.jsonl")) {
+		if u := normalizeLiveTarget(row.URL); u != "" {
+			hosts = append(hosts, u)
+		}
+	}
+	hosts = unique(hosts)
+	if len(hosts) > graphqlMaxHosts {
+		a.logger.Printf("%s: limiting path probes from %d to %d hosts", StepGraphQL, len(hosts), graphqlMaxHosts)
+		hosts = hosts[:graphqlMaxHosts]
+	}
+	for _, base := range hosts {
+		parsed, err := url.Parse(base)
+		if err != nil {
+			continue
+		}
+		// graphql.example.com and gql.example.com usually serve the API at the root.
+		label := strings.SplitN(strings.ToLower(parsed.Hostname()), ".", 2)[0]
+		if strings.Contains(label, "graphql") || label == "gql" {
+			add(parsed.Scheme+"://"+parsed.Host+"/", "host-name")
+		}
+		for _, path := range graphqlCommonPaths {
+			add(parsed.Scheme+"://"+parsed.Host+path, "path-probe")
+		}
+	}
+	return out
+}
+
+func (a *App) graphqlRequest(ctx context.Context, client *http.Client, lastByHost map[string]time.Time, method, target, contentType string, body []byte) (int, []byte, http.Header, *Evidence, error) {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return 0, nil, nil, nil, err
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if last, ok := lastByHost[host]; ok {
+		if wait := a.hostRequestDelay(host) - time.Since(last); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return 0, nil, nil, nil, ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return 0, nil, nil, nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	session := a.applySession(req)
+	resp, err := client.Do(req)
+	lastByHost[host] = time.Now()
+	if err != nil {
+		return 0, nil, nil, nil, err
+	}
+	defer resp.Body.Close()
+	data, truncated := readLimitedBody(resp.Body, graphqlBodyLimit)
+	a.observeSession(ctx, session, target, resp.StatusCode, resp.Header.Get("Location"), string(data))
+	return resp.StatusCode, data, resp.Header, captureEvidence(req, body, resp, data, truncated), nil
+}
+
+func (a *App) graphqlPost(ctx context.Context, client *http.Client, lastByHost map[string]time.Time, endpoint, query string) (int, graphqlResponse, []byte, *Evidence, bool) {
+	payload, _ := json.Marshal(map[string]string{"query": query})
+	status, body, _, exchange, err := a.graphqlRequest(ctx, client, lastByHost, http.MethodPost, endpoint, "application/json", payload)
+	if err != nil {
+		return status, graphqlResponse{}, nil, nil, false
+	}
+	resp, ok := parseGraphQLResponse(body)
+	return status, resp, body, exchange, ok
+}
+
+func (a *App) graphqlGet(ctx context.Context, client *http.Client, lastByHost map[string]time.Time, endpoint, query string) (int, graphqlResponse, *Evidence, bool) {
+	target := mutateURLQuery(endpoint, "query", query)
+	if target == "" {
+		return 0, graphqlResponse{}, nil, false
+	}
+	status, body, _, exchange, err := a.graphqlRequest(ctx, client, lastByHost, http.MethodGet, target, "", nil)
+	if err != nil {
+		return status, graphqlResponse{}, nil, false
+	}
+	resp, ok := parseGraphQLResponse(body)
+	return status, resp, exchange, ok
+}
+
+// parseGraphQLResponse accepts only bodies shaped like a GraphQL result, so soft-404 JSON
+// and HTML pages never count as an endpoint.
+func parseGraphQLResponse(body []byte) (graphqlResponse, bool) {
+	var resp graphqlResponse
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return resp, false
+	}
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &probe); err != nil {
+		return resp, false
+	}
+	_, hasData := probe["data"]
+	_, hasErrors := probe["errors"]
+	if !hasData && !hasErrors {
+		return resp, false
+	}
+	if err := json.Unmarshal(trimmed, &resp); err != nil && !hasErrors {
+		return resp, false
+	}
+	return resp, true
+}
+
+func (r graphqlResponse) errorText() string {
+	var parts []string
+	for _, e := range r.Errors {
+		parts = append(parts, e.Message)
+	}
+	return strings.Join(parts, "\n")
+}
+
+func (r graphqlResponse) hasData(key string) bool {
+	raw, ok := r.Data[key]
+	return ok && len(raw) > 0 && string(raw) != "null"
+}
+
+func (a *App) detectGraphQL(ctx context.Context, client *http.Client, lastByHost map[string]time.Time, endpoint string) (int, graphqlResponse, bool) {
+	status, resp, _, _, ok := a.graphqlPost(ctx, client, lastByHost, endpoint, "query{__typename}")
+	if ok && status < 500 && (resp.hasData("__typename") || len(resp.Errors) > 0) {
+		return status, resp, true
+	}
+	status, resp, _, ok = a.graphqlGet(ctx, client, lastByHost, endpoint, "query{__typename}")
+	if ok && status < 500 && (resp.hasData("__typename") || len(resp.Errors) > 0) {
+		return status, resp, true
+	}
+	return status, resp, false
+}
+
+func (a *App) fingerprintGraphQL(ctx context.Context, client *http.Client, lastByHost map[string]time.Time, endpoint string) (string, string) {
+	probes := [][]byte{
+		[]byte(`{"query":"queryy {__typename}"}`),
+		[]byte(`{"query":"query @skip {__typename}"}`),
+		[]byte(`{"query":1}`),
+		[]byte(`{"query":"{}"}`),
+	}
+	for _, probe := range probes {
+		_, body, headers, _, err := a.graphqlRequest(ctx, client, lastByHost, http.MethodPost, endpoint, "application/json", probe)
+		if err != nil {
+			continue
+		}
+		var headerText strings.Builder
+		for name, values := range headers {
+			headerText.WriteString(strings.ToLower(name) + ": " + strings.ToLower(strings.Join(values, ",")) + "\n")
+		}
+		text := headerText.String() + strings.ToLower(string(body))
+		for _, sig := range graphqlEngineSignatures {
+			if strings.Contains(text, sig.marker) {
+				return sig.engine, sig.marker
+			}
+		}
+	}
+	return "unknown", ""
+}
+
+// introspectGraphQL tries the standard query over POST, then variants that slip past naive
+// "__schema{" filters and transport restrictions that only guard JSON POST.
+func (a *App) introspectGraphQL(ctx context.Context, client *http.Client, lastByHost map[string]time.Time, endpoint string) (*graphqlSchema, []byte, string, *Evidence) {
+	attempts := []struct {
+		via   string
+		query string
+		send  func(query string) ([]byte, *Evidence)
+	}{
+		{"post-json", graphqlIntrospectionQuery, nil},
+		{"post-json-newline", strings.Replace(graphqlIntrospectionQuery, "__schema{", "__schema\n{", 1), nil},
+		{"get", graphqlIntrospectionQuery, func(query string) ([]byte, *Evidence) {
+			target := mutateURLQuery(endpoint, "query", query)
+			_, body, _, exchange, err := a.graphqlRequest(ctx, client, lastByHost, http.MethodGet, target, "", nil)
+			if err != nil {
+				return nil, nil
+			}
+			return body, exchange
+		}},
+		{"post-form", graphqlIntrospectionQuery, func(query string) ([]byte, *Evidence) {
+			form := url.Values{"query": {query}}.Encode()
+			_, body, _, exchange, err := a.graphqlRequest(ctx, client, lastByHost, http.MethodPost, endpoint, "application/x-www-form-urlencoded", []byte(form))
+			if err != nil {
+				return nil, nil
+			}
+			return body, exchange
+		}},
+	}
+	for _, attempt := range attempts {
+		if ctx.Err() != nil {
+			return nil, nil, "", nil
+		}
+		var body []byte
+		var exchange *Evidence
+		if attempt.send == nil {
+			_, _, body, exchange, _ = a.graphqlPost(ctx, client, lastByHost, endpoint, attempt.query)
+		} else {
+			body, exchange = attempt.send(attempt.query)
+		}
+		if schema := parseGraphQLIntrospection(body); schema != nil {
+			return schema, body, attempt.via, exchange
+		}
+	}
+	return nil, nil, "", nil
+}
+
+func parseGraphQLIntrospection(body []byte) *graphqlSchema {
+	if len(body) == 0 || !bytes.Contains(body, []byte("__schema")) {
+		return nil
+	}
+	var doc graphqlIntrospection
+	if err := json.Unmarshal(body, &doc); err != nil || doc.Data.Schema == nil || len(doc.Data.Schema.Types) == 0 {
+		return nil
+	}
+	schema := &graphqlSchema{queryType: "Query", types: make(map[string]map[string]graphqlField)}
+	if doc.Data.Schema.QueryType != nil {
+		schema.queryType = doc.Data.Schema.QueryType.Name
+	}
+	if doc.Data.Schema.MutationType != nil {
+		schema.mutationType = doc.Data.Schema.MutationType.Name
+	}
+	for _, t := range doc.Data.Schema.Types {
+		if t.Name == "" || strings.HasPrefix(t.Name, "__") || len(t.Fields) == 0 {
+			continue
+		}
+		fields := make(map[string]graphqlField, len(t.Fields))
+		for _, f := range t.Fields {
+			typeName, kind := f.Type.base()
+			field := graphqlField{typeName: typeName, kind: kind}
+			for _, arg := range f.Args {
+				if arg.Type.Kind == "NON_NULL" {
+					field.requiredArgs = true
+				}
+			}
+			fields[f.Name] = field
+		}
+		schema.types[t.Name] = fields
+	}
+	return schema
+}
+
+func (t graphqlTypeRef) base() (string, string) {
+	current := &t
+	for current != nil {
+		if current.Name != nil && *current.Name != "" {
+			return *current.Name, current.Kind
+		}
+		current = current.OfType
+	}
+	return "", ""
+}
+
+// recoverGraphQLSchema rebuilds root fields from "Did you mean" suggestions and from the
+// errors valid fields produce (missing selections or arguments). It also returns the
+// first exchange that leaked a suggestion.
+func (a *App) recoverGraphQLSchema(ctx context.Context, client *http.Client, lastByHost map[string]time.Time, endpoint string, rec *graphqlEndpointRecord) (*graphqlSchema, *Evidence) {
+	schema := &graphqlSchema{types: make(map[string]map[string]graphqlField)}
+	var suggestion *Evidence
+	recoverRoot := func(operation string, words []string) string {
+		rootType := ""
+		fields := make(map[string]graphqlField)
+		for start := 0; start < len(words); start += graphqlSuggestionChunk {
+			if ctx.Err() != nil {
+				break
+			}
+			end := start + graphqlSuggestionChunk
+			if end > len(words) {
+				end = len(words)
+			}
+			chunk := words[start:end]
+			_, resp, _, exchange, ok := a.graphqlPost(ctx, client, lastByHost, endpoint, operation+"{"+strings.Join(chunk, " ")+"}")
+			if !ok {
+				continue
+			}
+			rejected := make(map[string]struct{})
+			for _, e := range resp.Errors {
+				if match := graphqlSuggestionRe.FindStringSubmatch(e.Message); len(match) > 0 {
+					rejected[match[1]] = struct{}{}
+					rootType = match[2]
+					if match[3] != "" {
+						rec.Suggestions = true
+						if suggestion == nil {
+							suggestion = exchange
+						}
+						for _, s := range graphqlQuotedRe.FindAllStringSubmatch(match[3], -1) {
+							if _, ok := fields[s[1]]; !ok {
+								fields[s[1]] = graphqlField{}
+							}
+						}
+					}
+					continue
+				}
+				if match := graphqlSubfieldsRe.FindStringSubmatch(e.Message); len(match) > 0 {
+					fields[match[1]] = graphqlField{typeName: strings.Trim(match[2], "[]!"), kind: "OBJECT"}
+					continue
+				}
+				if match := graphqlRequiredArgRe.FindStringSubmatch(e.Message); len(match) > 0 {
+					field := fields[match[1]]
+					field.requiredArgs = true
+					fields[match[1]] = field
+				}
+			}
+			// Only trust silent acceptance when the server is clearly validating this chunk.
+			if len(rejected) == 0 {
+				continue
+			}
+			for _, word := range chunk {
+				if _, bad := rejected[word]; bad {
+					continue
+				}
+				if _, ok := fields[word]; !ok && resp.hasData(word) {
+					fields[word] = graphqlField{kind: "SCALAR"}
+				}
+			}
+		}
+		if len(fields) == 0 {
+			return ""
+		}
+		if rootType == "" {
+			rootType = map[string]string{"query": "Query", "mutation": "Mutation"}[operation]
+		}
+		schema.types[rootType] = fields
+		return rootType
+	}
+	schema.queryType = recoverRoot("query", graphqlFieldWordlist)
+	schema.mutationType = recoverRoot("mutation", graphqlMutationWordlist)
+	if schema.queryType == "" && schema.mutationType == "" {
+		return nil, suggestion
+	}
+	return schema, suggestion
+}
+
+func (s *graphqlSchema) fieldNames(typeName string) []string {
+	if s == nil || typeName == "" {
+		return nil
+	}
+	var out []string
+	for name := range s.types[typeName] {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func graphqlHasBruteForceTarget(fields []string) bool {
+	for _, field := range fields {
+		lower := strings.ToLower(field)
+		for _, term := range graphqlBruteForceTerms {
+			if strings.Contains(lower, term) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (a *App) checkGraphQLBatching(ctx context.Context, client *http.Client, lastByHost map[string]time.Time, rec *graphqlEndpointRecord, addFinding func(graphqlFinding, ...*Evidence)) {
+	severity := "low"
+	if graphqlHasBruteForceTarget(rec.MutationFields) {
+		severity = "medium"
+	}
+
+	batch := make([]map[string]string, graphqlBatchSize)
+	for i := range batch {
+		batch[i] = map[string]string{"query": "query{__typename}"}
+	}
+	payload, _ := json.Marshal(batch)
+	status, body, _, exchange, err := a.graphqlRequest(ctx, client, lastByHost, http.MethodPost, rec.Endpoint, "application/json", payload)
+	if err == nil {
+		var results []graphqlResponse
+		if json.Unmarshal(body, &results) == nil && len(results) == graphqlBatchSize {
+			rec.Batching = true
+			addFinding(graphqlFinding{
+				Family:       "graphql_batching",
+				Severity:     severity,
+				StatusCode:   status,
+				Reasons:      []string{fmt.Sprintf("batched_array_accepted:%d", graphqlBatchSize)},
+				ManualAction: "Array batching is accepted: one HTTP request carries many operations, so per-request rate limits can be bypassed. Try batching login/OTP/reset mutations to confirm brute-force impact.",
+			}, exchange)
+		}
+	}
+
+	var query strings.Builder
+	query.WriteString("query{")
+	for i := 0; i < graphqlAliasCount; i++ {
+		fmt.Fprintf(&query, "a%d:__typename ", i)
+	}
+	query.WriteString("}")
+	status, resp, _, exchange, ok := a.graphqlPost(ctx, client, lastByHost, rec.Endpoint, query.String())
+	if ok && resp.hasData(fmt.Sprintf("a%d", graphqlAliasCount-1)) {
+		rec.Aliasing = true
+		addFinding(graphqlFinding{
+			Family:       "graphql_alias_overloading",
+			Severity:     severity,
+			StatusCode:   status,
+			Reasons:      []string{fmt.Sprintf("aliases_accepted:%d", graphqlAliasCount)},
+			ManualAction: "Hundreds of aliases run in a single operation with no cost limit: alias a sensitive mutation (login, OTP check, coupon redeem) to test for brute-force or resource exhaustion.",
+		}, exchange)
+	}
+}
+
+func (a *App) checkGraphQLDepth(ctx context.Context, client *http.Client, lastByHost map[string]time.Time, schema *graphqlSchema, rec *graphqlEndpointRecord, addFinding func(graphqlFinding, ...*Evidence)) {
+	query := ""
+	if schema != nil && schema.queryType != "" {
+		if selection := schema.nestedSelection(schema.queryType, graphqlDepthProbe, make(map[string]string)); selection != "" {
+			query = "query" + selection
+		}
+	}
+	if query == "" && rec.Introspection {
+		// __Type.fields.type nests without limit even when the application types are flat.
+		var b strings.Builder
+		b.WriteString("query{__schema{types{")
+		levels := (graphqlDepthProbe - 2) / 2
+		for i := 0; i < levels; i++ {
+			b.WriteString("fields{type{")
+		}
+		b.WriteString("name")
+		b.WriteString(strings.Repeat("}}", levels))
+		b.WriteString("}}}")
+		query = b.String()
+	}
+	if query == "" {
+		return
+	}
+	status, resp, _, exchange, ok := a.graphqlPost(ctx, client, lastByHost, rec.Endpoint, query)
+	if !ok || graphqlDepthErrorRe.MatchString(resp.errorText()) || len(resp.Data) == 0 {
+		return
+	}
+	rec.MaxDepthAccepted = graphqlDepthProbe
+	addFinding(graphqlFinding{
+		Family:       "graphql_no_depth_limit",
+		Severity:     "low",
+		Query:        query,
+		StatusCode:   status,
+		Reasons:      []string{fmt.Sprintf("depth_%d_accepted", graphqlDepthProbe)},
+		ManualAction: "A deeply nested query was executed without a depth or complexity error: measure response time as depth grows (carefully, off-peak) to judge denial-of-service impact before reporting.",
+	}, exchange)
+}
+
+// nestedSelection builds a selection set at least depth levels deep by following object
+// fields without required arguments, typically through a cyclic relation such as
+// user -> posts -> author. memo caches per type and depth so wide schemas stay cheap.
+func (s *graphqlSchema) nestedSelection(typeName string, depth int, memo map[string]string) string {
+	if depth <= 1 {
+		return "{__typename}"
+	}
+	key := fmt.Sprintf("%s/%d", typeName, depth)
+	if cached, ok := memo[key]; ok {
+		return cached
+	}
+	memo[key] = ""
+	names := s.fieldNames(typeName)
+	for _, name := range names {
+		field := s.types[typeName][name]
+		if field.requiredArgs || (field.kind != "OBJECT" && field.kind != "INTERFACE") {
+			continue
+		}
+		if _, ok := s.types[field.typeName]; !ok {
+			continue
+		}
+		if inner := s.nestedSelection(field.typeName, depth-1, memo); inner != "" {
+			memo[key] = "{" + name + inner + "}"
+			return memo[key]
+		}
+	}
+	return ""
+}
+
+func (a *App) checkGraphQLCSRF(ctx context.Context, client *http.Client, lastByHost map[string]time.Time, schema *graphqlSchema, rec *graphqlEndpointRecord, addFinding func(graphqlFinding, ...*Evidence)) {
+	if _, resp, _, ok := a.graphqlGet(ctx, client, lastByHost, rec.Endpoint, "query{__typename}"); ok && resp.hasData("__typename") {
+		rec.GETQueries = true
+	}
+	hasMutations := schema != nil && schema.mutationType != ""
+	if rec.GETQueries && hasMutations {
+		status, resp, exchange, ok := a.graphqlGet(ctx, client, lastByHost, rec.Endpoint, "mutation{__typename}")
+		if ok && resp.hasData("__typename") {
+			addFinding(graphqlFinding{
+				Family:       "graphql_get_mutation",
+				Severity:     "medium",
+				Query:        "GET ?query=mutation{__typename}",
+				StatusCode:   status,
+				Reasons:      []string{"mutation_over_get"},
+				ManualAction: "Mutations execute over GET, so a link or <img> can trigger them cross-site. If the API authenticates with cookies, build a PoC with a real state-changing mutation.",
+			}, exchange)
+		}
+	}
+
+	form := url.Values{"query": {"query{__typename}"}}.Encode()
+	status, body, _, exchange, err := a.graphqlRequest(ctx, client, lastByHost, http.MethodPost, rec.Endpoint, "application/x-www-form-urlencoded", []byte(form))
+	if err != nil {
+		return
+	}
+	if resp, ok := parseGraphQLResponse(body); ok && resp.hasData("__typename") {
+		severity := "low"
+		if hasMutations {
+			severity = "medium"
+		}
+		addFinding(graphqlFinding{
+			Family:       "graphql_form_post",
+			Severity:     severity,
+			Query:        "POST application/x-www-form-urlencoded query=...",
+			StatusCode:   status,
+			Reasons:      []string{"urlencoded_post_accepted"},
+			ManualAction: "Form-encoded POSTs are accepted, which browsers send cross-site without a CORS preflight. With cookie auth, an auto-submitting form can run mutations as the victim.",
+		}, exchange)
+	}
+}
+
+// checkGraphQLFieldAuthz queries sensitive-looking root fields without credentials. Session
+// profiles are withheld, so any non-null data is data an anonymous user can read.
+func (a *App) checkGraphQLFieldAuthz(ctx context.Context, client *http.Client, lastByHost map[string]time.Time, schema *graphqlSchema, rec *graphqlEndpointRecord, addFinding func(graphqlFinding, ...*Evidence)) {
+	if schema == nil || schema.queryType == "" {
+		return
+	}
+	ctx = withoutSession(ctx)
+	checked := 0
+	for _, name := range schema.fieldNames(schema.queryType) {
+		if checked >= graphqlMaxAuthzFields || ctx.Err() != nil {
+			return
+		}
+		field := schema.types[schema.queryType][name]
+		if field.requiredArgs || !graphqlSensitiveField(name) {
+			continue
+		}
+		checked++
+		var selections []string
+		switch {
+		case field.kind == "SCALAR" || field.kind == "ENUM":
+			selections = []string{""}
+		case field.typeName != "" && len(schema.types[field.typeName]) > 0:
+			selections = []string{"{" + strings.Join(schema.scalarFields(field.typeName, graphqlMaxAuthzSubfields), " ") + "}"}
+		default:
+			// Suggestion-recovered fields carry no type: try as an object, then as a scalar.
+			selections = []string{"{__typename}", ""}
+		}
+		for _, selection := range selections {
+			query := "query{" + name + selection + "}"
+			status, resp, _, exchange, ok := a.graphqlPost(ctx, client, lastByHost, rec.Endpoint, query)
+			if !ok || !resp.hasData(name) {
+				continue
+			}
+			if len(resp.Errors) > 0 && graphqlAuthErrorText(resp.errorText()) {
+				break
+			}
+			raw := string(resp.Data[name])
+			if raw == "[]" || raw == "{}" {
+				break
+			}
+			// A bare __typename only proves the resolver ran, not that data leaked.
+			severity, reason := "medium", "sensitive_field_returned_data_anonymously"
+			if selection == "{__typename}" {
+				severity, reason = "low", "sensitive_field_resolved_anonymously"
+			}
+			addFinding(graphqlFinding{
+				Family:       "graphql_field_unauthenticated",
+				Severity:     severity,
+				Field:        name,
+				Query:        query,
+				StatusCode:   status,
+				Reasons:      []string{reason},
+				ManualAction: "A sensitive root field returned data without credentials: confirm the values are not public by design, then enumerate arguments and nested fields for more exposure.",
+			}, exchange)
+			break
+		}
+	}
+}
+
+func (s *graphqlSchema) scalarFields(typeName string, limit int) []string {
+	var out []string
+	for _, name := range s.fieldNames(typeName) {
+		field := s.types[typeName][name]
+		if field.requiredArgs || (field.kind != "SCALAR" && field.kind != "ENUM") {
+			continue
+		}
+		out = append(out, name)
+		if len(out) >= limit {
+			break
+		}
+	}
+	if len(out) == 0 {
+		out = []string{"__typename"}
+	}
+	return out
+}
+
+func graphqlSensitiveField(name string) bool {
+	lower := strings.ToLower(name)
+	for _, term := range graphqlSensitiveFieldTerms {
+		if strings.Contains(lower, term) {
+			return true
+		}
+	}
+	return false
+}
+
+func graphqlAuthErrorText(text string) bool {
+	lower := strings.ToLower(text)
+	for _, marker := range []string{"unauthori", "unauthentic", "forbidden", "not authori", "access denied", "permission", "login required", "must be logged in"} {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
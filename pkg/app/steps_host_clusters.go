@@ -0,0 +1,418 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"hash/fnv"
+	"io"
+	"math/bits"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	hostClustersMaxTargets  = 200
+	hostClustersSimhashDist = 6
+	hostClustersMinTokens   = 20
+	hostClustersBodyLimit   = 262144
+)
+
+type hostClusterRecord struct {
+	Timestamp         string   `json:"timestamp"`
+	Host              string   `json:"host"`
+	URL               string   `json:"url"`
+	Cluster           string   `json:"cluster"`
+	Rank              int      `json:"rank"`
+	Representative    bool     `json:"representative"`
+	Siblings          []string `json:"siblings,omitempty"`
+	StatusCode        int      `json:"status_code,omitempty"`
+	BodyTokens        int      `json:"body_tokens"`
+	BodySimhash       string   `json:"body_simhash,omitempty"`
+	FaviconHash       string   `json:"favicon_hash,omitempty"`
+	HeaderFingerprint string   `json:"header_fingerprint,omitempty"`
+	CrawledURLs       int      `json:"crawled_urls"`
+}
+
+type hostFingerprint struct {
+	host       string
+	target     string
+	status     int
+	tokens     int
+	simhash    uint64
+	favicon    string
+	headers    string
+	crawled    int
+	probeError bool
+}
+
+var (
+	hostClusterTokenRe   = regexp.MustCompile(`[a-z][a-z0-9_\-]{1,30}`)
+	hostClusterDigitsRe  = regexp.MustCompile(`[0-9]+`)
+	hostClusterIconRe    = regexp.MustCompile(`(?i)<link[^>]+rel=["'][^"']*icon[^"']*["'][^>]*>`)
+	hostClusterHrefRe    = regexp.MustCompile(`(?i)href=["']([^"']+)["']`)
+	hostClusterVolatiles = map[string]struct{}{
+		"date": {}, "content-length": {}, "set-cookie": {}, "expires": {}, "last-modified": {}, "etag": {}, "age": {},
+		"cf-ray": {}, "x-request-id": {}, "x-amz-cf-id": {}, "x-amzn-requestid": {}, "x-amzn-trace-id": {}, "report-to": {}, "nel": {},
+	}
+)
+
+func (a *App) runHostClustering(ctx context.Context) error {
+	baseDir := filepath.Dir(a.cfg.Lists.Domains)
+	reconDir := filepath.Join(baseDir, "recon")
+	if err := os.MkdirAll(reconDir, 0o755); err != nil {
+		return err
+	}
+
+	targets := normalizeHTTPSTargets(readSafeLines(a.httpListOrDefault(a.cfg.Lists.Domains)))
+	for _, row := range readLiveWebserverRecords(filepath.Join(baseDir, "live-webservers.jsonl")) {
+		if u := normalizeLiveTarget(row.URL); u != "" {
+			targets = append(targets, u)
+		}
+	}
+	targetByHost := make(map[string]string)
+	for _, target := range unique(targets) {
+		host := extractHostCandidate(target)
+		if host == "" {
+			continue
+		}
+		if current, ok := targetByHost[host]; !ok || (strings.HasPrefix(target, "https://") && !strings.HasPrefix(current, "https://")) {
+			targetByHost[host] = target
+		}
+	}
+	crawled := make(map[string]int)
+	for _, line := range readSafeLines(filepath.Join(reconDir, "all_urls.txt")) {
+		if host := extractHostCandidate(line); host != "" {
+			crawled[host]++
+		}
+	}
+
+	hosts := make([]string, 0, len(targetByHost))
+	for host := range targetByHost {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	if len(hosts) > hostClustersMaxTargets {
+		a.logger.Printf("%s: limiting hosts from %d to %d", StepHostClusters, len(hosts), hostClustersMaxTargets)
+		hosts = hosts[:hostClustersMaxTargets]
+	}
+
+	client := a.newHTTPClient(paramFuzzRequestTimeout, false)
+	prints := make([]hostFingerprint, 0, len(hosts))
+	for _, host := range hosts {
+		fp := a.fingerprintHost(ctx, client, host, targetByHost[host])
+		fp.crawled = crawled[host]
+		prints = append(prints, fp)
+	}
+
+	// Union-find over pairwise matches keeps clusters transitive.
+	parent := make([]int, len(prints))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range prints {
+		for j := i + 1; j < len(prints); j++ {
+			if hostFingerprintsMatch(prints[i], prints[j]) {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+	groups := make(map[int][]int)
+	for i := range prints {
+		root := find(i)
+		groups[root] = append(groups[root], i)
+	}
+
+	var clusters [][]int
+	for _, members := range groups {
+		// Representatives are the hosts with the most crawled URLs, so the tested
+		// sibling is also the one with the widest endpoint coverage.
+		sort.Slice(members, func(x, y int) bool {
+			px, py := prints[members[x]], prints[members[y]]
+			if px.crawled != py.crawled {
+				return px.crawled > py.crawled
+			}
+			if len(px.host) != len(py.host) {
+				return len(px.host) < len(py.host)
+			}
+			return px.host < py.host
+		})
+		clusters = append(clusters, members)
+	}
+	sort.Slice(clusters, func(x, y int) bool {
+		if len(clusters[x]) != len(clusters[y]) {
+			return len(clusters[x]) > len(clusters[y])
+		}
+		return prints[clusters[x][0]].host < prints[clusters[y][0]].host
+	})
+
+	outFile, err := os.Create(filepath.Join(reconDir, "host_clusters.jsonl"))
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+	w := bufio.NewWriter(outFile)
+	defer w.Flush()
+
+	metrics := map[string]int{
+		"hosts":          len(prints),
+		"clusters":       len(clusters),
+		"multi_clusters": 0,
+		"siblings":       0,
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for idx, members := range clusters {
+		id := "hc" + strconv.Itoa(idx+1)
+		names := make([]string, 0, len(members))
+		for _, m := range members {
+			names = append(names, prints[m].host)
+		}
+		if len(members) > 1 {
+			metrics["multi_clusters"]++
+			metrics["siblings"] += len(members) - 1
+		}
+		for rank, m := range members {
+			fp := prints[m]
+			rec := hostClusterRecord{
+				Timestamp:         now,
+				Host:              fp.host,
+				URL:               fp.target,
+				Cluster:           id,
+				Rank:              rank,
+				Representative:    rank == 0,
+				StatusCode:        fp.status,
+				BodyTokens:        fp.tokens,
+				FaviconHash:       fp.favicon,
+				HeaderFingerprint: fp.headers,
+				CrawledURLs:       fp.crawled,
+			}
+			if fp.tokens > 0 {
+				rec.BodySimhash = strconv.FormatUint(fp.simhash, 16)
+			}
+			for _, name := range names {
+				if name != fp.host {
+					rec.Siblings = append(rec.Siblings, name)
+				}
+			}
+			_ = writeJSONLine(w, rec)
+		}
+	}
+
+	a.logger.Printf("%s: hosts=%d clusters=%d lookalike_clusters=%d siblings=%d max_per_cluster=%d", StepHostClusters,
+		metrics["hosts"], metrics["clusters"], metrics["multi_clusters"], metrics["siblings"], a.cfg.HostClusters.MaxPerCluster)
+	return nil
+}
+
+func (a *App) fingerprintHost(ctx context.Context, client *http.Client, host, target string) hostFingerprint {
+	fp := hostFingerprint{host: host, target: target}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		fp.probeError = true
+		return fp
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		fp.probeError = true
+		return fp
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, hostClustersBodyLimit))
+	_ = resp.Body.Close()
+
+	fp.status = resp.StatusCode
+	fp.headers = headerFingerprint(resp)
+	fp.simhash, fp.tokens = bodySimhash(string(body), host)
+
+	iconURL := strings.TrimRight(target, "/") + "/favicon.ico"
+	if tag := hostClusterIconRe.FindString(string(body)); tag != "" {
+		if href := hostClusterHrefRe.FindStringSubmatch(tag); len(href) > 1 {
+			if base, err := url.Parse(target); err == nil {
+				if ref, err := base.Parse(strings.TrimSpace(href[1])); err == nil && (ref.Scheme == "http" || ref.Scheme == "https") {
+					iconURL = ref.String()
+				}
+			}
+		}
+	}
+	if iconReq, err := http.NewRequestWithContext(ctx, http.MethodGet, iconURL, nil); err == nil {
+		if iconResp, err := client.Do(iconReq); err == nil {
+			icon, _ := io.ReadAll(io.LimitReader(iconResp.Body, 1<<20))
+			_ = iconResp.Body.Close()
+			ctype := strings.ToLower(iconResp.Header.Get("Content-Type"))
+			if iconResp.StatusCode == http.StatusOK && len(icon) > 0 && !strings.Contains(ctype, "text/html") {
+				fp.favicon = strconv.FormatInt(int64(faviconMMH3(icon)), 10)
+			}
+		}
+	}
+	return fp
+}
+
+// hostFingerprintsMatch treats two hosts as the same application when their bodies
+// are near-identical and at least one of favicon or header fingerprint agrees.
+func hostFingerprintsMatch(x, y hostFingerprint) bool {
+	if x.probeError || y.probeError {
+		return false
+	}
+	if x.tokens < hostClustersMinTokens || y.tokens < hostClustersMinTokens {
+		return false
+	}
+	if x.status != y.status {
+		return false
+	}
+	if bits.OnesCount64(x.simhash^y.simhash) > hostClustersSimhashDist {
+		return false
+	}
+	if x.favicon != "" && x.favicon == y.favicon {
+		return true
+	}
+	return x.headers != "" && x.headers == y.headers
+}
+
+// bodySimhash is a 64-bit simhash over word trigrams. The host's own name and all
+// digits are normalised away so per-host links and nonces do not split clusters.
+func bodySimhash(body, host string) (uint64, int) {
+	text := strings.ToLower(body)
+	if host != "" {
+		text = strings.ReplaceAll(text, strings.ToLower(host), " ")
+	}
+	text = hostClusterDigitsRe.ReplaceAllString(text, "0")
+	tokens := hostClusterTokenRe.FindAllString(text, -1)
+	if len(tokens) == 0 {
+		return 0, 0
+	}
+	var weights [64]int
+	add := func(shingle string) {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(shingle))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<uint(bit)) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+	if len(tokens) < 3 {
+		add(strings.Join(tokens, " "))
+	}
+	for i := 0; i+2 < len(tokens); i++ {
+		add(tokens[i] + " " + tokens[i+1] + " " + tokens[i+2])
+	}
+	var out uint64
+	for bit := 0; bit < 64; bit++ {
+		if weights[bit] > 0 {
+			out |= 1 << uint(bit)
+		}
+	}
+	return out, len(tokens)
+}
+
+func headerFingerprint(resp *http.Response) string {
+	var parts []string
+	for name := range resp.Header {
+		lower := strings.ToLower(name)
+		if _, volatile := hostClusterVolatiles[lower]; volatile {
+			continue
+		}
+		parts = append(parts, lower)
+	}
+	for _, cookie := range resp.Cookies() {
+		parts = append(parts, "cookie:"+strings.ToLower(cookie.Name))
+	}
+	parts = append(parts, "server:"+strings.ToLower(strings.TrimSpace(resp.Header.Get("Server"))))
+	sort.Strings(parts)
+	sum := sha1.Sum([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// faviconMMH3 reproduces the Shodan-style favicon hash (murmur3 over the
+// MIME-wrapped base64 encoding) so values can be pivoted on directly.
+func faviconMMH3(data []byte) int32 {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var b strings.Builder
+	for i := 0; i < len(encoded); i += 76 {
+		end := i + 76
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		b.WriteString(encoded[i:end])
+		b.WriteByte('\n')
+	}
+	return int32(murmur3x86_32([]byte(b.String()), 0))
+}
+
+func murmur3x86_32(data []byte, seed uint32) uint32 {
+	const c1, c2 = 0xcc9e2d51, 0x1b873593
+	h := seed
+	n := len(data) / 4
+	for i := 0; i < n; i++ {
+		k := binary.LittleEndian.Uint32(data[i*4:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+	var k uint32
+	tail := data[n*4:]
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
+
+// loadClusterSiblingSkips returns hosts ranked past the configured per-cluster
+// budget; they are covered by a representative and left out of the check modules.
+func (a *App) loadClusterSiblingSkips() map[string]struct{} {
+	out := make(map[string]struct{})
+	limit := a.cfg.HostClusters.MaxPerCluster
+	if limit <= 0 {
+		return out
+	}
+	path := filepath.Join(filepath.Dir(a.cfg.Lists.Domains), "recon", "host_clusters.jsonl")
+	for _, line := range readSafeLines(path) {
+		var rec hostClusterRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			continue
+		}
+		if rec.Rank >= limit {
+			out[strings.ToLower(strings.TrimSpace(rec.Host))] = struct{}{}
+		}
+	}
+	return out
+}
@@ -0,0 +1,476 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	hostRoleAuth    = "auth"
+	hostRoleAdmin   = "admin"
+	hostRoleUploads = "uploads"
+	hostRoleStatic  = "static"
+	hostRoleCDN     = "cdn"
+	hostRoleAPI     = "api"
+	hostRoleApp     = "app"
+
+	hostRolesMaxTargets      = 200
+	hostRolesStaticRatio     = 0.8
+	hostRolesMinStaticSample = 5
+)
+
+type hostRoleRecord struct {
+	Timestamp   string   `json:"timestamp"`
+	Host        string   `json:"host"`
+	URL         string   `json:"url"`
+	Roles       []string `json:"roles"`
+	Signals     []string `json:"signals"`
+	StatusCode  int      `json:"status_code,omitempty"`
+	Location    string   `json:"location,omitempty"`
+	ContentType string   `json:"content_type,omitempty"`
+	CrawledURLs int      `json:"crawled_urls"`
+	LiveURLs    int      `json:"live_urls"`
+	StaticURLs  int      `json:"static_urls"`
+}
+
+var (
+	hostRoleLabelHints = map[string][]string{
+		hostRoleAuth:    {"auth", "login", "signin", "sso", "oauth", "idp", "accounts", "account", "identity", "iam", "adfs", "okta", "passport"},
+		hostRoleAdmin:   {"admin", "administrator", "manage", "manager", "dashboard", "console", "backoffice", "backend", "cms", "panel", "internal", "staff", "ops", "jenkins", "grafana", "kibana"},
+		hostRoleUploads: {"upload", "uploads", "files", "file", "media", "storage", "attachments", "docs-upload", "usercontent", "ugc"},
+		hostRoleStatic:  {"static", "assets", "asset", "images", "image", "css", "fonts", "resources"},
+		hostRoleCDN:     {"cdn", "edge", "cache", "akamai", "cloudfront", "fastly"},
+	}
+	hostRoleTitleHints = map[string][]string{
+		hostRoleAuth:    {"login", "log in", "sign in", "signin", "single sign-on", "sso", "authenticate", "identity"},
+		hostRoleAdmin:   {"admin", "dashboard", "console", "control panel", "management", "backoffice", "jenkins", "grafana", "kibana", "phpmyadmin"},
+		hostRoleUploads: {"upload", "file manager", "file share"},
+	}
+	hostRoleCDNHeaders = map[string]string{
+		"cf-ray":               "cloudflare",
+		"x-amz-cf-id":          "cloudfront",
+		"x-amz-cf-pop":         "cloudfront",
+		"x-served-by":          "fastly",
+		"x-fastly-request-id":  "fastly",
+		"x-akamai-transformed": "akamai",
+		"akamai-grn":           "akamai",
+		"x-azure-ref":          "azure_front_door",
+		"x-cdn":                "generic_cdn",
+		"x-edge-location":      "generic_cdn",
+	}
+	hostRoleCDNServers = []string{"cloudflare", "cloudfront", "akamaighost", "akamai", "fastly", "varnish", "amazons3", "gws", "netlify", "vercel"}
+	// hostRoleStaticTypes are content-type prefixes of asset responses.
+	hostRoleStaticTypes = []string{
+		"text/css", "text/javascript", "application/javascript", "application/x-javascript", "image/", "font/",
+		"application/font", "application/x-font", "video/", "audio/", "application/pdf", "application/zip", "application/wasm",
+	}
+	hostRoleUploadPathHints = []string{"/upload", "/uploads", "/file-upload", "/fileupload", "/attachments", "/media/upload", "/import"}
+	hostRoleLoginPathHints  = []string{"/login", "/signin", "/sign-in", "/sso", "/oauth", "/authorize", "/auth", "/saml", "/cas/login", "/adfs"}
+
+	// hostRoleSkips lists roles whose hosts are excluded from the active check modules
+	// when the host carries no other role (see hostRoleSkipped).
+	hostRoleSkips = []string{hostRoleStatic, hostRoleCDN}
+	// hostRoleTargets maps check steps to the host roles they should test first.
+	hostRoleTargets = map[string][]string{
+		StepCSRFChecks:     {hostRoleAuth, hostRoleAdmin},
+		StepWorkflowLogic:  {hostRoleAuth, hostRoleAdmin},
+		StepFormTamper:     {hostRoleAuth, hostRoleAdmin},
+		StepOpenRedirect:   {hostRoleAuth},
+		StepServerInputChk: {hostRoleUploads},
+		StepAdvInjection:   {hostRoleUploads, hostRoleAPI},
+		StepInjectionCheck: {hostRoleAPI, hostRoleAdmin},
+	}
+)
+
+func (a *App) runHostRoleSegmentation(ctx context.Context) error {
+	baseDir := filepath.Dir(a.cfg.Lists.Domains)
+	reconDir := filepath.Join(baseDir, "recon")
+	if err := os.MkdirAll(reconDir, 0o755); err != nil {
+		return err
+	}
+
+	outFile, err := os.Create(filepath.Join(reconDir, "host_roles.jsonl"))
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+	w := bufio.NewWriter(outFile)
+	defer w.Flush()
+
+	liveByHost := make(map[string]liveWebserverRecord)
+	for _, row := range readLiveWebserverRecords(filepath.Join(baseDir, "live-webservers.jsonl")) {
+		host := extractHostCandidate(row.URL)
+		if host == "" {
+			continue
+		}
+		if _, ok := liveByHost[host]; !ok {
+			liveByHost[host] = row
+		}
+	}
+
+	targets := normalizeHTTPSTargets(readSafeLines(a.httpListOrDefault(a.cfg.Lists.Domains)))
+	for _, row := range liveByHost {
+		if u := normalizeLiveTarget(row.URL); u != "" {
+			targets = append(targets, u)
+		}
+	}
+	targetByHost := make(map[string]string)
+	for _, target := range unique(targets) {
+		host := extractHostCandidate(target)
+		if host == "" {
+			continue
+		}
+		if current, ok := targetByHost[host]; !ok || (strings.HasPrefix(target, "https://") && !strings.HasPrefix(current, "https://")) {
+			targetByHost[host] = target
+		}
+	}
+
+	crawled := make(map[string][]string)
+	for _, line := range readSafeLines(filepath.Join(reconDir, "all_urls.txt")) {
+		host := extractHostCandidate(line)
+		if host == "" {
+			continue
+		}
+		crawled[host] = append(crawled[host], line)
+	}
+	// Archived URLs (wayback, gau) are mostly assets even on app hosts, so the static
+	// ratio comes from what the corpus probe saw served live.
+	liveTypes := make(map[string][]string)
+	for _, rec := range readURLCorpus(a.urlCorpusPath()) {
+		host := strings.ToLower(strings.TrimSpace(rec.Host))
+		if host == "" || rec.LastStatus < 200 || rec.LastStatus >= 300 || rec.ContentType == "" {
+			continue
+		}
+		liveTypes[host] = append(liveTypes[host], strings.ToLower(rec.ContentType))
+	}
+
+	hosts := make([]string, 0, len(targetByHost))
+	for host := range targetByHost {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	client := a.newHTTPClient(paramFuzzRequestTimeout, false)
+
+	metrics := map[string]int{
+		"hosts":         len(hosts),
+		"probed":        0,
+		hostRoleAuth:    0,
+		hostRoleAdmin:   0,
+		hostRoleUploads: 0,
+		hostRoleStatic:  0,
+		hostRoleCDN:     0,
+		hostRoleAPI:     0,
+		hostRoleApp:     0,
+	}
+
+	for idx, host := range hosts {
+		target := targetByHost[host]
+		roles := make(map[string]struct{})
+		var signals []string
+		addRole := func(role, signal string) {
+			roles[role] = struct{}{}
+			signals = append(signals, signal)
+		}
+
+		classifyHostLabels(host, addRole)
+
+		live := liveByHost[host]
+		title := strings.ToLower(strings.TrimSpace(live.Title))
+		for _, role := range []string{hostRoleAuth, hostRoleAdmin, hostRoleUploads} {
+			for _, hint := range hostRoleTitleHints[role] {
+				if title != "" && strings.Contains(title, hint) {
+					addRole(role, "title:"+hint)
+					break
+				}
+			}
+		}
+		cdnVendor := ""
+		server := strings.ToLower(strings.TrimSpace(live.WebServer))
+		for _, marker := range hostRoleCDNServers {
+			if server != "" && strings.Contains(server, marker) {
+				cdnVendor = marker
+				signals = append(signals, "server:"+marker)
+				break
+			}
+		}
+		for _, tech := range live.Technologies {
+			lower := strings.ToLower(tech)
+			for _, marker := range hostRoleCDNServers {
+				if strings.Contains(lower, marker) {
+					cdnVendor = marker
+					signals = append(signals, "tech:"+marker)
+				}
+			}
+			if strings.Contains(lower, "keycloak") || strings.Contains(lower, "okta") || strings.Contains(lower, "auth0") {
+				addRole(hostRoleAuth, "tech:"+lower)
+			}
+		}
+
+		rec := hostRoleRecord{Host: host, URL: target}
+		if idx < hostRolesMaxTargets {
+			if probe, ok := a.probeHostRoleSignals(ctx, client, target); ok {
+				metrics["probed"]++
+				rec.StatusCode = probe.status
+				rec.Location = probe.location
+				rec.ContentType = probe.contentType
+				if probe.authenticate != "" {
+					addRole(hostRoleAuth, "header:www-authenticate")
+				}
+				if probe.loginRedirect {
+					addRole(hostRoleAuth, "redirect:login")
+				}
+				if probe.passwordForm {
+					addRole(hostRoleAuth, "body:password_form")
+				}
+				if probe.cdnVendor != "" {
+					cdnVendor = probe.cdnVendor
+					signals = append(signals, "header:cdn_"+probe.cdnVendor)
+				}
+				if strings.Contains(probe.contentType, "json") {
+					addRole(hostRoleAPI, "content_type:json")
+				}
+			}
+		}
+
+		for _, u := range crawled[host] {
+			rec.CrawledURLs++
+			parsed, err := url.Parse(u)
+			if err != nil {
+				continue
+			}
+			p := strings.ToLower(parsed.Path)
+			for _, hint := range hostRoleUploadPathHints {
+				if strings.Contains(p, hint) {
+					if _, ok := roles[hostRoleUploads]; !ok {
+						addRole(hostRoleUploads, "path:"+strings.TrimPrefix(hint, "/"))
+					}
+					break
+				}
+			}
+			for _, hint := range hostRoleLoginPathHints {
+				if strings.HasPrefix(p, hint) {
+					if _, ok := roles[hostRoleAuth]; !ok {
+						addRole(hostRoleAuth, "path:"+strings.TrimPrefix(hint, "/"))
+					}
+					break
+				}
+			}
+		}
+		for _, contentType := range liveTypes[host] {
+			rec.LiveURLs++
+			if hostRoleStaticType(contentType) {
+				rec.StaticURLs++
+			}
+		}
+		mostlyStatic := rec.LiveURLs >= hostRolesMinStaticSample && float64(rec.StaticURLs)/float64(rec.LiveURLs) >= hostRolesStaticRatio
+		if mostlyStatic {
+			addRole(hostRoleStatic, "live:static_ratio")
+		}
+		// A CDN marker alone only means the app is fronted by an edge; the host
+		// is treated as a CDN asset host when it also serves static content.
+		if cdnVendor != "" && (mostlyStatic || hasAnyRole(roles, hostRoleStatic)) {
+			addRole(hostRoleCDN, "cdn:"+cdnVendor)
+		}
+
+		if len(roles) == 0 {
+			addRole(hostRoleApp, "default")
+		}
+		rec.Timestamp = time.Now().UTC().Format(time.RFC3339)
+		rec.Roles = sortedParamKeys(roles)
+		rec.Signals = unique(signals)
+		for _, role := range rec.Roles {
+			metrics[role]++
+		}
+		_ = writeJSONLine(w, rec)
+	}
+
+	a.logger.Printf("%s: hosts=%d probed=%d auth=%d admin=%d uploads=%d static=%d cdn=%d api=%d app=%d", StepHostRoles,
+		metrics["hosts"], metrics["probed"], metrics[hostRoleAuth], metrics[hostRoleAdmin], metrics[hostRoleUploads],
+		metrics[hostRoleStatic], metrics[hostRoleCDN], metrics[hostRoleAPI], metrics[hostRoleApp])
+	return nil
+}
+
+func classifyHostLabels(host string, addRole func(role, signal string)) {
+	labels := strings.Split(strings.ToLower(strings.TrimSpace(host)), ".")
+	if len(labels) > 2 {
+		labels = labels[:len(labels)-2]
+	}
+	for _, role := range []string{hostRoleAuth, hostRoleAdmin, hostRoleUploads, hostRoleStatic, hostRoleCDN} {
+		matched := false
+		for _, label := range labels {
+			for _, part := range strings.FieldsFunc(label, func(r rune) bool { return r == '-' || r == '_' }) {
+				for _, hint := range hostRoleLabelHints[role] {
+					if part == hint {
+						addRole(role, "label:"+hint)
+						matched = true
+						break
+					}
+				}
+				if matched {
+					break
+				}
+			}
+			if matched {
+				break
+			}
+		}
+	}
+	if isAPIRelatedHost(host) {
+		addRole(hostRoleAPI, "label:api")
+	}
+}
+
+type hostRoleProbe struct {
+	status        int
+	location      string
+	contentType   string
+	authenticate  string
+	cdnVendor     string
+	loginRedirect bool
+	passwordForm  bool
+}
+
+func (a *App) probeHostRoleSignals(ctx context.Context, client *http.Client, target string) (hostRoleProbe, bool) {
+	var probe hostRoleProbe
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return probe, false
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return probe, false
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 8192))
+	_ = resp.Body.Close()
+
+	probe.status = resp.StatusCode
+	probe.location = strings.TrimSpace(resp.Header.Get("Location"))
+	probe.contentType = strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Type")))
+	probe.authenticate = strings.TrimSpace(resp.Header.Get("WWW-Authenticate"))
+	for header, vendor := range hostRoleCDNHeaders {
+		if resp.Header.Get(header) != "" {
+			probe.cdnVendor = vendor
+			break
+		}
+	}
+	if probe.location != "" {
+		loc := strings.ToLower(probe.location)
+		for _, hint := range hostRoleLoginPathHints {
+			if strings.Contains(loc, hint) {
+				probe.loginRedirect = true
+				break
+			}
+		}
+	}
+	lowerBody := strings.ToLower(string(body))
+	probe.passwordForm = strings.Contains(lowerBody, `type="password"`) || strings.Contains(lowerBody, `type='password'`)
+	return probe, true
+}
+
+func readLiveWebserverRecords(path string) []liveWebserverRecord {
+	var out []liveWebserverRecord
+	for _, line := range readSafeLines(path) {
+		var row liveWebserverRecord
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			continue
+		}
+		if row.URL == "" {
+			continue
+		}
+		out = append(out, row)
+	}
+	return out
+}
+
+func hostRoleStaticType(contentType string) bool {
+	for _, prefix := range hostRoleStaticTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyRole(roles map[string]struct{}, want ...string) bool {
+	for _, role := range want {
+		if _, ok := roles[role]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *App) loadHostRoles() map[string][]string {
+	path := filepath.Join(filepath.Dir(a.cfg.Lists.Domains), "recon", "host_roles.jsonl")
+	out := make(map[string][]string)
+	for _, line := range readSafeLines(path) {
+		var rec hostRoleRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			continue
+		}
+		host := strings.ToLower(strings.TrimSpace(rec.Host))
+		if host == "" {
+			continue
+		}
+		out[host] = rec.Roles
+	}
+	return out
+}
+
+func hostRoleMatches(roles map[string][]string, host string, want ...string) bool {
+	for _, role := range roles[strings.ToLower(strings.TrimSpace(host))] {
+		if containsAnyString(want, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostRoleSkipped reports whether host is only a static or CDN asset host. A host that
+// also carries another role (auth, admin, api, ...) stays in the check modules.
+func hostRoleSkipped(roles map[string][]string, host string) bool {
+	hostRoles := roles[strings.ToLower(strings.TrimSpace(host))]
+	if len(hostRoles) == 0 {
+		return false
+	}
+	for _, role := range hostRoles {
+		if !containsAnyString(hostRoleSkips, role) {
+			return false
+		}
+	}
+	return true
+}
+
+// aimEndpointsAtRoles moves endpoints on hosts carrying the step's target roles to the front.
+func (a *App) aimEndpointsAtRoles(step string, endpoints []string) []string {
+	want := hostRoleTargets[step]
+	if len(want) == 0 || len(endpoints) == 0 {
+		return endpoints
+	}
+	roles := a.loadHostRoles()
+	if len(roles) == 0 {
+		return endpoints
+	}
+	var preferred, rest []string
+	for _, endpoint := range endpoints {
+		if hostRoleMatches(roles, extractHostCandidate(endpoint), want...) {
+			preferred = append(preferred, endpoint)
+			continue
+		}
+		rest = append(rest, endpoint)
+	}
+	if len(preferred) > 0 {
+		a.logger.Printf("%s: %d endpoint(s) on %s host(s) prioritized", step, len(preferred), strings.Join(want, "/"))
+	}
+	return append(preferred, rest...)
+}
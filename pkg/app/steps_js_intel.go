@@ -0,0 +1,423 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	jsIntelMaxAssets = 300
+	jsIntelBodyLimit = 3 << 20
+)
+
+type jsIntelRecord struct {
+	Timestamp  string   `json:"timestamp"`
+	URL        string   `json:"url"`
+	Host       string   `json:"host"`
+	StatusCode int      `json:"status_code"`
+	Size       int      `json:"size"`
+	Endpoints  []string `json:"endpoints,omitempty"`
+	GraphQL    []string `json:"graphql_operations,omitempty"`
+	Params     []string `json:"params,omitempty"`
+	Hosts      []string `json:"hosts,omitempty"`
+	Buckets    []string `json:"buckets,omitempty"`
+	Secrets    []string `json:"secrets,omitempty"`
+	SourceMap  string   `json:"source_map,omitempty"`
+}
+
+type jsSecretHit struct {
+	Timestamp    string   `json:"timestamp"`
+	Endpoint     string   `json:"endpoint"`
+	Host         string   `json:"host"`
+	Family       string   `json:"family"`
+	Severity     string   `json:"severity"`
+	Match        string   `json:"match"`
+	Line         int      `json:"line"`
+	Source       string   `json:"source,omitempty"`
+	Reasons      []string `json:"reasons"`
+	ManualAction string   `json:"manual_action"`
+}
+
+type jsSecretPattern struct {
+	kind     string
+	severity string
+	re       *regexp.Regexp
+	group    int
+}
+
+var (
+	jsEndpointRe  = regexp.MustCompile("[\"'`]((?:https?://[a-zA-Z0-9.\\-]+(?::\\d+)?)?/[a-zA-Z0-9_\\-./~%]*(?:\\?[a-zA-Z0-9_\\-.~%=&\\[\\]]*)?|[a-zA-Z0-9_\\-]+/[a-zA-Z0-9_\\-./]*(?:\\?[a-zA-Z0-9_\\-.~%=&\\[\\]]*)?)[\"'`]")
+	jsAbsoluteRe  = regexp.MustCompile(`https?://([a-zA-Z0-9][a-zA-Z0-9.\-]*\.[a-zA-Z]{2,})`)
+	jsGraphQLRe   = regexp.MustCompile(`\b(query|mutation|subscription)\s+([A-Za-z_][A-Za-z0-9_]{1,60})\s*[({]`)
+	jsParamRe     = regexp.MustCompile(`(?:[?&]|searchParams\.(?:get|set|append|has)\(\s*["'])([A-Za-z_][A-Za-z0-9_\-]{0,40})(?:=|["'])`)
+	jsSourceMapRe = regexp.MustCompile(`(?m)^[/][/*][#@]\s*sourceMappingURL=(\S+?)(?:\s*\*/)?\s*$`)
+	jsBucketRe    = regexp.MustCompile(`(?i)(?:[a-z0-9.\-]+\.s3[.\-](?:[a-z0-9\-]+\.)?amazonaws\.com|s3[.\-](?:[a-z0-9\-]+\.)?amazonaws\.com/[a-z0-9.\-]+|storage\.googleapis\.com/[a-z0-9._\-]+|[a-z0-9\-]+\.storage\.googleapis\.com|[a-z0-9\-]+\.blob\.core\.windows\.net|[a-z0-9\-]+\.(?:[a-z0-9\-]+\.)?digitaloceanspaces\.com|[a-z0-9\-]+\.firebaseio\.com)`)
+	jsMIMERe      = regexp.MustCompile(`^(?:application|text|image|audio|video|font|multipart|model)/`)
+	jsRelativeAPI = regexp.MustCompile(`(?i)(?:^|/)(?:api|rest|graphql|v\d+)(?:/|$)|\.(?:php|aspx?|jsp|json|action|do|xml|cgi)(?:\?|$)`)
+
+	jsStaticExts = map[string]struct{}{
+		".png": {}, ".jpg": {}, ".jpeg": {}, ".gif": {}, ".svg": {}, ".webp": {}, ".ico": {}, ".bmp": {},
+		".css": {}, ".woff": {}, ".woff2": {}, ".ttf": {}, ".eot": {}, ".otf": {}, ".mp4": {}, ".mp3": {}, ".webm": {},
+	}
+
+	jsSecretPatterns = []jsSecretPattern{
+		{kind: "aws_access_key", severity: "high", re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+		{kind: "github_token", severity: "high", re: regexp.MustCompile(`\b(?:ghp|gho|ghu|ghs|ghr)_[0-9A-Za-z]{36}\b|\bgithub_pat_[0-9A-Za-z_]{60,}`)},
+		{kind: "slack_token", severity: "high", re: regexp.MustCompile(`\bxox[baprs]-[0-9A-Za-z\-]{10,}`)},
+		{kind: "slack_webhook", severity: "medium", re: regexp.MustCompile(`https://hooks\.slack\.com/services/[A-Za-z0-9/]{20,}`)},
+		{kind: "stripe_secret_key", severity: "high", re: regexp.MustCompile(`\b(?:sk|rk)_live_[0-9A-Za-z]{20,}\b`)},
+		{kind: "sendgrid_key", severity: "high", re: regexp.MustCompile(`\bSG\.[A-Za-z0-9_\-]{22}\.[A-Za-z0-9_\-]{43}\b`)},
+		{kind: "private_key", severity: "high", re: regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP )?PRIVATE KEY-----`)},
+		{kind: "google_api_key", severity: "medium", re: regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+		{kind: "twilio_key", severity: "medium", re: regexp.MustCompile(`\bSK[0-9a-f]{32}\b`)},
+		{kind: "mailgun_key", severity: "medium", re: regexp.MustCompile(`\bkey-[0-9a-zA-Z]{32}\b`)},
+		{kind: "jwt", severity: "medium", re: regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]{10,}\.eyJ[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,}`)},
+		{kind: "generic_secret", severity: "low", group: 1, re: regexp.MustCompile(`(?i)["']?(?:api[_-]?key|apikey|client[_-]?secret|secret[_-]?key|access[_-]?token|auth[_-]?token|password)["']?\s*[:=]\s*["']([A-Za-z0-9_\-.+/=]{16,})["']`)},
+	}
+)
+
+func (a *App) runJSIntel(ctx context.Context) error {
+	baseDir := filepath.Dir(a.cfg.Lists.Domains)
+	reconDir := filepath.Join(baseDir, "recon")
+	if err := os.MkdirAll(reconDir, 0o755); err != nil {
+		return err
+	}
+
+	roots := normalizeRootDomains(readSafeLines(a.cfg.Lists.Wildcards))
+	scoped := a.hostScopeFilter()
+	assets := collectJSAssets(scoped, filepath.Join(reconDir, "all_urls.txt"), filepath.Join(reconDir, "katana_urls.txt"))
+	if len(assets) > jsIntelMaxAssets {
+		a.logger.Printf("%s: limiting js assets from %d to %d", StepJSIntel, len(assets), jsIntelMaxAssets)
+		assets = assets[:jsIntelMaxAssets]
+	}
+
+	intelFile, err := os.Create(filepath.Join(reconDir, "js_intel.jsonl"))
+	if err != nil {
+		return err
+	}
+	defer intelFile.Close()
+	intelWriter := bufio.NewWriter(intelFile)
+	defer intelWriter.Flush()
+
+	secretsFile, err := os.Create(filepath.Join(reconDir, "js_secrets.jsonl"))
+	if err != nil {
+		return err
+	}
+	defer secretsFile.Close()
+	secretsWriter := bufio.NewWriter(secretsFile)
+	defer secretsWriter.Flush()
+
+	client := a.newHTTPClient(paramFuzzRequestTimeout, true)
+	metrics := map[string]int{
+		"assets":    len(assets),
+		"fetched":   0,
+		"endpoints": 0,
+		"params":    0,
+		"new_hosts": 0,
+		"buckets":   0,
+		"secrets":   0,
+	}
+	endpointSet := make(map[string]struct{})
+	paramSet := make(map[string]struct{})
+	hostSet := make(map[string]struct{})
+	lastByHost := make(map[string]time.Time)
+	seenSecrets := make(map[string]struct{})
+
+	for _, asset := range assets {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		parsed, err := url.Parse(asset)
+		if err != nil {
+			continue
+		}
+		host := strings.ToLower(parsed.Hostname())
+		if last, ok := lastByHost[host]; ok {
+			if wait := a.hostRequestDelay(host) - time.Since(last); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, asset, nil)
+		if err != nil {
+			continue
+		}
+		a.applySession(req)
+		resp, err := client.Do(req)
+		lastByHost[host] = time.Now()
+		if err != nil {
+			a.logger.Printf("%s: fetch failed for %s: %v", StepJSIntel, asset, err)
+			continue
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, jsIntelBodyLimit))
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || len(body) == 0 {
+			continue
+		}
+		metrics["fetched"]++
+
+		rec := analyzeJSAsset(parsed, string(body), scoped)
+		rec.Timestamp = time.Now().UTC().Format(time.RFC3339)
+		rec.StatusCode = resp.StatusCode
+		rec.Size = len(body)
+		if rec.SourceMap == "" {
+			header := strings.TrimSpace(resp.Header.Get("SourceMap"))
+			if header == "" {
+				header = strings.TrimSpace(resp.Header.Get("X-SourceMap"))
+			}
+			if header != "" {
+				if ref, err := parsed.Parse(header); err == nil {
+					rec.SourceMap = ref.String()
+				}
+			}
+		}
+		for _, endpoint := range rec.Endpoints {
+			endpointSet[endpoint] = struct{}{}
+		}
+		for _, param := range rec.Params {
+			paramSet[param] = struct{}{}
+		}
+		for _, h := range rec.Hosts {
+			hostSet[h] = struct{}{}
+		}
+		metrics["buckets"] += len(rec.Buckets)
+
+		for _, hit := range findJSSecrets(string(body)) {
+			key := hit.Family + "|" + hit.Match
+			if _, ok := seenSecrets[key]; ok {
+				continue
+			}
+			seenSecrets[key] = struct{}{}
+			hit.Timestamp = rec.Timestamp
+			hit.Endpoint = asset
+			hit.Host = host
+			rec.Secrets = append(rec.Secrets, hit.Family)
+			_ = writeJSONLine(secretsWriter, hit)
+			metrics["secrets"]++
+		}
+		rec.Secrets = unique(rec.Secrets)
+		_ = writeJSONLine(intelWriter, rec)
+	}
+
+	endpoints := sortedParamKeys(endpointSet)
+	params := sortedParamKeys(paramSet)
+	metrics["endpoints"] = len(endpoints)
+	metrics["params"] = len(params)
+	if err := os.WriteFile(filepath.Join(reconDir, "js_endpoints.txt"), []byte(strings.Join(endpoints, "\n")), 0o644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(reconDir, "js_params.txt"), []byte(strings.Join(params, "\n")), 0o644); err != nil {
+		return err
+	}
+
+	// url-corpus and param-fuzz pick the js files up on their own; this run already passed
+	// url-corpus, so fold the results straight into the current corpus and candidates.
+	if err := mergeLinesIntoFile(filepath.Join(reconDir, "all_urls.txt"), endpoints); err != nil {
+		return err
+	}
+	if err := a.mergeURLCorpusSource("js", endpoints); err != nil {
+		return err
+	}
+	if err := mergeLinesIntoFile(filepath.Join(reconDir, "params_candidates.txt"), params); err != nil {
+		return err
+	}
+
+	known := collectHostsFromLists(a.cfg.Lists.Domains)
+	var newHosts []string
+	for h := range hostSet {
+		if _, ok := known[h]; ok {
+			continue
+		}
+		if len(roots) > 0 && !hostMatchesAny(h, roots) {
+			continue
+		}
+		newHosts = append(newHosts, h)
+	}
+	if err := mergeLinesIntoFile(a.cfg.Lists.Domains, newHosts); err != nil {
+		return err
+	}
+	metrics["new_hosts"] = len(newHosts)
+
+	a.logger.Printf("%s: assets=%d fetched=%d endpoints=%d params=%d new_hosts=%d buckets=%d secrets=%d", StepJSIntel, metrics["assets"], metrics["fetched"], metrics["endpoints"], metrics["params"], metrics["new_hosts"], metrics["buckets"], metrics["secrets"])
+	return nil
+}
+
+// hostScopeFilter reports whether a host is in scope: under a wildcard root or a listed
+// domain, and not excluded by out_of_scope.
+func (a *App) hostScopeFilter() func(string) bool {
+	inScope := normalizeRootDomains(readSafeLines(a.cfg.Lists.Wildcards))
+	for host := range collectHostsFromLists(a.cfg.Lists.Domains, a.cfg.Lists.APIDomains) {
+		inScope = append(inScope, host)
+	}
+	outScope := sortedParamKeys(collectHostsFromLists(a.cfg.Lists.OutOfScope))
+	return func(host string) bool {
+		if host == "" || hostMatchesAny(host, outScope) {
+			return false
+		}
+		return len(inScope) == 0 || hostMatchesAny(host, inScope)
+	}
+}
+
+func mergeLinesIntoFile(path string, lines []string) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	return os.WriteFile(path, []byte(strings.Join(unique(append(readSafeLines(path), lines...)), "\n")), 0o644)
+}
+
+func collectJSAssets(scoped func(string) bool, paths ...string) []string {
+	seen := make(map[string]struct{})
+	var out []string
+	for _, p := range paths {
+		for _, line := range readSafeLines(p) {
+			u := normalizeFFUFHitURL(line)
+			if u == "" {
+				continue
+			}
+			parsed, err := url.Parse(u)
+			if err != nil || !strings.HasSuffix(strings.ToLower(parsed.Path), ".js") {
+				continue
+			}
+			if !scoped(strings.ToLower(parsed.Hostname())) {
+				continue
+			}
+			if _, ok := seen[u]; ok {
+				continue
+			}
+			seen[u] = struct{}{}
+			out = append(out, u)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func analyzeJSAsset(base *url.URL, body string, scoped func(string) bool) jsIntelRecord {
+	rec := jsIntelRecord{URL: base.String(), Host: strings.ToLower(base.Hostname())}
+	origin := &url.URL{Scheme: base.Scheme, Host: base.Host, Path: "/"}
+
+	endpointSet := make(map[string]struct{})
+	paramSet := make(map[string]struct{})
+	for _, match := range jsEndpointRe.FindAllStringSubmatch(body, -1) {
+		raw := strings.TrimSpace(match[1])
+		if raw == "" || strings.HasPrefix(raw, "//") || jsMIMERe.MatchString(raw) {
+			continue
+		}
+		if !strings.HasPrefix(raw, "/") && !strings.Contains(raw, "://") && !jsRelativeAPI.MatchString(raw) {
+			continue
+		}
+		ref, err := origin.Parse(raw)
+		if err != nil {
+			continue
+		}
+		if _, ok := jsStaticExts[strings.ToLower(path.Ext(ref.Path))]; ok {
+			continue
+		}
+		if !scoped(strings.ToLower(ref.Hostname())) {
+			continue
+		}
+		u := normalizeFFUFHitURL(ref.String())
+		if u == "" || u == rec.URL {
+			continue
+		}
+		endpointSet[u] = struct{}{}
+		for key := range ref.Query() {
+			if name := normalizeParamName(key); name != "" {
+				paramSet[name] = struct{}{}
+			}
+		}
+	}
+	for _, match := range jsParamRe.FindAllStringSubmatch(body, -1) {
+		if name := normalizeParamName(match[1]); name != "" {
+			paramSet[name] = struct{}{}
+		}
+	}
+
+	opSet := make(map[string]struct{})
+	for _, match := range jsGraphQLRe.FindAllStringSubmatch(body, -1) {
+		opSet[match[1]+" "+match[2]] = struct{}{}
+	}
+
+	hostSet := make(map[string]struct{})
+	for _, match := range jsAbsoluteRe.FindAllStringSubmatch(body, -1) {
+		h := strings.ToLower(strings.Trim(match[1], "."))
+		if h != rec.Host && scoped(h) {
+			hostSet[h] = struct{}{}
+		}
+	}
+
+	bucketSet := make(map[string]struct{})
+	for _, match := range jsBucketRe.FindAllString(body, -1) {
+		bucketSet[strings.ToLower(match)] = struct{}{}
+	}
+
+	if match := jsSourceMapRe.FindStringSubmatch(body); len(match) > 1 && !strings.HasPrefix(match[1], "data:") {
+		if ref, err := base.Parse(match[1]); err == nil {
+			rec.SourceMap = ref.String()
+		}
+	}
+
+	rec.Endpoints = sortedParamKeys(endpointSet)
+	rec.Params = sortedParamKeys(paramSet)
+	rec.GraphQL = sortedParamKeys(opSet)
+	rec.Hosts = sortedParamKeys(hostSet)
+	rec.Buckets = sortedParamKeys(bucketSet)
+	return rec
+}
+
+func findJSSecrets(body string) []jsSecretHit {
+	var hits []jsSecretHit
+	for _, pattern := range jsSecretPatterns {
+		for _, loc := range pattern.re.FindAllStringSubmatchIndex(body, 20) {
+			start, end := loc[0], loc[1]
+			if pattern.group > 0 && len(loc) > 2*pattern.group+1 && loc[2*pattern.group] >= 0 {
+				start, end = loc[2*pattern.group], loc[2*pattern.group+1]
+			}
+			value := body[start:end]
+			if pattern.kind == "generic_secret" && isJSPlaceholderSecret(value) {
+				continue
+			}
+			hits = append(hits, jsSecretHit{
+				Family:       pattern.kind,
+				Severity:     pattern.severity,
+				Match:        redactJSSecret(value),
+				Line:         strings.Count(body[:start], "\n") + 1,
+				Reasons:      []string{"js_secret:" + pattern.kind},
+				ManualAction: "Confirm the credential is live and scoped to production before reporting; check whether it is meant to be public (e.g. restricted browser keys).",
+			})
+		}
+	}
+	return hits
+}
+
+func isJSPlaceholderSecret(value string) bool {
+	lower := strings.ToLower(value)
+	for _, marker := range []string{"xxxx", "your", "example", "placeholder", "changeme", "undefined", "process.env"} {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return strings.Count(lower, string(lower[0])) == len(lower)
+}
+
+func redactJSSecret(value string) string {
+	if len(value) <= 12 {
+		return value[:2] + strings.Repeat("*", len(value)-2)
+	}
+	return value[:6] + strings.Repeat("*", len(value)-10) + value[len(value)-4:]
+}
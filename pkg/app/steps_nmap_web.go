@@ -0,0 +1,250 @@
+package app
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	nmapWebMaxInputs    = 512
+	nmapWebProbeTimeout = 8 * time.Second
+)
+
+// nmapWebPorts carry HTTP often enough that they are probed even when nmap names the service differently.
+var nmapWebPorts = map[string]struct{}{
+	"81": {}, "591": {}, "2082": {}, "2083": {}, "2086": {}, "2087": {}, "2375": {}, "3000": {},
+	"4443": {}, "5000": {}, "5601": {}, "7001": {}, "8000": {}, "8008": {}, "8080": {}, "8081": {},
+	"8088": {}, "8443": {}, "8888": {}, "9000": {}, "9090": {}, "9200": {}, "9443": {}, "10000": {},
+}
+
+var nmapWebTLSPorts = map[string]struct{}{
+	"443": {}, "2083": {}, "2087": {}, "4443": {}, "8443": {}, "9443": {},
+}
+
+var htmlTitleRe = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+func isNmapWebService(row nmapServiceRow) bool {
+	if row.Proto != "" && !strings.EqualFold(row.Proto, "tcp") {
+		return false
+	}
+	if strings.Contains(strings.ToLower(row.Service), "http") {
+		return true
+	}
+	_, ok := nmapWebPorts[row.Port]
+	return ok
+}
+
+func (a *App) nmapWebServicesPath() string {
+	return filepath.Join(a.fuzzingBaseDir(), "nmap", "web_services.jsonl")
+}
+
+// probeNmapWebServices probes every http-like nmap service and merges the live ones into
+// domains_http and live-webservers.jsonl. Results are kept in web_services.jsonl so the
+// httpx step can fold them back in on later runs.
+func (a *App) probeNmapWebServices(ctx context.Context, rows []nmapServiceRow) (int, int, error) {
+	aliases := nmapHostAliases(filepath.Join(a.fuzzingBaseDir(), "nmap", "scan.gnmap"))
+	inputSet := make(map[string]struct{})
+	tlsHint := make(map[string]bool)
+	for _, row := range rows {
+		if !isNmapWebService(row) {
+			continue
+		}
+		hosts := aliases[row.Host]
+		if len(hosts) == 0 {
+			hosts = []string{row.Host}
+		}
+		service := strings.ToLower(row.Service)
+		_, tlsPort := nmapWebTLSPorts[row.Port]
+		for _, host := range hosts {
+			host = strings.ToLower(strings.Trim(strings.TrimSpace(host), "."))
+			if host == "" {
+				continue
+			}
+			input := host + ":" + row.Port
+			inputSet[input] = struct{}{}
+			tlsHint[input] = tlsPort || strings.Contains(service, "ssl") || strings.Contains(service, "https")
+		}
+	}
+	inputs := sortedParamKeys(inputSet)
+	if len(inputs) > nmapWebMaxInputs {
+		inputs = inputs[:nmapWebMaxInputs]
+	}
+	if len(inputs) == 0 {
+		return 0, 0, nil
+	}
+
+	live, err := a.probeWebInputsWithHTTPX(ctx, inputs)
+	if err != nil {
+		a.logger.Printf("%s: httpx probe of web ports failed, using direct requests: %v", StepNmapEnrich, err)
+		live = a.probeWebInputsDirect(ctx, inputs, tlsHint)
+	}
+	for i := range live {
+		live[i].Port = liveWebserverPort(live[i].URL, live[i].Port)
+	}
+
+	if err := a.writeLiveWebserversJSONL(a.nmapWebServicesPath(), live); err != nil {
+		return len(live), 0, err
+	}
+	added, err := a.mergeLiveWebserverRows(live)
+	return len(live), added, err
+}
+
+func (a *App) probeWebInputsWithHTTPX(ctx context.Context, inputs []string) ([]liveWebserverRecord, error) {
+	tmp, err := os.CreateTemp("", "bflow-httpx-ports-")
+	if err != nil {
+		return nil, err
+	}
+	probeSource := tmp.Name()
+	_ = tmp.Close()
+	defer os.Remove(probeSource)
+	if err := os.WriteFile(probeSource, []byte(strings.Join(inputs, "\n")), 0o644); err != nil {
+		return nil, err
+	}
+	stdout, err := a.runCommandCapture(
+		ctx,
+		"httpx",
+		"-silent",
+		"-json",
+		"-status-code",
+		"-title",
+		"-web-server",
+		"-tech-detect",
+		"-content-length",
+		"-l",
+		probeSource,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return parseHTTPXJSONRecords(stdout), nil
+}
+
+func (a *App) probeWebInputsDirect(ctx context.Context, inputs []string, tlsHint map[string]bool) []liveWebserverRecord {
+	// Admin consoles on odd ports are mostly self-signed; httprobe skips verification too.
+	client := a.newInsecureHTTPClient(nmapWebProbeTimeout, false)
+
+	var out []liveWebserverRecord
+	for _, input := range inputs {
+		if ctx.Err() != nil {
+			break
+		}
+		schemes := []string{"http", "https"}
+		if tlsHint[input] {
+			schemes = []string{"https", "http"}
+		}
+		for _, scheme := range schemes {
+			target := scheme + "://" + input
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+			if err != nil {
+				continue
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				continue
+			}
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 65536))
+			_ = resp.Body.Close()
+
+			rec := liveWebserverRecord{
+				URL:           target,
+				StatusCode:    resp.StatusCode,
+				WebServer:     strings.TrimSpace(resp.Header.Get("Server")),
+				ContentLength: len(body),
+			}
+			if match := htmlTitleRe.FindSubmatch(body); len(match) > 1 {
+				rec.Title = strings.Join(strings.Fields(string(match[1])), " ")
+			}
+			out = append(out, rec)
+			break
+		}
+	}
+	return out
+}
+
+// foldNmapWebServices re-applies web services found on non-default ports by an earlier nmap run,
+// since buildHTTPDomains rewrites domains_http and live-webservers.jsonl from scratch.
+func (a *App) foldNmapWebServices() error {
+	rows := readLiveWebserverRecords(a.nmapWebServicesPath())
+	if len(rows) == 0 {
+		return nil
+	}
+	added, err := a.mergeLiveWebserverRows(rows)
+	if err != nil {
+		return err
+	}
+	if added > 0 {
+		a.logger.Printf("%s: merged %d web service(s) on non-default ports from nmap", StepHTTPX, added)
+	}
+	return nil
+}
+
+func (a *App) mergeLiveWebserverRows(extra []liveWebserverRecord) (int, error) {
+	baseDir := filepath.Dir(a.cfg.Lists.Domains)
+	jsonlPath := filepath.Join(baseDir, "live-webservers.jsonl")
+	domainsHTTPPath := filepath.Join(baseDir, "domains_http")
+
+	rows := readLiveWebserverRecords(jsonlPath)
+	seen := make(map[string]struct{}, len(rows))
+	for _, row := range rows {
+		seen[normalizeLiveTarget(row.URL)] = struct{}{}
+	}
+	var added []string
+	for _, row := range extra {
+		u := normalizeLiveTarget(row.URL)
+		if u == "" {
+			continue
+		}
+		if _, ok := seen[u]; ok {
+			continue
+		}
+		seen[u] = struct{}{}
+		rows = append(rows, row)
+		added = append(added, u)
+	}
+	if len(added) == 0 {
+		return 0, nil
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return strings.ToLower(rows[i].URL) < strings.ToLower(rows[j].URL)
+	})
+
+	urls := unique(append(readSafeLines(domainsHTTPPath), added...))
+	if err := os.WriteFile(domainsHTTPPath, []byte(strings.Join(urls, "\n")), 0o644); err != nil {
+		return 0, err
+	}
+	if err := a.syncProbedDomainViews(urls); err != nil {
+		return 0, err
+	}
+	if err := a.writeLiveWebserversJSONL(jsonlPath, rows); err != nil {
+		return 0, err
+	}
+	return len(added), nil
+}
+
+func liveWebserverPort(rawURL, fallback string) string {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return fallback
+	}
+	if port := parsed.Port(); port != "" {
+		return port
+	}
+	if fallback != "" {
+		return fallback
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "https":
+		return "443"
+	case "http":
+		return "80"
+	}
+	return ""
+}
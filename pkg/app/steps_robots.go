@@ -0,0 +1,277 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	sitemapMaxDepth   = 3
+	sitemapMaxFetches = 50
+	sitemapMaxURLs    = 10000
+	sitemapBodyLimit  = 50 << 20
+)
+
+type robotsGroup struct {
+	UserAgents []string `json:"user_agents"`
+	Allow      []string `json:"allow,omitempty"`
+	Disallow   []string `json:"disallow,omitempty"`
+	CrawlDelay float64  `json:"crawl_delay,omitempty"`
+}
+
+type robotsRecord struct {
+	Timestamp      string        `json:"timestamp"`
+	Host           string        `json:"host"`
+	URL            string        `json:"url"`
+	StatusCode     int           `json:"status_code"`
+	Groups         []robotsGroup `json:"groups,omitempty"`
+	Sitemaps       []string      `json:"sitemaps,omitempty"`
+	SitemapFetches int           `json:"sitemap_fetches"`
+	SitemapURLs    int           `json:"sitemap_urls"`
+	NewSitemapURLs int           `json:"new_sitemap_urls"`
+	Truncated      bool          `json:"sitemap_budget_hit,omitempty"`
+}
+
+type sitemapEntry struct {
+	Timestamp string `json:"timestamp"`
+	Host      string `json:"host"`
+	Loc       string `json:"loc"`
+	Lastmod   string `json:"lastmod,omitempty"`
+	Sitemap   string `json:"sitemap"`
+	Depth     int    `json:"depth"`
+	FirstSeen string `json:"first_seen"`
+	New       bool   `json:"new"`
+}
+
+type sitemapDocument struct {
+	URLs []struct {
+		Loc     string `xml:"loc"`
+		Lastmod string `xml:"lastmod"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc     string `xml:"loc"`
+		Lastmod string `xml:"lastmod"`
+	} `xml:"sitemap"`
+}
+
+type sitemapCrawl struct {
+	entries   []sitemapEntry
+	fetches   int
+	truncated bool
+}
+
+// parseRobotsTxt splits robots.txt into user-agent groups. Consecutive User-agent lines
+// share a group; Sitemap lines are global and returned separately.
+func parseRobotsTxt(body string) ([]robotsGroup, []string) {
+	var groups []robotsGroup
+	var sitemaps []string
+	current := -1
+	lastWasAgent := false
+	for _, line := range strings.Split(body, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if !lastWasAgent || current < 0 {
+				groups = append(groups, robotsGroup{})
+				current = len(groups) - 1
+			}
+			if value != "" {
+				groups[current].UserAgents = append(groups[current].UserAgents, value)
+			}
+			lastWasAgent = true
+			continue
+		case "sitemap":
+			if normalizeFFUFHitURL(value) != "" && !containsAnyString(sitemaps, value) {
+				sitemaps = append(sitemaps, value)
+			}
+		case "allow", "disallow", "crawl-delay":
+			if current < 0 {
+				groups = append(groups, robotsGroup{UserAgents: []string{"*"}})
+				current = 0
+			}
+			switch key {
+			case "allow":
+				if value != "" {
+					groups[current].Allow = append(groups[current].Allow, value)
+				}
+			case "disallow":
+				if value != "" {
+					groups[current].Disallow = append(groups[current].Disallow, value)
+				}
+			case "crawl-delay":
+				if delay, err := strconv.ParseFloat(value, 64); err == nil {
+					groups[current].CrawlDelay = delay
+				}
+			}
+		}
+		lastWasAgent = false
+	}
+	for i := range groups {
+		groups[i].Allow = unique(groups[i].Allow)
+		groups[i].Disallow = unique(groups[i].Disallow)
+	}
+	return groups, sitemaps
+}
+
+func robotsRulePaths(groups []robotsGroup) []string {
+	var out []string
+	for _, group := range groups {
+		out = append(out, group.Disallow...)
+		out = append(out, group.Allow...)
+	}
+	return unique(out)
+}
+
+// crawlSitemaps follows sitemap indexes breadth-first up to sitemapMaxDepth, stopping once
+// sitemapMaxFetches documents or sitemapMaxURLs page URLs have been collected.
+func (a *App) crawlSitemaps(ctx context.Context, host string, roots []string) sitemapCrawl {
+	var crawl sitemapCrawl
+	type queued struct {
+		url   string
+		depth int
+	}
+	queue := make([]queued, 0, len(roots))
+	visited := make(map[string]struct{})
+	for _, root := range roots {
+		queue = append(queue, queued{url: root})
+	}
+	seenLocs := make(map[string]struct{})
+	for len(queue) > 0 {
+		if ctx.Err() != nil {
+			break
+		}
+		next := queue[0]
+		queue = queue[1:]
+		if _, ok := visited[next.url]; ok {
+			continue
+		}
+		visited[next.url] = struct{}{}
+		if crawl.fetches >= sitemapMaxFetches || len(crawl.entries) >= sitemapMaxURLs {
+			crawl.truncated = true
+			break
+		}
+		crawl.fetches++
+		doc, plain, err := a.fetchSitemapDocument(ctx, next.url)
+		if err != nil {
+			continue
+		}
+		for _, child := range doc.Sitemaps {
+			loc := strings.TrimSpace(child.Loc)
+			if normalizeFFUFHitURL(loc) == "" {
+				continue
+			}
+			if next.depth+1 > sitemapMaxDepth {
+				crawl.truncated = true
+				continue
+			}
+			queue = append(queue, queued{url: loc, depth: next.depth + 1})
+		}
+		add := func(raw, lastmod string) {
+			loc := normalizeFFUFHitURL(strings.TrimSpace(raw))
+			if loc == "" {
+				return
+			}
+			if _, ok := seenLocs[loc]; ok {
+				return
+			}
+			if len(crawl.entries) >= sitemapMaxURLs {
+				crawl.truncated = true
+				return
+			}
+			seenLocs[loc] = struct{}{}
+			crawl.entries = append(crawl.entries, sitemapEntry{
+				Host:    host,
+				Loc:     loc,
+				Lastmod: strings.TrimSpace(lastmod),
+				Sitemap: next.url,
+				Depth:   next.depth,
+			})
+		}
+		for _, u := range doc.URLs {
+			add(u.Loc, u.Lastmod)
+		}
+		for _, loc := range plain {
+			add(loc, "")
+		}
+	}
+	return crawl
+}
+
+// fetchSitemapDocument downloads one sitemap, transparently inflating gzip bodies. XML
+// sitemaps come back as a document; text sitemaps and unparsable XML fall back to plain URLs.
+func (a *App) fetchSitemapDocument(ctx context.Context, sitemapURL string) (sitemapDocument, []string, error) {
+	var doc sitemapDocument
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sitemapURL, nil)
+	if err != nil {
+		return doc, nil, err
+	}
+	a.applySession(req)
+	resp, err := a.newHTTPClient(0, true).Do(req)
+	if err != nil {
+		return doc, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return doc, nil, fmt.Errorf("sitemap returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, sitemapBodyLimit))
+	if err != nil {
+		return doc, nil, err
+	}
+	if len(body) > 2 && body[0] == 0x1f && body[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return doc, nil, err
+		}
+		body, err = io.ReadAll(io.LimitReader(zr, sitemapBodyLimit))
+		_ = zr.Close()
+		if err != nil && len(body) == 0 {
+			return doc, nil, err
+		}
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] != '<' {
+		var plain []string
+		scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+		for scanner.Scan() {
+			if u := normalizeFFUFHitURL(scanner.Text()); u != "" {
+				plain = append(plain, u)
+			}
+		}
+		return doc, plain, nil
+	}
+	if err := xml.Unmarshal(trimmed, &doc); err != nil {
+		return doc, extractSitemapLocs(string(trimmed)), nil
+	}
+	return doc, nil, nil
+}
+
+func loadSitemapFirstSeen(path string) map[string]string {
+	out := make(map[string]string)
+	for _, line := range readSafeLines(path) {
+		var entry sitemapEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil || entry.Loc == "" {
+			continue
+		}
+		out[entry.Loc] = entry.FirstSeen
+	}
+	return out
+}
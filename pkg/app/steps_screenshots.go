@@ -0,0 +1,342 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"image"
+	_ "image/png"
+	"math/bits"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	screenshotConcurrency    = 4
+	screenshotPageTimeoutMS  = 20000
+	screenshotMaxTargets     = 500
+	screenshotRunTimeout     = 45 * time.Minute
+	screenshotClusterMaxDist = 8
+)
+
+type screenshotCapture struct {
+	URL      string `json:"url"`
+	FinalURL string `json:"final_url"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	File     string `json:"file"`
+	Error    string `json:"error"`
+	At       string `json:"at"`
+}
+
+type screenshotRecord struct {
+	Timestamp string `json:"timestamp"`
+	URL       string `json:"url"`
+	Host      string `json:"host"`
+	FinalURL  string `json:"final_url,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Status    int    `json:"status,omitempty"`
+	File      string `json:"file,omitempty"`
+	PHash     string `json:"phash,omitempty"`
+	Cluster   string `json:"cluster,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type screenshotCluster struct {
+	ID             string   `json:"id"`
+	Size           int      `json:"size"`
+	PHash          string   `json:"phash"`
+	Representative string   `json:"representative"`
+	File           string   `json:"file"`
+	Titles         []string `json:"titles"`
+	Hosts          []string `json:"hosts"`
+}
+
+func (a *App) runScreenshots(ctx context.Context) error {
+	baseDir := filepath.Dir(a.cfg.Lists.Domains)
+	outDir := filepath.Join(baseDir, "screenshots")
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return err
+	}
+
+	targets := unique(normalizeHTTPSTargets(readSafeLines(filepath.Join(baseDir, "domains_http"))))
+	if len(targets) == 0 {
+		a.logger.Printf("%s: no targets available", StepScreenshots)
+		return nil
+	}
+	if len(targets) > screenshotMaxTargets {
+		a.logger.Printf("%s: limiting targets from %d to %d", StepScreenshots, len(targets), screenshotMaxTargets)
+		targets = targets[:screenshotMaxTargets]
+	}
+	targetsFile := filepath.Join(outDir, "targets.txt")
+	if err := os.WriteFile(targetsFile, []byte(strings.Join(targets, "\n")+"\n"), 0o644); err != nil {
+		return err
+	}
+
+	nodeBin, err := ResolveNodeBinary()
+	if err != nil {
+		return err
+	}
+	args := []string{
+		filepath.Join("scripts", "screenshot_capture.mjs"),
+		"--targets", targetsFile,
+		"--out-dir", outDir,
+		"--concurrency", strconv.Itoa(screenshotConcurrency),
+		"--timeout", strconv.Itoa(screenshotPageTimeoutMS),
+	}
+	if proxy := a.browserProxyURL(); proxy != "" {
+		args = append(args, "--proxy", proxy)
+	}
+	args = append(args, "--ignore-https-errors", strconv.FormatBool(a.cfg.Network.InsecureSkipVerify))
+	if a.cfg.Network.CACert != "" {
+		spki, err := caCertSPKIHashes(a.cfg.Network.CACert)
+		if err != nil {
+			a.logger.Printf("%s: %v; browser uses system trust only", StepScreenshots, err)
+		} else {
+			args = append(args, "--ca-spki", strings.Join(spki, ","))
+		}
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, screenshotRunTimeout)
+	defer cancel()
+	start := time.Now()
+	a.logger.Printf("exec: %s %s", nodeBin, strings.Join(args, " "))
+	cmd := exec.CommandContext(runCtx, nodeBin, args...)
+	output, runErr := cmd.CombinedOutput()
+	_ = os.WriteFile(filepath.Join(outDir, "capture.log"), output, 0o644)
+	if runErr != nil {
+		a.logger.Printf("%s: playwright capture error after %s: %v", StepScreenshots, time.Since(start).Round(time.Second), runErr)
+	}
+
+	var captures []screenshotCapture
+	for _, line := range readSafeLines(filepath.Join(outDir, "captures.jsonl")) {
+		var row screenshotCapture
+		if err := json.Unmarshal([]byte(line), &row); err != nil || row.URL == "" {
+			continue
+		}
+		captures = append(captures, row)
+	}
+
+	records := make([]screenshotRecord, 0, len(captures))
+	hashes := make([]uint64, 0, len(captures))
+	hashed := make([]int, 0, len(captures))
+	for _, row := range captures {
+		rec := screenshotRecord{
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			URL:       row.URL,
+			Host:      extractHostCandidate(row.URL),
+			FinalURL:  row.FinalURL,
+			Title:     row.Title,
+			Status:    row.Status,
+			File:      row.File,
+			Error:     row.Error,
+		}
+		if row.File != "" {
+			if hash, err := screenshotDHash(filepath.Join(outDir, "png", filepath.Base(row.File))); err == nil {
+				rec.PHash = fmt.Sprintf("%016x", hash)
+				hashes = append(hashes, hash)
+				hashed = append(hashed, len(records))
+			}
+		}
+		records = append(records, rec)
+	}
+
+	clusters := clusterScreenshotHashes(hashes, screenshotClusterMaxDist)
+	clusterRows := make([]screenshotCluster, 0, len(clusters))
+	for _, members := range clusters {
+		rep := records[hashed[members[0]]]
+		cluster := screenshotCluster{PHash: rep.PHash, Representative: rep.URL, File: rep.File, Size: len(members)}
+		var titles []string
+		for _, m := range members {
+			rec := records[hashed[m]]
+			cluster.Hosts = append(cluster.Hosts, rec.Host)
+			if rec.Title != "" {
+				titles = append(titles, rec.Title)
+			}
+		}
+		cluster.Hosts = unique(cluster.Hosts)
+		cluster.Titles = unique(titles)
+		if len(cluster.Titles) > 5 {
+			cluster.Titles = cluster.Titles[:5]
+		}
+		clusterRows = append(clusterRows, cluster)
+	}
+	sort.SliceStable(clusterRows, func(i, j int) bool {
+		if clusterRows[i].Size != clusterRows[j].Size {
+			return clusterRows[i].Size > clusterRows[j].Size
+		}
+		return clusterRows[i].Representative < clusterRows[j].Representative
+	})
+	for i := range clusterRows {
+		clusterRows[i].ID = fmt.Sprintf("c%03d", i+1)
+	}
+	clusterByRep := make(map[string]string, len(clusterRows))
+	for _, cluster := range clusterRows {
+		clusterByRep[cluster.Representative] = cluster.ID
+	}
+	for _, members := range clusters {
+		id := clusterByRep[records[hashed[members[0]]].URL]
+		for _, m := range members {
+			records[hashed[m]].Cluster = id
+		}
+	}
+
+	outFile, err := os.Create(filepath.Join(outDir, "screenshots.jsonl"))
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+	w := bufio.NewWriter(outFile)
+	defer w.Flush()
+	captured := 0
+	for _, rec := range records {
+		if rec.File != "" {
+			captured++
+		}
+		_ = writeJSONLine(w, rec)
+	}
+	if err := writePrettyJSON(filepath.Join(outDir, "clusters.json"), clusterRows); err != nil {
+		return err
+	}
+
+	multi := 0
+	for _, cluster := range clusterRows {
+		if cluster.Size > 1 {
+			multi++
+		}
+	}
+	a.logger.Printf("%s: targets=%d captured=%d failed=%d clusters=%d lookalike_clusters=%d", StepScreenshots,
+		len(targets), captured, len(records)-captured, len(clusterRows), multi)
+	return nil
+}
+
+// browserProxyURL is egressProxyURL for Chromium, which ignores proxy env vars. Chromium
+// already resolves names through a SOCKS5 proxy and has no socks5h scheme.
+func (a *App) browserProxyURL() string {
+	proxy := a.egressProxyURL()
+	if proxy == nil {
+		return ""
+	}
+	out := *proxy
+	if out.Scheme == "socks5h" {
+		out.Scheme = "socks5"
+	}
+	return out.String()
+}
+
+// caCertSPKIHashes returns the base64 SHA-256 of each certificate's public key in the
+// network.ca_cert bundle, the form Chromium takes to trust a CA the system store lacks.
+func caCertSPKIHashes(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ca_cert: %w", err)
+	}
+	var hashes []string
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("ca_cert %s: %w", path, err)
+		}
+		sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		hashes = append(hashes, base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	if len(hashes) == 0 {
+		return nil, fmt.Errorf("ca_cert %s: no PEM certificates found", path)
+	}
+	return hashes, nil
+}
+
+// screenshotDHash computes a 64-bit difference hash: the image is reduced to a
+// 9x8 grayscale grid and each bit records whether a cell is brighter than its right neighbour.
+func screenshotDHash(path string) (uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return 0, err
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 9 || height < 8 {
+		return 0, fmt.Errorf("image too small: %dx%d", width, height)
+	}
+
+	const cols, rows = 9, 8
+	var grid [rows][cols]float64
+	step := 1
+	if width*height > 400000 {
+		step = 2
+	}
+	for gy := 0; gy < rows; gy++ {
+		y0 := bounds.Min.Y + gy*height/rows
+		y1 := bounds.Min.Y + (gy+1)*height/rows
+		for gx := 0; gx < cols; gx++ {
+			x0 := bounds.Min.X + gx*width/cols
+			x1 := bounds.Min.X + (gx+1)*width/cols
+			var sum float64
+			var count int
+			for y := y0; y < y1; y += step {
+				for x := x0; x < x1; x += step {
+					r, g, b, _ := img.At(x, y).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+					count++
+				}
+			}
+			if count > 0 {
+				grid[gy][gx] = sum / float64(count)
+			}
+		}
+	}
+
+	var hash uint64
+	for gy := 0; gy < rows; gy++ {
+		for gx := 0; gx < cols-1; gx++ {
+			hash <<= 1
+			if grid[gy][gx] > grid[gy][gx+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash, nil
+}
+
+// clusterScreenshotHashes groups hashes greedily: each hash joins the first cluster
+// whose representative is within maxDist bits, otherwise it starts a new cluster.
+func clusterScreenshotHashes(hashes []uint64, maxDist int) [][]int {
+	var clusters [][]int
+	for i, hash := range hashes {
+		placed := false
+		for c, members := range clusters {
+			if bits.OnesCount64(hash^hashes[members[0]]) <= maxDist {
+				clusters[c] = append(clusters[c], i)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			clusters = append(clusters, []int{i})
+		}
+	}
+	return clusters
+}
@@ -0,0 +1,295 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	sourceMapMaxBundles  = 200
+	sourceMapBodyLimit   = 25 << 20
+	sourceMapMaxSources  = 3000
+	sourceMapMaxSegments = 16
+)
+
+type sourceMapRecord struct {
+	Timestamp    string   `json:"timestamp"`
+	Endpoint     string   `json:"endpoint"`
+	MapURL       string   `json:"map_url"`
+	Host         string   `json:"host"`
+	Family       string   `json:"family"`
+	Severity     string   `json:"severity"`
+	Sources      int      `json:"sources"`
+	Recovered    int      `json:"recovered"`
+	Vendor       int      `json:"vendor_sources"`
+	OutputDir    string   `json:"output_dir"`
+	Endpoints    []string `json:"endpoints,omitempty"`
+	Params       []string `json:"params,omitempty"`
+	Secrets      []string `json:"secrets,omitempty"`
+	Reasons      []string `json:"reasons"`
+	ManualAction string   `json:"manual_action"`
+}
+
+type sourceMapDocument struct {
+	Version        int       `json:"version"`
+	SourceRoot     string    `json:"sourceRoot"`
+	Sources        []string  `json:"sources"`
+	SourcesContent []*string `json:"sourcesContent"`
+	Mappings       string    `json:"mappings"`
+}
+
+func (a *App) runSourceMaps(ctx context.Context) error {
+	reconDir := filepath.Join(filepath.Dir(a.cfg.Lists.Domains), "recon")
+	outDir := filepath.Join(reconDir, "sourcemaps")
+	if err := os.RemoveAll(outDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return err
+	}
+
+	candidates := make(map[string]string)
+	var bundles []string
+	for _, line := range readSafeLines(filepath.Join(reconDir, "js_intel.jsonl")) {
+		var rec jsIntelRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil || rec.URL == "" {
+			continue
+		}
+		if _, ok := candidates[rec.URL]; ok {
+			continue
+		}
+		mapURL := rec.SourceMap
+		if mapURL == "" {
+			mapURL = rec.URL + ".map"
+		}
+		candidates[rec.URL] = mapURL
+		bundles = append(bundles, rec.URL)
+	}
+	if len(bundles) == 0 {
+		a.logger.Printf("%s: no js assets in js_intel.jsonl", StepSourceMaps)
+		return nil
+	}
+	if len(bundles) > sourceMapMaxBundles {
+		a.logger.Printf("%s: limiting bundles from %d to %d", StepSourceMaps, len(bundles), sourceMapMaxBundles)
+		bundles = bundles[:sourceMapMaxBundles]
+	}
+
+	f, err := os.Create(filepath.Join(outDir, "sourcemaps.jsonl"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	defer w.Flush()
+
+	// js-intel has already written this run's secrets; recovered-source hits are appended
+	// unless js-intel or an earlier bundle already reported the same match.
+	seenSecrets := make(map[string]struct{})
+	for _, line := range readSafeLines(filepath.Join(reconDir, "js_secrets.jsonl")) {
+		var hit jsSecretHit
+		if err := json.Unmarshal([]byte(line), &hit); err == nil {
+			seenSecrets[hit.Family+"|"+hit.Match] = struct{}{}
+		}
+	}
+	secretsFile, err := os.OpenFile(filepath.Join(reconDir, "js_secrets.jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer secretsFile.Close()
+	secretsWriter := bufio.NewWriter(secretsFile)
+	defer secretsWriter.Flush()
+
+	client := a.newHTTPClient(paramFuzzRequestTimeout*2, true)
+	scoped := a.hostScopeFilter()
+	metrics := map[string]int{
+		"bundles":      len(bundles),
+		"exposed":      0,
+		"recovered":    0,
+		"endpoints":    0,
+		"secrets":      0,
+		"out_of_scope": 0,
+	}
+	endpointSet := make(map[string]struct{})
+	paramSet := make(map[string]struct{})
+	lastByHost := make(map[string]time.Time)
+
+	for _, bundle := range bundles {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		bundleURL, err := url.Parse(bundle)
+		if err != nil {
+			continue
+		}
+		mapURL := candidates[bundle]
+		host := strings.ToLower(bundleURL.Hostname())
+		// sourceMappingURL may point anywhere, including hosts outside the program.
+		if parsedMap, err := url.Parse(mapURL); err != nil || !scoped(strings.ToLower(parsedMap.Hostname())) {
+			metrics["out_of_scope"]++
+			continue
+		}
+		if last, ok := lastByHost[host]; ok {
+			if wait := a.hostRequestDelay(host) - time.Since(last); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+		doc, ok := fetchSourceMap(ctx, client, mapURL)
+		lastByHost[host] = time.Now()
+		if !ok {
+			continue
+		}
+		metrics["exposed"]++
+
+		rec := sourceMapRecord{
+			Timestamp:    time.Now().UTC().Format(time.RFC3339),
+			Endpoint:     bundle,
+			MapURL:       mapURL,
+			Host:         host,
+			Family:       "sourcemap_exposed",
+			Severity:     "low",
+			Sources:      len(doc.Sources),
+			OutputDir:    filepath.Join(outDir, sanitizeFilename(host)),
+			Reasons:      []string{"sourcemap_exposed"},
+			ManualAction: "Source map is publicly served: review the recovered tree for internal routes, feature flags and credentials, then report the exposure if the program accepts it.",
+		}
+		bundleEndpoints := make(map[string]struct{})
+		bundleParams := make(map[string]struct{})
+		for i, source := range doc.Sources {
+			if i >= sourceMapMaxSources {
+				break
+			}
+			if i >= len(doc.SourcesContent) || doc.SourcesContent[i] == nil {
+				continue
+			}
+			if strings.Contains(source, "node_modules/") || strings.HasPrefix(source, "webpack/") {
+				rec.Vendor++
+				continue
+			}
+			content := *doc.SourcesContent[i]
+			target := sourceMapFilePath(rec.OutputDir, doc.SourceRoot+source, i)
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				continue
+			}
+			if err := os.WriteFile(target, []byte(content), 0o644); err != nil {
+				continue
+			}
+			rec.Recovered++
+
+			intel := analyzeJSAsset(bundleURL, content, scoped)
+			for _, endpoint := range intel.Endpoints {
+				bundleEndpoints[endpoint] = struct{}{}
+				endpointSet[endpoint] = struct{}{}
+			}
+			for _, param := range intel.Params {
+				bundleParams[param] = struct{}{}
+				paramSet[param] = struct{}{}
+			}
+			rel, _ := filepath.Rel(outDir, target)
+			for _, hit := range findJSSecrets(content) {
+				rec.Secrets = append(rec.Secrets, hit.Family)
+				key := hit.Family + "|" + hit.Match
+				if _, ok := seenSecrets[key]; ok {
+					continue
+				}
+				seenSecrets[key] = struct{}{}
+				hit.Timestamp = rec.Timestamp
+				hit.Endpoint = bundle
+				hit.Host = host
+				hit.Source = rel
+				_ = writeJSONLine(secretsWriter, hit)
+				metrics["secrets"]++
+			}
+		}
+		rec.Endpoints = sortedParamKeys(bundleEndpoints)
+		rec.Params = sortedParamKeys(bundleParams)
+		rec.Secrets = unique(rec.Secrets)
+		metrics["recovered"] += rec.Recovered
+		_ = writeJSONLine(w, rec)
+	}
+
+	endpoints := sortedParamKeys(endpointSet)
+	params := sortedParamKeys(paramSet)
+	metrics["endpoints"] = len(endpoints)
+	for _, path := range []string{filepath.Join(reconDir, "js_endpoints.txt"), filepath.Join(reconDir, "all_urls.txt")} {
+		if err := mergeLinesIntoFile(path, endpoints); err != nil {
+			return err
+		}
+	}
+	for _, path := range []string{filepath.Join(reconDir, "js_params.txt"), filepath.Join(reconDir, "params_candidates.txt")} {
+		if err := mergeLinesIntoFile(path, params); err != nil {
+			return err
+		}
+	}
+
+	a.logger.Printf("%s: bundles=%d out_of_scope=%d exposed=%d recovered_files=%d endpoints=%d secrets=%d", StepSourceMaps, metrics["bundles"], metrics["out_of_scope"], metrics["exposed"], metrics["recovered"], metrics["endpoints"], metrics["secrets"])
+	return nil
+}
+
+func fetchSourceMap(ctx context.Context, client *http.Client, mapURL string) (sourceMapDocument, bool) {
+	var doc sourceMapDocument
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mapURL, nil)
+	if err != nil {
+		return doc, false
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return doc, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return doc, false
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, sourceMapBodyLimit))
+	if err != nil {
+		return doc, false
+	}
+	// Soft-404 pages answer 200 with HTML; only a real v3 map has sources and mappings.
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return doc, false
+	}
+	if len(doc.Sources) == 0 || doc.Mappings == "" {
+		return doc, false
+	}
+	return doc, true
+}
+
+// sourceMapFilePath maps a source entry such as webpack://app/./src/api.ts onto a path
+// under root, dropping scheme prefixes and any segment that could escape the tree.
+func sourceMapFilePath(root, source string, index int) string {
+	if i := strings.Index(source, "://"); i >= 0 {
+		source = source[i+3:]
+	}
+	if i := strings.IndexAny(source, "?#"); i >= 0 {
+		source = source[:i]
+	}
+	var parts []string
+	for _, segment := range strings.Split(strings.ReplaceAll(source, "\\", "/"), "/") {
+		segment = strings.TrimSpace(segment)
+		if segment == "" || segment == "." || segment == ".." {
+			continue
+		}
+		parts = append(parts, sanitizeFilename(segment))
+	}
+	if len(parts) > sourceMapMaxSegments {
+		parts = parts[len(parts)-sourceMapMaxSegments:]
+	}
+	if len(parts) == 0 {
+		parts = []string{fmt.Sprintf("source_%d.js", index)}
+	}
+	return filepath.Join(append([]string{root}, parts...)...)
+}
@@ -0,0 +1,767 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	techProfileMaxTargets    = 200
+	techProfileMaxCVEQueries = 40
+	techProfileMaxExploits   = 5
+	techProfileVersionBonus  = 0.15
+	// techProfileMinConfidence is the floor for a technology to drive nuclei tags and family choice.
+	techProfileMinConfidence = 0.5
+
+	techCategoryServer    = "server"
+	techCategoryLanguage  = "language"
+	techCategoryFramework = "framework"
+	techCategoryCMS       = "cms"
+	techCategoryApp       = "application"
+	techCategoryJSLibrary = "js_library"
+	techCategoryEdge      = "edge"
+	techCategoryService   = "service"
+	techCategoryOther     = "other"
+)
+
+type techEvidence struct {
+	Source     string  `json:"source"`
+	Detail     string  `json:"detail"`
+	Version    string  `json:"version,omitempty"`
+	Confidence float64 `json:"confidence"`
+}
+
+type techEntry struct {
+	Name        string         `json:"name"`
+	Category    string         `json:"category"`
+	Version     string         `json:"version,omitempty"`
+	AltVersions []string       `json:"alt_versions,omitempty"`
+	Confidence  float64        `json:"confidence"`
+	Evidence    []techEvidence `json:"evidence"`
+	CVEs        []string       `json:"cves,omitempty"`
+	Exploits    []string       `json:"exploits,omitempty"`
+}
+
+type techProfileRecord struct {
+	Timestamp    string      `json:"timestamp"`
+	Host         string      `json:"host"`
+	Technologies []techEntry `json:"technologies"`
+}
+
+type techSignature struct {
+	Category   string
+	NucleiTags []string
+}
+
+var (
+	techSourceConfidence = map[string]float64{
+		"httpx":      0.55,
+		"header":     0.7,
+		"cookie":     0.45,
+		"js_banner":  0.6,
+		"meta":       0.65,
+		"error_page": 0.6,
+		"nmap":       0.75,
+	}
+
+	techAliases = map[string]string{
+		"apache httpd":           "apache",
+		"apache http server":     "apache",
+		"httpd":                  "apache",
+		"microsoft-iis":          "iis",
+		"microsoft iis httpd":    "iis",
+		"microsoft iis":          "iis",
+		"apache tomcat":          "tomcat",
+		"apache-coyote":          "tomcat",
+		"node":                   "node.js",
+		"nodejs":                 "node.js",
+		"express.js":             "express",
+		"aspnet":                 "asp.net",
+		"asp.net mvc":            "asp.net",
+		"microsoft asp.net":      "asp.net",
+		"spring boot":            "spring",
+		"spring framework":       "spring",
+		"ruby on rails":          "rails",
+		"werkzeug":               "flask",
+		"openssh":                "openssh",
+		"postgres":               "postgresql",
+		"mongo":                  "mongodb",
+		"vue":                    "vue.js",
+		"vuejs":                  "vue.js",
+		"angularjs":              "angular",
+		"oracle weblogic server": "weblogic",
+		"wildfly":                "jboss",
+		"cloudflare":             "cloudflare",
+		"amazon cloudfront":      "cloudfront",
+	}
+
+	techCatalog = map[string]techSignature{
+		"nginx":         {Category: techCategoryServer, NucleiTags: []string{"nginx"}},
+		"openresty":     {Category: techCategoryServer, NucleiTags: []string{"nginx", "openresty"}},
+		"apache":        {Category: techCategoryServer, NucleiTags: []string{"apache"}},
+		"iis":           {Category: techCategoryServer, NucleiTags: []string{"iis", "microsoft"}},
+		"tomcat":        {Category: techCategoryServer, NucleiTags: []string{"tomcat", "apache"}},
+		"jetty":         {Category: techCategoryServer, NucleiTags: []string{"jetty"}},
+		"lighttpd":      {Category: techCategoryServer, NucleiTags: []string{"lighttpd"}},
+		"caddy":         {Category: techCategoryServer, NucleiTags: []string{"caddy"}},
+		"weblogic":      {Category: techCategoryServer, NucleiTags: []string{"weblogic", "oracle"}},
+		"jboss":         {Category: techCategoryServer, NucleiTags: []string{"jboss"}},
+		"php":           {Category: techCategoryLanguage, NucleiTags: []string{"php"}},
+		"asp.net":       {Category: techCategoryLanguage, NucleiTags: []string{"aspnet", "iis"}},
+		"java":          {Category: techCategoryLanguage, NucleiTags: []string{"java"}},
+		"node.js":       {Category: techCategoryLanguage, NucleiTags: []string{"nodejs"}},
+		"python":        {Category: techCategoryLanguage, NucleiTags: []string{"python"}},
+		"ruby":          {Category: techCategoryLanguage, NucleiTags: []string{"ruby"}},
+		"express":       {Category: techCategoryFramework, NucleiTags: []string{"express", "nodejs"}},
+		"laravel":       {Category: techCategoryFramework, NucleiTags: []string{"laravel", "php"}},
+		"symfony":       {Category: techCategoryFramework, NucleiTags: []string{"symfony", "php"}},
+		"codeigniter":   {Category: techCategoryFramework, NucleiTags: []string{"codeigniter", "php"}},
+		"django":        {Category: techCategoryFramework, NucleiTags: []string{"django", "python"}},
+		"flask":         {Category: techCategoryFramework, NucleiTags: []string{"flask", "werkzeug", "python"}},
+		"rails":         {Category: techCategoryFramework, NucleiTags: []string{"rails", "ruby"}},
+		"spring":        {Category: techCategoryFramework, NucleiTags: []string{"spring", "springboot", "java"}},
+		"struts":        {Category: techCategoryFramework, NucleiTags: []string{"struts", "apache"}},
+		"wordpress":     {Category: techCategoryCMS, NucleiTags: []string{"wordpress", "wp-plugin"}},
+		"drupal":        {Category: techCategoryCMS, NucleiTags: []string{"drupal"}},
+		"joomla":        {Category: techCategoryCMS, NucleiTags: []string{"joomla"}},
+		"magento":       {Category: techCategoryCMS, NucleiTags: []string{"magento"}},
+		"jenkins":       {Category: techCategoryApp, NucleiTags: []string{"jenkins"}},
+		"grafana":       {Category: techCategoryApp, NucleiTags: []string{"grafana"}},
+		"kibana":        {Category: techCategoryApp, NucleiTags: []string{"kibana"}},
+		"jira":          {Category: techCategoryApp, NucleiTags: []string{"jira", "atlassian"}},
+		"confluence":    {Category: techCategoryApp, NucleiTags: []string{"confluence", "atlassian"}},
+		"gitlab":        {Category: techCategoryApp, NucleiTags: []string{"gitlab"}},
+		"phpmyadmin":    {Category: techCategoryApp, NucleiTags: []string{"phpmyadmin"}},
+		"keycloak":      {Category: techCategoryApp, NucleiTags: []string{"keycloak"}},
+		"jquery":        {Category: techCategoryJSLibrary, NucleiTags: []string{"jquery"}},
+		"angular":       {Category: techCategoryJSLibrary},
+		"react":         {Category: techCategoryJSLibrary},
+		"vue.js":        {Category: techCategoryJSLibrary},
+		"bootstrap":     {Category: techCategoryJSLibrary},
+		"lodash":        {Category: techCategoryJSLibrary},
+		"cloudflare":    {Category: techCategoryEdge},
+		"cloudfront":    {Category: techCategoryEdge},
+		"akamai":        {Category: techCategoryEdge},
+		"fastly":        {Category: techCategoryEdge},
+		"varnish":       {Category: techCategoryEdge},
+		"openssh":       {Category: techCategoryService, NucleiTags: []string{"openssh"}},
+		"mysql":         {Category: techCategoryService, NucleiTags: []string{"mysql"}},
+		"postgresql":    {Category: techCategoryService, NucleiTags: []string{"postgres"}},
+		"mongodb":       {Category: techCategoryService, NucleiTags: []string{"mongodb"}},
+		"redis":         {Category: techCategoryService, NucleiTags: []string{"redis"}},
+		"elasticsearch": {Category: techCategoryService, NucleiTags: []string{"elasticsearch"}},
+		"openldap":      {Category: techCategoryService, NucleiTags: []string{"ldap"}},
+	}
+
+	techCookieHints = map[string]string{
+		"phpsessid":           "php",
+		"jsessionid":          "java",
+		"asp.net_sessionid":   "asp.net",
+		"aspsessionid":        "asp.net",
+		".aspxauth":           "asp.net",
+		"laravel_session":     "laravel",
+		"ci_session":          "codeigniter",
+		"connect.sid":         "express",
+		"csrftoken":           "django",
+		"sessionid":           "django",
+		"_rails_session":      "rails",
+		"wordpress_logged_in": "wordpress",
+		"wp-settings":         "wordpress",
+		"symfony":             "symfony",
+		"grafana_session":     "grafana",
+		"jenkins-timestamper": "jenkins",
+		"__cf_bm":             "cloudflare",
+	}
+
+	techHeaderNames = []string{"Server", "X-Powered-By", "X-AspNet-Version", "X-AspNetMvc-Version", "X-Generator", "X-Drupal-Cache", "X-Jenkins", "X-Runtime", "X-Varnish", "Via"}
+
+	techHeaderImplied = map[string]string{
+		"x-aspnet-version":    "asp.net",
+		"x-aspnetmvc-version": "asp.net",
+		"x-drupal-cache":      "drupal",
+		"x-jenkins":           "jenkins",
+		"x-runtime":           "rails",
+		"x-varnish":           "varnish",
+	}
+
+	techBannerPattern   = regexp.MustCompile(`(?i)([a-z][a-z0-9 .\-]*?)[/ ]v?(\d+(?:\.\d+){0,3})`)
+	techGeneratorRe     = regexp.MustCompile(`(?i)<meta[^>]+name=["']generator["'][^>]+content=["']([^"']+)["']`)
+	techScriptVersionRe = regexp.MustCompile(`(?i)/(jquery|angular|react|vue|bootstrap|lodash)(?:\.min)?[-.@/]v?(\d+\.\d+(?:\.\d+)?)`)
+	techInlineBannerRe  = regexp.MustCompile(`(?i)\b(jquery|bootstrap|lodash|vue\.js|angularjs)\s+v?(\d+\.\d+(?:\.\d+)?)`)
+	techErrorPatterns   = []struct {
+		name string
+		re   *regexp.Regexp
+	}{
+		{"tomcat", regexp.MustCompile(`(?i)apache tomcat/(\d+(?:\.\d+)*)`)},
+		{"nginx", regexp.MustCompile(`(?i)<center>nginx/?(\d+(?:\.\d+)*)?</center>`)},
+		{"apache", regexp.MustCompile(`(?i)apache/(\d+(?:\.\d+)*)[^<]*server at`)},
+		{"iis", regexp.MustCompile(`(?i)microsoft-iis/(\d+(?:\.\d+)*)`)},
+		{"spring", regexp.MustCompile(`(?i)whitelabel error page()`)},
+		{"django", regexp.MustCompile(`(?i)using the urlconf defined in|django version (\d+(?:\.\d+)*)?`)},
+		{"laravel", regexp.MustCompile(`(?i)laravel(?: v?(\d+(?:\.\d+)*))?`)},
+		{"flask", regexp.MustCompile(`(?i)werkzeug/(\d+(?:\.\d+)*)`)},
+		{"express", regexp.MustCompile(`(?i)<pre>cannot get /()`)},
+		{"asp.net", regexp.MustCompile(`(?i)asp\.net version:(\d+(?:\.\d+)*)`)},
+		{"jetty", regexp.MustCompile(`(?i)powered by jetty:// (\d+(?:\.\d+)*)`)},
+		{"php", regexp.MustCompile(`(?i)php (?:fatal error|warning|notice)()`)},
+	}
+	techVersionRe  = regexp.MustCompile(`\d+(?:\.\d+)+`)
+	techCVEPattern = regexp.MustCompile(`CVE-\d{4}-\d{4,7}`)
+
+	techNucleiBaselineTags = []string{"exposure", "misconfig", "takeover", "default-login", "panel"}
+)
+
+func (a *App) techProfilePath() string {
+	return filepath.Join(filepath.Dir(a.cfg.Lists.Domains), "recon", "tech_profile.jsonl")
+}
+
+func (a *App) runTechProfile(ctx context.Context) error {
+	baseDir := filepath.Dir(a.cfg.Lists.Domains)
+	if err := os.MkdirAll(filepath.Join(baseDir, "recon"), 0o755); err != nil {
+		return err
+	}
+
+	profile := make(map[string]map[string]*techEntry)
+	targetByHost := make(map[string]string)
+	for _, row := range readLiveWebserverRecords(filepath.Join(baseDir, "live-webservers.jsonl")) {
+		host := extractHostCandidate(row.URL)
+		if host == "" {
+			continue
+		}
+		if _, ok := targetByHost[host]; !ok {
+			targetByHost[host] = normalizeLiveTarget(row.URL)
+		}
+		for _, tech := range row.Technologies {
+			name, version := splitTechBanner(tech)
+			addTechEvidence(profile, host, name, "httpx", tech, version)
+		}
+		if server := strings.TrimSpace(row.WebServer); server != "" {
+			name, version := splitTechBanner(server)
+			addTechEvidence(profile, host, name, "httpx", "webserver:"+server, version)
+		}
+	}
+	for _, target := range normalizeHTTPSTargets(readSafeLines(a.httpListOrDefault(a.cfg.Lists.Domains))) {
+		host := extractHostCandidate(target)
+		if host == "" {
+			continue
+		}
+		if current, ok := targetByHost[host]; !ok || current == "" {
+			targetByHost[host] = target
+		}
+	}
+
+	hosts := make([]string, 0, len(targetByHost))
+	for host := range targetByHost {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	if len(hosts) > techProfileMaxTargets {
+		a.logger.Printf("%s: limiting hosts from %d to %d", StepTechProfile, len(hosts), techProfileMaxTargets)
+		hosts = hosts[:techProfileMaxTargets]
+	}
+
+	client := a.newHTTPClient(paramFuzzRequestTimeout, false)
+	probed := 0
+	for _, host := range hosts {
+		target := targetByHost[host]
+		if target == "" {
+			continue
+		}
+		if a.probeTechSignals(ctx, client, profile, host, target) {
+			probed++
+		}
+	}
+
+	nmapRows := a.mergeNmapTechEvidence(profile)
+	cveHits := a.matchTechCVEs(ctx, profile)
+	if err := a.writeTechProfile(profile); err != nil {
+		return err
+	}
+
+	techCount := 0
+	versioned := 0
+	for _, entries := range profile {
+		for _, entry := range entries {
+			techCount++
+			if entry.Version != "" {
+				versioned++
+			}
+		}
+	}
+	a.logger.Printf("%s: hosts=%d probed=%d technologies=%d versioned=%d nmap_rows=%d cve_matches=%d", StepTechProfile,
+		len(profile), probed, techCount, versioned, nmapRows, cveHits)
+	return nil
+}
+
+// refreshTechProfileFromNmap folds fresh nmap service versions into an existing tech profile.
+func (a *App) refreshTechProfileFromNmap(ctx context.Context) {
+	profile := a.loadTechProfile()
+	rows := a.mergeNmapTechEvidence(profile)
+	if rows == 0 {
+		return
+	}
+	cveHits := a.matchTechCVEs(ctx, profile)
+	if err := a.writeTechProfile(profile); err != nil {
+		a.logger.Printf("%s: tech profile refresh failed: %v", StepNmapEnrich, err)
+		return
+	}
+	a.logger.Printf("%s: tech profile refreshed with %d service row(s), cve_matches=%d", StepNmapEnrich, rows, cveHits)
+}
+
+func (a *App) probeTechSignals(ctx context.Context, client *http.Client, profile map[string]map[string]*techEntry, host, target string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 65536))
+	_ = resp.Body.Close()
+
+	for _, header := range techHeaderNames {
+		value := strings.TrimSpace(resp.Header.Get(header))
+		if value == "" {
+			continue
+		}
+		detail := strings.ToLower(header) + ": " + value
+		if implied, ok := techHeaderImplied[strings.ToLower(header)]; ok {
+			addTechEvidence(profile, host, implied, "header", detail, techVersionRe.FindString(value))
+			continue
+		}
+		for _, part := range splitHeaderBanners(value) {
+			name, version := splitTechBanner(part)
+			addTechEvidence(profile, host, name, "header", detail, version)
+		}
+	}
+	for _, cookie := range resp.Cookies() {
+		lower := strings.ToLower(cookie.Name)
+		for prefix, tech := range techCookieHints {
+			if strings.HasPrefix(lower, prefix) {
+				addTechEvidence(profile, host, tech, "cookie", "cookie:"+cookie.Name, "")
+				break
+			}
+		}
+	}
+
+	text := string(body)
+	if match := techGeneratorRe.FindStringSubmatch(text); len(match) > 1 {
+		name, version := splitTechBanner(match[1])
+		addTechEvidence(profile, host, name, "meta", "generator:"+match[1], version)
+	}
+	for _, match := range techScriptVersionRe.FindAllStringSubmatch(text, 20) {
+		addTechEvidence(profile, host, match[1], "js_banner", match[0], match[2])
+	}
+	for _, match := range techInlineBannerRe.FindAllStringSubmatch(text, 20) {
+		addTechEvidence(profile, host, match[1], "js_banner", match[0], match[2])
+	}
+
+	missing := strings.TrimRight(target, "/") + fmt.Sprintf("/bflow-missing-%d.aspx", time.Now().UnixNano()%1000000)
+	if errReq, err := http.NewRequestWithContext(ctx, http.MethodGet, missing, nil); err == nil {
+		if errResp, err := client.Do(errReq); err == nil {
+			errBody, _ := io.ReadAll(io.LimitReader(errResp.Body, 32768))
+			_ = errResp.Body.Close()
+			errText := string(errBody)
+			for _, pattern := range techErrorPatterns {
+				match := pattern.re.FindStringSubmatch(errText)
+				if match == nil {
+					continue
+				}
+				version := ""
+				if len(match) > 1 {
+					version = match[1]
+				}
+				addTechEvidence(profile, host, pattern.name, "error_page", fmt.Sprintf("status=%d match=%q", errResp.StatusCode, truncateTechDetail(match[0])), version)
+			}
+		}
+	}
+	return true
+}
+
+func (a *App) mergeNmapTechEvidence(profile map[string]map[string]*techEntry) int {
+	gnmapPath := filepath.Join(a.fuzzingBaseDir(), "nmap", "scan.gnmap")
+	rows := parseNmapGNMAP(gnmapPath)
+	if len(rows) == 0 {
+		return 0
+	}
+	aliases := nmapHostAliases(gnmapPath)
+	merged := 0
+	for _, row := range rows {
+		if row.Info == "" && row.Service == "" {
+			continue
+		}
+		name, version := splitTechBanner(row.Info)
+		if name == "" {
+			name = row.Service
+		}
+		detail := fmt.Sprintf("%s/%s %s %s", row.Port, row.Proto, row.Service, row.Info)
+		hosts := append([]string{row.Host}, aliases[row.Host]...)
+		for _, host := range hosts {
+			addTechEvidence(profile, strings.ToLower(host), name, "nmap", strings.TrimSpace(detail), version)
+		}
+		merged++
+	}
+	return merged
+}
+
+func nmapHostAliases(path string) map[string][]string {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	re := regexp.MustCompile(`Host:\s+(\S+)\s+\(([^)]*)\)`)
+	out := make(map[string][]string)
+	for _, line := range strings.Split(string(raw), "\n") {
+		match := re.FindStringSubmatch(line)
+		if len(match) < 3 || strings.TrimSpace(match[2]) == "" {
+			continue
+		}
+		if !containsAnyString(out[match[1]], match[2]) {
+			out[match[1]] = append(out[match[1]], strings.TrimSpace(match[2]))
+		}
+	}
+	return out
+}
+
+func (a *App) matchTechCVEs(ctx context.Context, profile map[string]map[string]*techEntry) int {
+	if _, err := exec.LookPath("searchsploit"); err != nil {
+		return 0
+	}
+	type lookup struct {
+		cves     []string
+		exploits []string
+	}
+	cache := make(map[string]lookup)
+	queries := 0
+	matches := 0
+	for _, host := range sortedTechHosts(profile) {
+		for _, entry := range profile[host] {
+			if entry.Version == "" || entry.Category == techCategoryEdge {
+				continue
+			}
+			key := entry.Name + " " + entry.Version
+			result, ok := cache[key]
+			if !ok {
+				if queries >= techProfileMaxCVEQueries {
+					continue
+				}
+				queries++
+				stdout, err := a.runCommandCapture(ctx, "searchsploit", "-j", entry.Name, entry.Version)
+				if err != nil {
+					a.logger.Printf("%s: searchsploit lookup failed for %q: %v", StepTechProfile, key, err)
+				}
+				result = lookup{}
+				result.cves, result.exploits = parseSearchsploitJSON(stdout)
+				cache[key] = result
+			}
+			entry.CVEs = result.cves
+			entry.Exploits = result.exploits
+			if len(result.cves) > 0 || len(result.exploits) > 0 {
+				matches++
+			}
+		}
+	}
+	return matches
+}
+
+func parseSearchsploitJSON(raw string) ([]string, []string) {
+	var payload struct {
+		Results []struct {
+			Title string `json:"Title"`
+			EDBID string `json:"EDB-ID"`
+			Codes string `json:"Codes"`
+		} `json:"RESULTS_EXPLOIT"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(raw)), &payload); err != nil {
+		return nil, nil
+	}
+	var cves, exploits []string
+	for _, row := range payload.Results {
+		cves = append(cves, techCVEPattern.FindAllString(row.Codes+" "+row.Title, -1)...)
+		if len(exploits) < techProfileMaxExploits && row.Title != "" {
+			exploits = append(exploits, fmt.Sprintf("EDB-%s: %s", row.EDBID, row.Title))
+		}
+	}
+	return unique(cves), exploits
+}
+
+func addTechEvidence(profile map[string]map[string]*techEntry, host, rawName, source, detail, version string) {
+	name := normalizeTechName(rawName)
+	host = strings.ToLower(strings.TrimSpace(host))
+	if name == "" || host == "" {
+		return
+	}
+	version = strings.Trim(strings.TrimSpace(version), ".")
+	confidence := techSourceConfidence[source]
+	if version != "" {
+		confidence = math.Min(0.95, confidence+techProfileVersionBonus)
+	}
+	if profile[host] == nil {
+		profile[host] = make(map[string]*techEntry)
+	}
+	entry, ok := profile[host][name]
+	if !ok {
+		category := techCategoryOther
+		if sig, known := techCatalog[name]; known {
+			category = sig.Category
+		}
+		entry = &techEntry{Name: name, Category: category}
+		profile[host][name] = entry
+	}
+	for _, existing := range entry.Evidence {
+		if existing.Source == source && existing.Detail == detail {
+			return
+		}
+	}
+	entry.Evidence = append(entry.Evidence, techEvidence{Source: source, Detail: detail, Version: version, Confidence: confidence})
+	scoreTechEntry(entry)
+}
+
+// scoreTechEntry combines independent evidence as 1-Π(1-c), counting each source once
+// at its strongest, and picks the version backed by the most confident evidence.
+func scoreTechEntry(entry *techEntry) {
+	bestBySource := make(map[string]float64)
+	versionScore := make(map[string]float64)
+	for _, ev := range entry.Evidence {
+		if ev.Confidence > bestBySource[ev.Source] {
+			bestBySource[ev.Source] = ev.Confidence
+		}
+		if ev.Version != "" {
+			versionScore[ev.Version] += ev.Confidence
+		}
+	}
+	miss := 1.0
+	for _, c := range bestBySource {
+		miss *= 1 - c
+	}
+	entry.Confidence = math.Round((1-miss)*100) / 100
+	if entry.Confidence > 0.99 {
+		entry.Confidence = 0.99
+	}
+
+	versions := make([]string, 0, len(versionScore))
+	for v := range versionScore {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		if versionScore[versions[i]] != versionScore[versions[j]] {
+			return versionScore[versions[i]] > versionScore[versions[j]]
+		}
+		return len(versions[i]) > len(versions[j])
+	})
+	entry.Version = ""
+	entry.AltVersions = nil
+	if len(versions) > 0 {
+		entry.Version = versions[0]
+		entry.AltVersions = versions[1:]
+	}
+}
+
+func normalizeTechName(raw string) string {
+	name := strings.ToLower(strings.TrimSpace(raw))
+	name = strings.Trim(name, " \t\"'()[];,")
+	if name == "" || len(name) > 48 {
+		return ""
+	}
+	if alias, ok := techAliases[name]; ok {
+		return alias
+	}
+	for alias, canonical := range techAliases {
+		if strings.HasPrefix(name, alias+" ") {
+			return canonical
+		}
+	}
+	if _, ok := techCatalog[name]; ok {
+		return name
+	}
+	if first := strings.Fields(name); len(first) > 0 {
+		if _, ok := techCatalog[first[0]]; ok {
+			return first[0]
+		}
+	}
+	return name
+}
+
+// splitTechBanner splits banners like "nginx/1.18.0 (Ubuntu)", "PHP:7.4.3" or
+// "Apache Tomcat 9.0.41" into a name and version.
+func splitTechBanner(raw string) (string, string) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", ""
+	}
+	if idx := strings.Index(raw, ":"); idx > 0 && !strings.Contains(raw[:idx], " ") {
+		return raw[:idx], strings.TrimSpace(raw[idx+1:])
+	}
+	if match := techBannerPattern.FindStringSubmatch(raw); len(match) > 2 && strings.Index(raw, match[0]) == 0 {
+		return strings.TrimSpace(match[1]), match[2]
+	}
+	if idx := strings.IndexAny(raw, "/("); idx > 0 {
+		return strings.TrimSpace(raw[:idx]), ""
+	}
+	return raw, ""
+}
+
+func splitHeaderBanners(value string) []string {
+	var out []string
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		// "Apache/2.4.41 (Ubuntu) OpenSSL/1.1.1f" carries several products separated by spaces.
+		found := false
+		for _, token := range strings.Fields(part) {
+			if strings.Contains(token, "/") && !strings.HasPrefix(token, "(") {
+				out = append(out, token)
+				found = true
+			}
+		}
+		if !found {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func truncateTechDetail(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) > 120 {
+		return value[:120]
+	}
+	return value
+}
+
+func sortedTechHosts(profile map[string]map[string]*techEntry) []string {
+	hosts := make([]string, 0, len(profile))
+	for host := range profile {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+func (a *App) writeTechProfile(profile map[string]map[string]*techEntry) error {
+	f, err := os.Create(a.techProfilePath())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	defer w.Flush()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, host := range sortedTechHosts(profile) {
+		entries := make([]techEntry, 0, len(profile[host]))
+		for _, entry := range profile[host] {
+			entries = append(entries, *entry)
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].Confidence != entries[j].Confidence {
+				return entries[i].Confidence > entries[j].Confidence
+			}
+			return entries[i].Name < entries[j].Name
+		})
+		_ = writeJSONLine(w, techProfileRecord{Timestamp: now, Host: host, Technologies: entries})
+	}
+	return nil
+}
+
+func (a *App) loadTechProfile() map[string]map[string]*techEntry {
+	profile := make(map[string]map[string]*techEntry)
+	for _, line := range readSafeLines(a.techProfilePath()) {
+		var rec techProfileRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			continue
+		}
+		host := strings.ToLower(strings.TrimSpace(rec.Host))
+		if host == "" {
+			continue
+		}
+		profile[host] = make(map[string]*techEntry)
+		for i := range rec.Technologies {
+			entry := rec.Technologies[i]
+			profile[host][entry.Name] = &entry
+		}
+	}
+	return profile
+}
+
+// techNucleiTags returns the nuclei tags implied by confidently detected technologies.
+func (a *App) techNucleiTags() []string {
+	var tags []string
+	for _, entries := range a.loadTechProfile() {
+		for _, entry := range entries {
+			if entry.Confidence < techProfileMinConfidence {
+				continue
+			}
+			tags = append(tags, techCatalog[entry.Name].NucleiTags...)
+		}
+	}
+	return unique(tags)
+}
+
+// selectFamiliesForHost aims payload families at the host's confident stack: families
+// whose pack tech list matches it go first, the rest keep pack order behind them. A
+// stack-specific family is skipped only when it conflicts with a confidently known
+// backend language, naming languages none of which the host runs; servers, edges and
+// services are often only a front and rule nothing out. Hosts without a profile get
+// every family.
+func selectFamiliesForHost(families []injectionFamilyConfig, profile map[string]map[string]*techEntry, host string) ([]injectionFamilyConfig, []string) {
+	var stack, languages []string
+	for name, entry := range profile[strings.ToLower(strings.TrimSpace(host))] {
+		if entry.Confidence < techProfileMinConfidence {
+			continue
+		}
+		switch entry.Category {
+		case techCategoryServer, techCategoryLanguage, techCategoryFramework, techCategoryCMS, techCategoryService:
+			stack = append(stack, name)
+		}
+		if entry.Category == techCategoryLanguage {
+			languages = append(languages, name)
+		}
+	}
+	if len(stack) == 0 {
+		return families, nil
+	}
+	var matched, rest []injectionFamilyConfig
+	var skipped []string
+	for _, family := range families {
+		if len(family.Tech) > 0 && containsAny(stack, family.Tech...) {
+			matched = append(matched, family)
+			continue
+		}
+		if techFamilyConflicts(family, languages) {
+			skipped = append(skipped, family.Name)
+			continue
+		}
+		rest = append(rest, family)
+	}
+	return append(matched, rest...), skipped
+}
+
+func techFamilyConflicts(family injectionFamilyConfig, languages []string) bool {
+	if len(languages) == 0 {
+		return false
+	}
+	named := false
+	for _, tech := range family.Tech {
+		if techCatalog[strings.ToLower(tech)].Category == techCategoryLanguage {
+			named = true
+			break
+		}
+	}
+	return named && !containsAny(languages, family.Tech...)
+}
@@ -0,0 +1,299 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const urlCorpusMaxProbe = 20000
+
+// URLCorpusRecord is one line of recon/url_corpus.jsonl. FirstSeen and LastSeen are the
+// url-corpus runs that first and most recently found the URL; LastStatus and ContentType
+// come from the latest httpx probe, taken at CheckedAt.
+type URLCorpusRecord struct {
+	URL         string   `json:"url"`
+	Host        string   `json:"host"`
+	Sources     []string `json:"sources"`
+	FirstSeen   string   `json:"first_seen"`
+	LastSeen    string   `json:"last_seen"`
+	LastStatus  int      `json:"last_status,omitempty"`
+	ContentType string   `json:"content_type,omitempty"`
+	CheckedAt   string   `json:"checked_at,omitempty"`
+	Params      []string `json:"params,omitempty"`
+}
+
+// URLCorpusFilter selects corpus records by source and last probe status. Statuses takes
+// exact codes ("404") and classes ("2xx"); MaxAge, when set, treats older probes as unknown.
+type URLCorpusFilter struct {
+	Sources  []string
+	Statuses []string
+	MaxAge   time.Duration
+}
+
+func (f URLCorpusFilter) Active() bool {
+	return len(f.Sources) > 0 || len(f.Statuses) > 0
+}
+
+func (f URLCorpusFilter) Match(rec URLCorpusRecord, now time.Time) bool {
+	if len(f.Sources) > 0 {
+		found := false
+		for _, source := range rec.Sources {
+			if containsAnyString(f.Sources, strings.ToLower(source)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.Statuses) == 0 {
+		return true
+	}
+	if rec.LastStatus == 0 {
+		return false
+	}
+	if f.MaxAge > 0 {
+		checked, err := time.Parse(time.RFC3339, rec.CheckedAt)
+		if err != nil || now.Sub(checked) > f.MaxAge {
+			return false
+		}
+	}
+	code := strconv.Itoa(rec.LastStatus)
+	class := code[:1] + "xx"
+	for _, want := range f.Statuses {
+		want = strings.ToLower(strings.TrimSpace(want))
+		if want == code || want == class {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *App) urlCorpusPath() string {
+	return filepath.Join(filepath.Dir(a.cfg.Lists.Domains), "recon", "url_corpus.jsonl")
+}
+
+func (a *App) urlCorpusFilter() URLCorpusFilter {
+	f := URLCorpusFilter{MaxAge: time.Duration(a.cfg.URLCorpus.StatusMaxAgeHours) * time.Hour}
+	for _, source := range a.cfg.URLCorpus.Sources {
+		if source = strings.ToLower(strings.TrimSpace(source)); source != "" {
+			f.Sources = append(f.Sources, source)
+		}
+	}
+	for _, status := range a.cfg.URLCorpus.Statuses {
+		if status = strings.ToLower(strings.TrimSpace(status)); status != "" {
+			f.Statuses = append(f.Statuses, status)
+		}
+	}
+	return f
+}
+
+// updateURLCorpus folds this run's URLs (url -> sources) into url_corpus.jsonl and probes
+// them with httpx. URLs no longer found keep their record so first_seen survives gaps.
+func (a *App) updateURLCorpus(ctx context.Context, found map[string]map[string]struct{}, params map[string][]string) error {
+	path := a.urlCorpusPath()
+	records := readURLCorpus(path)
+	now := time.Now().UTC().Format(time.RFC3339)
+	current := make([]string, 0, len(found))
+	for u, sources := range found {
+		rec, ok := records[u]
+		if !ok {
+			rec = URLCorpusRecord{URL: u, Host: extractHostCandidate(u), FirstSeen: now}
+		}
+		rec.LastSeen = now
+		for source := range sources {
+			rec.Sources = append(rec.Sources, source)
+		}
+		rec.Sources = unique(rec.Sources)
+		sort.Strings(rec.Sources)
+		rec.Params = unique(append(append(rec.Params, urlParamNames(u)...), params[u]...))
+		sort.Strings(rec.Params)
+		records[u] = rec
+		current = append(current, u)
+	}
+
+	probed := a.probeURLCorpus(ctx, records, current)
+	if err := writeURLCorpus(path, records); err != nil {
+		return err
+	}
+	a.logger.Printf("%s: indexed=%d current=%d probed=%d (%s)", StepURLCorpus, len(records), len(current), probed, path)
+	return nil
+}
+
+// mergeURLCorpusSource records URLs that reach all_urls.txt after url-corpus has run
+// (js-intel, request imports) so source filters see them in the same run.
+func (a *App) mergeURLCorpusSource(source string, urls []string) error {
+	if len(urls) == 0 {
+		return nil
+	}
+	path := a.urlCorpusPath()
+	records := readURLCorpus(path)
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, raw := range urls {
+		u := normalizeFFUFHitURL(raw)
+		if u == "" {
+			continue
+		}
+		rec, ok := records[u]
+		if !ok {
+			rec = URLCorpusRecord{URL: u, Host: extractHostCandidate(u), FirstSeen: now, Params: urlParamNames(u)}
+		}
+		rec.LastSeen = now
+		rec.Sources = unique(append(rec.Sources, source))
+		sort.Strings(rec.Sources)
+		records[u] = rec
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return writeURLCorpus(path, records)
+}
+
+// probeURLCorpus refreshes status and content type for up to urlCorpusMaxProbe URLs,
+// never-checked and longest-unchecked first. URLs already probed are skipped unless
+// url_corpus.probe_all is set or their probe is older than status_max_age_hours.
+// Without httpx, previous results are kept.
+func (a *App) probeURLCorpus(ctx context.Context, records map[string]URLCorpusRecord, urls []string) int {
+	if !a.cfg.URLCorpus.ProbeAll {
+		maxAge := time.Duration(a.cfg.URLCorpus.StatusMaxAgeHours) * time.Hour
+		now := time.Now()
+		var due []string
+		for _, u := range urls {
+			checked, err := time.Parse(time.RFC3339, records[u].CheckedAt)
+			if err != nil || (maxAge > 0 && now.Sub(checked) > maxAge) {
+				due = append(due, u)
+			}
+		}
+		urls = due
+	}
+	if len(urls) == 0 {
+		return 0
+	}
+	if _, err := exec.LookPath("httpx"); err != nil {
+		a.logger.Printf("%s: httpx not found; keeping previous status codes", StepURLCorpus)
+		return 0
+	}
+	sort.SliceStable(urls, func(i, j int) bool {
+		return records[urls[i]].CheckedAt < records[urls[j]].CheckedAt
+	})
+	if len(urls) > urlCorpusMaxProbe {
+		a.logger.Printf("%s: limiting probes from %d to %d urls", StepURLCorpus, len(urls), urlCorpusMaxProbe)
+		urls = urls[:urlCorpusMaxProbe]
+	}
+	stdout, err := a.runCommandCaptureWithInput(ctx, strings.Join(urls, "\n")+"\n", "httpx", "-silent", "-json", "-status-code", "-content-type", "-no-color")
+	if err != nil {
+		a.logger.Printf("%s: httpx probe failed: %v", StepURLCorpus, err)
+		return 0
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	probed := 0
+	for _, line := range strings.Split(stdout, "\n") {
+		var row map[string]any
+		if err := json.Unmarshal([]byte(strings.TrimSpace(line)), &row); err != nil {
+			continue
+		}
+		key := ""
+		for _, candidate := range []string{asString(row["input"]), asString(row["url"])} {
+			if _, ok := records[candidate]; ok {
+				key = candidate
+				break
+			}
+		}
+		if key == "" {
+			continue
+		}
+		rec := records[key]
+		rec.LastStatus = asInt(row["status_code"])
+		rec.ContentType = strings.TrimSpace(strings.SplitN(asString(row["content_type"]), ";", 2)[0])
+		rec.CheckedAt = now
+		records[key] = rec
+		probed++
+	}
+	return probed
+}
+
+// filterByURLCorpus applies the url_corpus settings from flow.yaml to endpoints taken
+// from all_urls.txt. URLs missing from the index count as unknown and are dropped.
+func (a *App) filterByURLCorpus(endpoints []string) []string {
+	filter := a.urlCorpusFilter()
+	if !filter.Active() || len(endpoints) == 0 {
+		return endpoints
+	}
+	index := make(map[string]URLCorpusRecord)
+	for u, rec := range readURLCorpus(a.urlCorpusPath()) {
+		index[strings.TrimRight(u, "/")] = rec
+	}
+	now := time.Now()
+	var out []string
+	for _, endpoint := range endpoints {
+		if rec, ok := index[strings.TrimRight(endpoint, "/")]; ok && filter.Match(rec, now) {
+			out = append(out, endpoint)
+		}
+	}
+	a.logger.Printf("url corpus filter (sources=%s statuses=%s): kept %d of %d endpoint(s)", strings.Join(filter.Sources, ","), strings.Join(filter.Statuses, ","), len(out), len(endpoints))
+	return out
+}
+
+func urlParamNames(raw string) []string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for key := range parsed.Query() {
+		if name := normalizeParamName(key); name != "" {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+func readURLCorpus(path string) map[string]URLCorpusRecord {
+	out := make(map[string]URLCorpusRecord)
+	for _, line := range readSafeLines(path) {
+		var rec URLCorpusRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil || rec.URL == "" {
+			continue
+		}
+		out[rec.URL] = rec
+	}
+	return out
+}
+
+func writeURLCorpus(path string, records map[string]URLCorpusRecord) error {
+	keys := make([]string, 0, len(records))
+	for u := range records {
+		keys = append(keys, u)
+	}
+	sort.Strings(keys)
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, u := range keys {
+		if err := writeJSONLine(w, records[u]); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
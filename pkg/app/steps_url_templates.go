@@ -0,0 +1,210 @@
+package app
+
+import (
+	"bufio"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	urlTemplateSamplesPerCluster = 2
+	urlTemplateMaxExamples       = 5
+)
+
+type urlTemplateRecord struct {
+	Timestamp       string   `json:"timestamp"`
+	Method          string   `json:"method"`
+	Host            string   `json:"host"`
+	Template        string   `json:"template"`
+	Params          []string `json:"params,omitempty"`
+	Count           int      `json:"count"`
+	Representatives []string `json:"representatives"`
+	Examples        []string `json:"examples,omitempty"`
+}
+
+type urlTemplateCluster struct {
+	method   string
+	host     string
+	template string
+	params   []string
+	members  []string
+}
+
+var (
+	urlTemplateIntRe    = regexp.MustCompile(`^\d+$`)
+	urlTemplateUUIDRe   = regexp.MustCompile(`(?i)^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	urlTemplateHashRe   = regexp.MustCompile(`(?i)^[0-9a-f]{16,}$`)
+	urlTemplateDateRe   = regexp.MustCompile(`^(?:19|20)\d{2}(?:[-_/]?(?:0[1-9]|1[0-2]))(?:[-_/]?(?:0[1-9]|[12]\d|3[01]))?$`)
+	urlTemplateLocaleRe = regexp.MustCompile(`^[a-z]{2}[-_][a-zA-Z]{2}$`)
+	urlTemplateSuffixRe = regexp.MustCompile(`^(.*[-_.])(\d+)$`)
+	urlTemplateTokenRe  = regexp.MustCompile(`^[A-Za-z0-9_\-]{24,}$`)
+
+	// urlTemplateLanguages are only treated as locale placeholders in the first path segment,
+	// where short words like "js" or "ui" are rare.
+	urlTemplateLanguages = map[string]struct{}{
+		"ar": {}, "cs": {}, "da": {}, "de": {}, "el": {}, "en": {}, "es": {}, "fi": {}, "fr": {}, "he": {}, "hu": {}, "id": {},
+		"it": {}, "ja": {}, "ko": {}, "nl": {}, "no": {}, "pl": {}, "pt": {}, "ro": {}, "ru": {}, "sv": {}, "th": {}, "tr": {},
+		"uk": {}, "vi": {}, "zh": {},
+	}
+)
+
+func (a *App) runURLTemplates() error {
+	reconDir := filepath.Join(filepath.Dir(a.cfg.Lists.Domains), "recon")
+	if err := os.MkdirAll(reconDir, 0o755); err != nil {
+		return err
+	}
+	var urls []string
+	for _, line := range readSafeLines(filepath.Join(reconDir, "all_urls.txt")) {
+		if u := normalizeFFUFHitURL(line); u != "" {
+			urls = append(urls, strings.TrimRight(u, "/"))
+		}
+	}
+	clusters := clusterURLTemplates(urls)
+
+	f, err := os.Create(filepath.Join(reconDir, "url_templates.jsonl"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	defer w.Flush()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	representatives := 0
+	collapsed := 0
+	for _, cluster := range clusters {
+		reps := cluster.representatives()
+		representatives += len(reps)
+		if len(cluster.members) > 1 {
+			collapsed++
+		}
+		examples := cluster.members
+		if len(examples) > urlTemplateMaxExamples {
+			examples = examples[:urlTemplateMaxExamples]
+		}
+		_ = writeJSONLine(w, urlTemplateRecord{
+			Timestamp:       now,
+			Method:          cluster.method,
+			Host:            cluster.host,
+			Template:        cluster.template,
+			Params:          cluster.params,
+			Count:           len(cluster.members),
+			Representatives: reps,
+			Examples:        examples,
+		})
+	}
+	a.logger.Printf("%s: urls=%d templates=%d multi_member=%d representatives=%d", StepURLTemplates, len(urls), len(clusters), collapsed, representatives)
+	return nil
+}
+
+// clusterURLTemplates groups URLs by method, host, learned path template and parameter-name set.
+// The corpus only carries URLs, so every member is a GET.
+func clusterURLTemplates(urls []string) []*urlTemplateCluster {
+	byKey := make(map[string]*urlTemplateCluster)
+	var keys []string
+	for _, raw := range urls {
+		parsed, err := url.Parse(raw)
+		if err != nil || parsed.Host == "" {
+			continue
+		}
+		template := urlPathTemplate(parsed.Path)
+		var params []string
+		for key := range parsed.Query() {
+			params = append(params, key)
+		}
+		sort.Strings(params)
+		host := strings.ToLower(parsed.Host)
+		key := "GET " + host + template + "?" + strings.Join(params, "&")
+		cluster, ok := byKey[key]
+		if !ok {
+			cluster = &urlTemplateCluster{method: "GET", host: host, template: template, params: params}
+			byKey[key] = cluster
+			keys = append(keys, key)
+		}
+		cluster.members = append(cluster.members, raw)
+	}
+	sort.Strings(keys)
+	out := make([]*urlTemplateCluster, 0, len(keys))
+	for _, key := range keys {
+		cluster := byKey[key]
+		cluster.members = unique(cluster.members)
+		out = append(out, cluster)
+	}
+	return out
+}
+
+// representatives picks the shortest members first so the sample favours canonical URLs
+// over long tracking-parameter variants.
+func (c *urlTemplateCluster) representatives() []string {
+	members := append([]string(nil), c.members...)
+	sort.SliceStable(members, func(i, j int) bool {
+		if len(members[i]) != len(members[j]) {
+			return len(members[i]) < len(members[j])
+		}
+		return members[i] < members[j]
+	})
+	if len(members) > urlTemplateSamplesPerCluster {
+		members = members[:urlTemplateSamplesPerCluster]
+	}
+	return members
+}
+
+func urlPathTemplate(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = urlSegmentTemplate(segment, i == 0 && len(segments) > 1)
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+func urlSegmentTemplate(segment string, first bool) string {
+	if segment == "" {
+		return segment
+	}
+	lower := strings.ToLower(segment)
+	switch {
+	case urlTemplateDateRe.MatchString(segment):
+		return "{date}"
+	case urlTemplateIntRe.MatchString(segment):
+		return "{int}"
+	case urlTemplateUUIDRe.MatchString(segment):
+		return "{uuid}"
+	case urlTemplateHashRe.MatchString(segment):
+		return "{hash}"
+	case urlTemplateLocaleRe.MatchString(segment):
+		return "{locale}"
+	}
+	if first {
+		if _, ok := urlTemplateLanguages[lower]; ok {
+			return "{locale}"
+		}
+	}
+	if urlTemplateTokenRe.MatchString(segment) && strings.ContainsAny(segment, "0123456789") && strings.IndexFunc(segment, isASCIILetter) >= 0 {
+		return "{token}"
+	}
+	if match := urlTemplateSuffixRe.FindStringSubmatch(segment); len(match) == 3 {
+		return match[1] + "{int}"
+	}
+	return segment
+}
+
+func isASCIILetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// dedupeByURLTemplate keeps a representative sample per template cluster. It re-clusters
+// the already-filtered endpoint list so representatives are never hosts or URLs that
+// scope, role or cluster filtering dropped.
+func dedupeByURLTemplate(endpoints []string) []string {
+	var out []string
+	for _, cluster := range clusterURLTemplates(endpoints) {
+		out = append(out, cluster.representatives()...)
+	}
+	sort.Strings(out)
+	return out
+}
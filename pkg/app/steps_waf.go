@@ -0,0 +1,330 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	wafDetectMaxTargets = 200
+	// wafProtectedHostDelay replaces paramFuzzHostDelay for hosts behind a WAF.
+	wafProtectedHostDelay = 1500 * time.Millisecond
+	wafProbeValue         = `<script>alert(1)</script>' OR '1'='1' -- ../../../../etc/passwd`
+)
+
+type wafHostState struct {
+	Timestamp      string   `json:"timestamp"`
+	Host           string   `json:"host"`
+	URL            string   `json:"url"`
+	Protected      bool     `json:"protected"`
+	Vendors        []string `json:"vendors,omitempty"`
+	Signals        []string `json:"signals,omitempty"`
+	BaselineStatus int      `json:"baseline_status,omitempty"`
+	ProbeStatus    int      `json:"probe_status,omitempty"`
+	ProbeBlocked   bool     `json:"probe_blocked"`
+	DelayMS        int64    `json:"delay_ms"`
+}
+
+type wafSignature struct {
+	vendor  string
+	headers []string
+	cookies []string
+	servers []string
+	bodies  []string
+}
+
+var (
+	wafSignatures = []wafSignature{
+		{vendor: "cloudflare", headers: []string{"cf-ray", "cf-mitigated"}, cookies: []string{"__cf_bm", "cf_clearance", "__cfduid"}, servers: []string{"cloudflare"},
+			bodies: []string{"attention required! | cloudflare", "cf-error-details", "cloudflare ray id", "/cdn-cgi/challenge-platform"}},
+		{vendor: "akamai", headers: []string{"akamai-grn", "x-akamai-transformed"}, cookies: []string{"ak_bmsc", "bm_sz", "_abck"}, servers: []string{"akamaighost"},
+			bodies: []string{"access denied</h1>", "you don't have permission to access", "reference&#32;&#35;"}},
+		{vendor: "aws_waf", headers: []string{"x-amzn-waf-action"}, cookies: []string{"aws-waf-token"}, servers: []string{"awselb"},
+			bodies: []string{"request blocked.", "generated by cloudfront", "the request could not be satisfied"}},
+		{vendor: "imperva", headers: []string{"x-iinfo"}, cookies: []string{"incap_ses_", "visid_incap_", "nlbi_"},
+			bodies: []string{"incapsula incident id", "request unsuccessful. incapsula", "_incapsula_resource"}},
+		{vendor: "sucuri", headers: []string{"x-sucuri-id", "x-sucuri-cache"}, servers: []string{"sucuri", "cloudproxy"},
+			bodies: []string{"sucuri website firewall", "access denied - sucuri"}},
+		{vendor: "f5_bigip", cookies: []string{"bigipserver", "ts01", "f5_cspm"}, servers: []string{"bigip", "big-ip"},
+			bodies: []string{"the requested url was rejected. please consult with your administrator"}},
+		{vendor: "fortiweb", cookies: []string{"fortiwafsid"}, servers: []string{"fortiweb"},
+			bodies: []string{"fortigate application control", "web page blocked", ".fgd_icon"}},
+		{vendor: "barracuda", cookies: []string{"barra_counter_session", "bni__barracuda_lb_cookie"}, servers: []string{"barracuda"},
+			bodies: []string{"barracuda web application firewall"}},
+		{vendor: "modsecurity", servers: []string{"mod_security", "modsecurity"},
+			bodies: []string{"mod_security", "modsecurity", "this error was generated by mod_security", "not acceptable!"}},
+		{vendor: "wordfence", bodies: []string{"generated by wordfence", "your access to this site has been limited"}},
+		{vendor: "reblaze", cookies: []string{"rbzid"}, servers: []string{"reblaze"}, bodies: []string{"access denied (403)", "reblaze"}},
+		{vendor: "azure_front_door", headers: []string{"x-azure-ref", "x-msedge-ref"}, bodies: []string{"the request is blocked."}},
+		{vendor: "fastly", headers: []string{"x-fastly-request-id"}, servers: []string{"fastly"}},
+		{vendor: "stackpath", headers: []string{"x-sp-url", "x-sp-waf"}, bodies: []string{"stackpath"}},
+	}
+	wafBlockStatuses = map[int]struct{}{403: {}, 406: {}, 419: {}, 429: {}, 501: {}, 503: {}, 999: {}}
+)
+
+func (a *App) runWAFDetection(ctx context.Context) error {
+	baseDir := filepath.Dir(a.cfg.Lists.Domains)
+	reconDir := filepath.Join(baseDir, "recon")
+	if err := os.MkdirAll(reconDir, 0o755); err != nil {
+		return err
+	}
+
+	targetByHost := make(map[string]string)
+	for _, target := range unique(normalizeHTTPSTargets(readSafeLines(a.httpListOrDefault(a.cfg.Lists.Domains)))) {
+		host := extractHostCandidate(target)
+		if host == "" {
+			continue
+		}
+		if current, ok := targetByHost[host]; !ok || (strings.HasPrefix(target, "https://") && !strings.HasPrefix(current, "https://")) {
+			targetByHost[host] = target
+		}
+	}
+	hosts := make([]string, 0, len(targetByHost))
+	for host := range targetByHost {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	if len(hosts) > wafDetectMaxTargets {
+		a.logger.Printf("%s: limiting hosts from %d to %d", StepWAFDetect, len(hosts), wafDetectMaxTargets)
+		hosts = hosts[:wafDetectMaxTargets]
+	}
+
+	outFile, err := os.Create(filepath.Join(reconDir, "waf_state.jsonl"))
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+	w := bufio.NewWriter(outFile)
+	defer w.Flush()
+
+	client := a.newHTTPClient(paramFuzzRequestTimeout, false)
+	metrics := map[string]int{
+		"hosts":         len(hosts),
+		"probed":        0,
+		"protected":     0,
+		"probe_blocked": 0,
+	}
+	vendorCounts := make(map[string]int)
+
+	for _, host := range hosts {
+		target := targetByHost[host]
+		state := wafHostState{Host: host, URL: target, DelayMS: paramFuzzHostDelay.Milliseconds()}
+		vendors := make(map[string]struct{})
+
+		baseResp, baseBody, err := wafFetch(ctx, client, target)
+		if err == nil {
+			metrics["probed"]++
+			state.BaselineStatus = baseResp.StatusCode
+			for _, vendor := range wafVendorsFromResponse(baseResp, baseBody, &state.Signals) {
+				vendors[vendor] = struct{}{}
+			}
+		}
+
+		probeURL := mutateURLQuery(target, "bflow_waf_probe", wafProbeValue)
+		if probeURL != "" && err == nil {
+			timer := time.NewTimer(paramFuzzHostDelay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+			probeResp, probeBody, probeErr := wafFetch(ctx, client, probeURL)
+			switch {
+			case probeErr != nil:
+				// A reset or timeout right after a clean baseline is typical of inline blocking.
+				state.ProbeBlocked = true
+				state.Signals = append(state.Signals, "probe:connection_dropped")
+			default:
+				state.ProbeStatus = probeResp.StatusCode
+				var probeSignals []string
+				for _, vendor := range wafVendorsFromResponse(probeResp, probeBody, &probeSignals) {
+					vendors[vendor] = struct{}{}
+				}
+				for _, sig := range probeSignals {
+					state.Signals = append(state.Signals, "probe_"+sig)
+				}
+				_, blockStatus := wafBlockStatuses[probeResp.StatusCode]
+				if blockStatus && probeResp.StatusCode != baseResp.StatusCode {
+					state.ProbeBlocked = true
+					state.Signals = append(state.Signals, "probe:status_"+strconv.Itoa(probeResp.StatusCode))
+				}
+				if vendor := wafBlockPageVendor(strings.ToLower(string(probeBody))); vendor != "" && wafBlockPageVendor(strings.ToLower(string(baseBody))) == "" {
+					state.ProbeBlocked = true
+					vendors[vendor] = struct{}{}
+					state.Signals = append(state.Signals, "probe:block_page_"+vendor)
+				}
+			}
+		}
+
+		state.Vendors = sortedParamKeys(vendors)
+		state.Signals = unique(state.Signals)
+		state.Protected = state.ProbeBlocked || hasWAFOnlyVendor(state.Vendors)
+		if state.Protected {
+			state.DelayMS = wafProtectedHostDelay.Milliseconds()
+			metrics["protected"]++
+		}
+		if state.ProbeBlocked {
+			metrics["probe_blocked"]++
+		}
+		for _, vendor := range state.Vendors {
+			vendorCounts[vendor]++
+		}
+		state.Timestamp = time.Now().UTC().Format(time.RFC3339)
+		_ = writeJSONLine(w, state)
+	}
+
+	vendorSummary := make([]string, 0, len(vendorCounts))
+	for vendor, count := range vendorCounts {
+		vendorSummary = append(vendorSummary, vendor+"="+strconv.Itoa(count))
+	}
+	sort.Strings(vendorSummary)
+	a.logger.Printf("%s: hosts=%d probed=%d protected=%d probe_blocked=%d vendors=[%s]", StepWAFDetect,
+		metrics["hosts"], metrics["probed"], metrics["protected"], metrics["probe_blocked"], strings.Join(vendorSummary, " "))
+	return nil
+}
+
+func wafFetch(ctx context.Context, client *http.Client, target string) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 16384))
+	_ = resp.Body.Close()
+	return resp, body, nil
+}
+
+func wafVendorsFromResponse(resp *http.Response, body []byte, signals *[]string) []string {
+	var vendors []string
+	server := strings.ToLower(resp.Header.Get("Server"))
+	var cookieNames []string
+	for _, cookie := range resp.Cookies() {
+		cookieNames = append(cookieNames, strings.ToLower(cookie.Name))
+	}
+	lowerBody := strings.ToLower(string(body))
+	for _, sig := range wafSignatures {
+		matched := ""
+		for _, header := range sig.headers {
+			if resp.Header.Get(header) != "" {
+				matched = "header:" + header
+				break
+			}
+		}
+		if matched == "" && server != "" {
+			for _, marker := range sig.servers {
+				if strings.Contains(server, marker) {
+					matched = "server:" + marker
+					break
+				}
+			}
+		}
+		if matched == "" {
+			for _, prefix := range sig.cookies {
+				for _, name := range cookieNames {
+					if strings.HasPrefix(name, prefix) {
+						matched = "cookie:" + prefix
+						break
+					}
+				}
+				if matched != "" {
+					break
+				}
+			}
+		}
+		if matched == "" {
+			for _, marker := range sig.bodies {
+				if strings.Contains(lowerBody, marker) {
+					matched = "body:" + marker
+					break
+				}
+			}
+		}
+		if matched != "" {
+			vendors = append(vendors, sig.vendor)
+			*signals = append(*signals, sig.vendor+"|"+matched)
+		}
+	}
+	return vendors
+}
+
+// wafBlockPageVendor returns the vendor whose block page the lowercased body matches.
+func wafBlockPageVendor(lowerBody string) string {
+	if lowerBody == "" {
+		return ""
+	}
+	for _, sig := range wafSignatures {
+		for _, marker := range sig.bodies {
+			if strings.Contains(lowerBody, marker) {
+				return sig.vendor
+			}
+		}
+	}
+	return ""
+}
+
+// hasWAFOnlyVendor reports vendors that imply active filtering by their mere presence;
+// plain CDNs (fastly, azure front door) only count once a probe is actually blocked.
+func hasWAFOnlyVendor(vendors []string) bool {
+	for _, vendor := range vendors {
+		switch vendor {
+		case "fastly", "azure_front_door", "stackpath":
+			continue
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+func (a *App) wafStatePath() string {
+	return filepath.Join(filepath.Dir(a.cfg.Lists.Domains), "recon", "waf_state.jsonl")
+}
+
+// wafHostStates returns the per-host WAF state, reloading it when the artifact changes.
+func (a *App) wafHostStates() map[string]wafHostState {
+	a.wafMu.Lock()
+	defer a.wafMu.Unlock()
+	info, err := os.Stat(a.wafStatePath())
+	if err != nil {
+		a.wafStates = nil
+		a.wafStatesMod = time.Time{}
+		return nil
+	}
+	if a.wafStates != nil && info.ModTime().Equal(a.wafStatesMod) {
+		return a.wafStates
+	}
+	states := make(map[string]wafHostState)
+	for _, line := range readSafeLines(a.wafStatePath()) {
+		var state wafHostState
+		if err := json.Unmarshal([]byte(line), &state); err != nil {
+			continue
+		}
+		host := strings.ToLower(strings.TrimSpace(state.Host))
+		if host != "" {
+			states[host] = state
+		}
+	}
+	a.wafStates = states
+	a.wafStatesMod = info.ModTime()
+	return states
+}
+
+func (a *App) hostRequestDelay(host string) time.Duration {
+	if state, ok := a.wafHostStates()[host]; ok && state.Protected {
+		return wafProtectedHostDelay
+	}
+	return paramFuzzHostDelay
+}
@@ -0,0 +1,194 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	timingScreenDelay    = 5
+	timingRounds         = 2
+	timingMinCorrelation = 0.95
+	timingMinSlope       = 0.7
+	timingMaxSlope       = 1.5
+	// One-sided p < 0.001 for the 5 degrees of freedom of six pairs.
+	timingMinT = 5.9
+)
+
+// timingDelays are the sleeps, in seconds, measured against a zero-sleep control. The
+// largest plus a slow baseline must stay under paramFuzzRequestTimeout.
+var timingDelays = []int{2, 4, 6}
+
+// timingEvidence is the measurement behind a time-based finding: each delayed sample
+// paired with the zero-sleep control sent next to it, and how the extra latency tracks
+// the requested delay. Slope is extra milliseconds per requested millisecond.
+type timingEvidence struct {
+	DelaysS     []int   `json:"delays_s"`
+	ControlMS   []int64 `json:"control_ms"`
+	DelayedMS   []int64 `json:"delayed_ms"`
+	BaselineMS  float64 `json:"baseline_mean_ms"`
+	BaselineSD  float64 `json:"baseline_sd_ms"`
+	Slope       float64 `json:"slope"`
+	InterceptMS float64 `json:"intercept_ms"`
+	Correlation float64 `json:"correlation"`
+	TStat       float64 `json:"t_stat"`
+}
+
+// renderSleepPayload fills a sleep template: {{delay}} in seconds, {{delay_ms}} in
+// milliseconds (MongoDB's sleep()).
+func renderSleepPayload(tmpl string, delay int) string {
+	return strings.NewReplacer(
+		"{{delay_ms}}", strconv.Itoa(delay*1000),
+		"{{delay}}", strconv.Itoa(delay),
+	).Replace(tmpl)
+}
+
+// timeBasedHit tries each sleep template of family on one parameter and returns a hit
+// for the first one whose delay is confirmed. send issues the request with the payload
+// in place; requests counts what was sent for the step metrics.
+func (a *App) timeBasedHit(family injectionFamilyConfig, endpoint, method, param, vector string, base *responseBaseline, mutatedURL func(string) string, send func(string) (paramFuzzObservation, error)) (hit injectionHit, requests int, ok bool) {
+	for _, tmpl := range family.Sleep {
+		timing, control, delayed, sent, confirmed := a.confirmTimeBased(base, tmpl, send)
+		requests += sent
+		if !confirmed {
+			continue
+		}
+		payload := renderSleepPayload(tmpl, timingDelays[len(timingDelays)-1])
+		return injectionHit{
+			Timestamp:  time.Now().UTC().Format(time.RFC3339),
+			Family:     family.Name,
+			Endpoint:   endpoint,
+			Method:     method,
+			Param:      param,
+			Payload:    payload,
+			Vector:     vector,
+			MutatedURL: mutatedURL(payload),
+			Reasons: []string{
+				"time_based_confirmed",
+				fmt.Sprintf("timing_correlation:%.2f", timing.Correlation),
+				fmt.Sprintf("timing_slope:%.2f", timing.Slope),
+			},
+			BaselineCode:   base.StatusCode,
+			MutatedCode:    delayed.StatusCode,
+			BaselineLen:    base.Length,
+			MutatedLen:     delayed.Length,
+			BaselineMS:     control.DurationMS,
+			MutatedMS:      delayed.DurationMS,
+			BaselineLoc:    base.Location,
+			MutatedLoc:     delayed.Location,
+			Timing:         timing,
+			EvidenceIDs:    a.saveEvidence(control.Exchange, delayed.Exchange),
+			SessionProfile: delayed.Session,
+		}, requests, true
+	}
+	return injectionHit{}, requests, false
+}
+
+// confirmTimeBased screens one sleep template and, when the screen comes back slow,
+// measures timingRounds pairs per delay: the template with a zero sleep and with the
+// delay, back to back and in alternating order so drift hits both sides. The delay is
+// confirmed when the paired extra latency rises with the requested delay (correlation
+// and slope), is significant by a one-sided paired t-test on extra/requested, and every
+// pair clears four times the baseline jitter. control and delayed are the pair at the
+// longest delay from the last round.
+func (a *App) confirmTimeBased(base *responseBaseline, tmpl string, send func(string) (paramFuzzObservation, error)) (timing *timingEvidence, control, delayed paramFuzzObservation, sent int, ok bool) {
+	screen, err := send(renderSleepPayload(tmpl, timingScreenDelay))
+	sent++
+	if err != nil || float64(screen.DurationMS) < base.durMean+0.8*timingScreenDelay*1000 {
+		return nil, control, delayed, sent, false
+	}
+
+	timing = &timingEvidence{BaselineMS: math.Round(base.durMean), BaselineSD: math.Round(base.durStd)}
+	for round := 0; round < timingRounds; round++ {
+		for _, delay := range timingDelays {
+			var c, d paramFuzzObservation
+			var cErr, dErr error
+			if round%2 == 0 {
+				c, cErr = send(renderSleepPayload(tmpl, 0))
+				d, dErr = send(renderSleepPayload(tmpl, delay))
+			} else {
+				d, dErr = send(renderSleepPayload(tmpl, delay))
+				c, cErr = send(renderSleepPayload(tmpl, 0))
+			}
+			sent += 2
+			if cErr != nil || dErr != nil {
+				return nil, control, delayed, sent, false
+			}
+			timing.DelaysS = append(timing.DelaysS, delay)
+			timing.ControlMS = append(timing.ControlMS, c.DurationMS)
+			timing.DelayedMS = append(timing.DelayedMS, d.DurationMS)
+			control, delayed = c, d
+		}
+	}
+
+	n := float64(len(timing.DelaysS))
+	xs := make([]float64, 0, len(timing.DelaysS))
+	ys := make([]float64, 0, len(timing.DelaysS))
+	ratios := make([]float64, 0, len(timing.DelaysS))
+	for i, delay := range timing.DelaysS {
+		x := float64(delay * 1000)
+		y := float64(timing.DelayedMS[i] - timing.ControlMS[i])
+		xs, ys, ratios = append(xs, x), append(ys, y), append(ratios, y/x)
+	}
+	xMean, xSD := meanSD(xs)
+	yMean, ySD := meanSD(ys)
+	rMean, rSD := meanSD(ratios)
+	controls := make([]float64, 0, len(timing.ControlMS))
+	for _, c := range timing.ControlMS {
+		controls = append(controls, float64(c))
+	}
+	_, controlSD := meanSD(controls)
+	jitter := math.Max(base.durStd, controlSD)
+
+	var cov float64
+	for i := range xs {
+		cov += (xs[i] - xMean) * (ys[i] - yMean)
+	}
+	cov /= n
+	if xSD > 0 {
+		timing.Slope = cov / (xSD * xSD)
+	}
+	if xSD > 0 && ySD > 0 {
+		timing.Correlation = cov / (xSD * ySD)
+	}
+	timing.InterceptMS = yMean - timing.Slope*xMean
+	// Sample standard deviation for the t statistic; a floor keeps perfectly steady
+	// pairs from dividing by zero.
+	rSD = math.Max(rSD*math.Sqrt(n/(n-1)), 0.01)
+	timing.TStat = rMean / (rSD / math.Sqrt(n))
+
+	timing.Slope = math.Round(timing.Slope*1000) / 1000
+	timing.Correlation = math.Round(timing.Correlation*1000) / 1000
+	timing.InterceptMS = math.Round(timing.InterceptMS)
+	timing.TStat = math.Round(timing.TStat*100) / 100
+
+	for i, y := range ys {
+		if y < 0.5*xs[i] || y < 4*jitter {
+			return timing, control, delayed, sent, false
+		}
+	}
+	ok = timing.Correlation >= timingMinCorrelation &&
+		timing.Slope >= timingMinSlope && timing.Slope <= timingMaxSlope &&
+		timing.TStat >= timingMinT
+	return timing, control, delayed, sent, ok
+}
+
+// meanSD returns the mean and population standard deviation of values.
+func meanSD(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
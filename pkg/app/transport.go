@@ -0,0 +1,128 @@
+package app
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const defaultTorSocks = "127.0.0.1:9050"
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// newHTTPClient returns a client on the shared transport, so every in-process request
+// follows the Tor/proxy settings and the network section of flow.yaml. Check modules
+// pass follow=false to see redirects as responses.
+func (a *App) newHTTPClient(timeout time.Duration, follow bool) *http.Client {
+	return a.clientOn(a.transport(false), timeout, follow)
+}
+
+// newInsecureHTTPClient is newHTTPClient without certificate verification, for probes
+// of self-signed admin ports.
+func (a *App) newInsecureHTTPClient(timeout time.Duration, follow bool) *http.Client {
+	return a.clientOn(a.transport(true), timeout, follow)
+}
+
+// HTTPClient exposes the shared transport to the server (lead replay, chaos lookups).
+func (a *App) HTTPClient(timeout time.Duration) *http.Client {
+	return a.newHTTPClient(timeout, true)
+}
+
+func (a *App) clientOn(transport *http.Transport, timeout time.Duration, follow bool) *http.Client {
+	client := &http.Client{Timeout: timeout, Transport: transport}
+	if !follow {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	return client
+}
+
+func (a *App) transport(insecure bool) *http.Transport {
+	a.transportMu.Lock()
+	defer a.transportMu.Unlock()
+	if t := a.transports[insecure]; t != nil {
+		return t
+	}
+	t, err := a.buildTransport(insecure)
+	if err != nil {
+		a.logger.Printf("network: %v; using system trust only", err)
+	}
+	if a.transports == nil {
+		a.transports = make(map[bool]*http.Transport, 2)
+	}
+	a.transports[insecure] = t
+	return t
+}
+
+// resetTransports drops cached transports after a Tor or proxy toggle. Clients built
+// before keep their old route until their step finishes.
+func (a *App) resetTransports() {
+	a.transportMu.Lock()
+	defer a.transportMu.Unlock()
+	for _, t := range a.transports {
+		t.CloseIdleConnections()
+	}
+	a.transports = nil
+}
+
+// buildTransport routes through Tor's SOCKS5 port when Tor is on, else through the
+// configured HTTP proxy, else through the environment proxy. A CA that fails to load is
+// reported but still yields a usable transport.
+func (a *App) buildTransport(insecure bool) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy := a.egressProxyURL(); proxy != nil {
+		t.Proxy = http.ProxyURL(proxy)
+	}
+	network := a.cfg.Network
+	tlsCfg := &tls.Config{InsecureSkipVerify: insecure || network.InsecureSkipVerify}
+	if network.MinTLSVersion != "" {
+		version, ok := tlsVersions[strings.TrimSpace(network.MinTLSVersion)]
+		if !ok {
+			return t, fmt.Errorf("unsupported min_tls_version %q", network.MinTLSVersion)
+		}
+		tlsCfg.MinVersion = version
+	}
+	t.TLSClientConfig = tlsCfg
+	if network.CACert == "" {
+		return t, nil
+	}
+	pem, err := os.ReadFile(network.CACert)
+	if err != nil {
+		return t, fmt.Errorf("ca_cert: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return t, fmt.Errorf("ca_cert %s: no PEM certificates found", network.CACert)
+	}
+	tlsCfg.RootCAs = pool
+	return t, nil
+}
+
+func (a *App) egressProxyURL() *url.URL {
+	if a.torEnabled {
+		addr := strings.TrimSpace(a.cfg.Network.TorSocks)
+		if addr == "" {
+			addr = defaultTorSocks
+		}
+		// socks5h resolves names on the Tor side so DNS does not leak.
+		return &url.URL{Scheme: "socks5h", Host: addr}
+	}
+	if a.proxyEnabled {
+		return &url.URL{Scheme: "http", Host: fmt.Sprintf("%s:%d", a.proxyHost, a.proxyPort)}
+	}
+	return nil
+}
@@ -6,16 +6,26 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/rojo/hack/web_bounty_flow/pkg/payloads"
 	"gopkg.in/yaml.v3"
 )
 
 // Config keeps all paths and options that used to live in flow.conf.
 type Config struct {
-	LogFile     string      `yaml:"log_file"`
-	Lists       Lists       `yaml:"lists"`
-	Paths       Paths       `yaml:"paths"`
-	Wordlists   Wordlists   `yaml:"wordlists"`
-	NmapSummary NmapSummary `yaml:"nmap_summary"`
+	LogFile      string       `yaml:"log_file"`
+	Lists        Lists        `yaml:"lists"`
+	Paths        Paths        `yaml:"paths"`
+	Wordlists    Wordlists    `yaml:"wordlists"`
+	NmapSummary  NmapSummary  `yaml:"nmap_summary"`
+	HostClusters HostClusters `yaml:"host_clusters"`
+	WAF          WAF          `yaml:"waf"`
+	Mutations    Mutations    `yaml:"mutations"`
+	URLCorpus    URLCorpus    `yaml:"url_corpus"`
+	Network      Network      `yaml:"network"`
+	OOB          OOB          `yaml:"oob"`
+	PayloadPacks PayloadPacks `yaml:"payload_packs"`
+	Nuclei       Nuclei       `yaml:"nuclei"`
+	Replay       Replay       `yaml:"replay"`
 }
 
 // Lists is the collection of file references to scope lists.
@@ -75,6 +85,92 @@ type NmapSummary struct {
 	InterestingPorts    []string `yaml:"interesting_ports"`
 }
 
+// HostClusters controls how look-alike hosts are collapsed before active checks.
+// MaxPerCluster caps how many hosts of one cluster are tested; 0 tests every host.
+type HostClusters struct {
+	MaxPerCluster int `yaml:"max_per_cluster"`
+}
+
+// WAF controls payload adaptation for hosts detected behind a WAF.
+// Evasion sends each payload family's mutation chains (URL encoding, case toggling, SQL
+// comments and the rest declared in its payload pack) to protected hosts.
+type WAF struct {
+	Evasion bool `yaml:"evasion"`
+}
+
+// Mutations widens payload mutation beyond WAF-protected hosts: AllHosts sends every
+// family's mutation chains to every host, multiplying the requests per parameter.
+type Mutations struct {
+	AllHosts bool `yaml:"all_hosts"`
+}
+
+// URLCorpus narrows the URLs check modules test, using recon/url_corpus.jsonl.
+// Sources keeps URLs found by any listed source (httpx, robots, sitemap, wayback,
+// katana, js, ffuf, import); Statuses keeps URLs whose last probe returned a listed
+// code or class ("200", "3xx"), ignoring probes older than StatusMaxAgeHours when set.
+// Empty lists test every URL. url-corpus only probes URLs never probed before (and, with
+// StatusMaxAgeHours, those whose probe has gone stale); ProbeAll re-probes every URL.
+type URLCorpus struct {
+	Sources           []string `yaml:"sources"`
+	Statuses          []string `yaml:"statuses"`
+	StatusMaxAgeHours int      `yaml:"status_max_age_hours"`
+	ProbeAll          bool     `yaml:"probe_all"`
+}
+
+// Network tunes the in-process HTTP clients; the Tor and proxy toggles themselves come
+// from the UI. CACert is a PEM file trusted on top of the system pool (the Burp or ZAP
+// CA when intercepting), InsecureSkipVerify disables verification altogether, TorSocks
+// is the SOCKS5 address used when Tor is on (default 127.0.0.1:9050) and MinTLSVersion
+// is "1.0" to "1.3".
+type Network struct {
+	CACert             string `yaml:"ca_cert"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+	TorSocks           string `yaml:"tor_socks"`
+	MinTLSVersion      string `yaml:"min_tls_version"`
+}
+
+// OOB runs the out-of-band listener that blind SSRF, XXE and command injection payloads
+// call back to. PublicHost is what payloads embed: a domain whose NS records point here
+// gives DNS and HTTP callbacks, an IP gives HTTP only. PublicPort is the HTTP port
+// targets reach (default: the HTTPListen port, e.g. behind a port forward), PublicIP is
+// answered for A queries under PublicHost and DNSListen "" disables DNS. WaitSeconds
+// keeps a step open for late callbacks after its last probe; later ones are still
+// recorded while the process runs.
+type OOB struct {
+	Enabled     bool   `yaml:"enabled"`
+	PublicHost  string `yaml:"public_host"`
+	PublicPort  int    `yaml:"public_port"`
+	PublicIP    string `yaml:"public_ip"`
+	HTTPListen  string `yaml:"http_listen"`
+	DNSListen   string `yaml:"dns_listen"`
+	WaitSeconds int    `yaml:"wait_seconds"`
+}
+
+// PayloadPacks enables payload packs on top of the bundled default pack. Each entry in
+// Dirs is a directory of YAML files (see pkg/payloads); families tagged destructive are
+// only loaded with AllowDestructive.
+type PayloadPacks struct {
+	Dirs             []string `yaml:"dirs"`
+	AllowDestructive bool     `yaml:"allow_destructive"`
+}
+
+// Nuclei tunes nuclei-scan. The tags picked from the tech profile are always included
+// on top of the full template set; TechTagsOnly restricts the scan to those tags (and
+// the exposure baseline) instead, which is faster but skips untagged CVE templates.
+type Nuclei struct {
+	TechTagsOnly bool `yaml:"tech_tags_only"`
+}
+
+// Replay controls how active checks re-send requests expanded from API specs or imported
+// from proxy history. Only GET, HEAD and OPTIONS requests are replayed unless
+// UnsafeMethods is set: POST, PUT, PATCH and DELETE carry payloads into handlers that may
+// create or delete data. Captured Cookie, Authorization and API key headers are dropped
+// so replays run under the session profile; CapturedCredentials sends them as recorded.
+type Replay struct {
+	UnsafeMethods       bool `yaml:"unsafe_methods"`
+	CapturedCredentials bool `yaml:"captured_credentials"`
+}
+
 // Load reads a YAML configuration file and expands environment variables.
 func Load(path string) (*Config, error) {
 	raw, err := os.ReadFile(path)
@@ -91,6 +187,9 @@ func Load(path string) (*Config, error) {
 	}
 
 	cfg.expandPaths()
+	if _, err := payloads.Load(cfg.PayloadPacks.Dirs, cfg.PayloadPacks.AllowDestructive); err != nil {
+		return nil, err
+	}
 	return &cfg, nil
 }
 
@@ -134,6 +233,12 @@ func (c *Config) expandPaths() {
 	c.NmapSummary.PointersFile = expand(c.NmapSummary.PointersFile)
 	c.NmapSummary.ServicesFile = expand(c.NmapSummary.ServicesFile)
 	c.NmapSummary.SearchsploitFile = expand(c.NmapSummary.SearchsploitFile)
+
+	c.Network.CACert = expand(c.Network.CACert)
+
+	for i, dir := range c.PayloadPacks.Dirs {
+		c.PayloadPacks.Dirs[i] = expand(dir)
+	}
 }
 
 func expand(path string) string {
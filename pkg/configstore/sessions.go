@@ -0,0 +1,135 @@
+package configstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SessionProvider holds authenticated session profiles. Each key is one profile: the
+// label is its name and the value a JSON-encoded SessionProfile. Active profiles are
+// applied to every run that does not select one explicitly.
+const SessionProvider = "sessions"
+
+// SessionProfile carries the credentials the Go checks attach to their requests.
+// Hosts binds the profile to exact hosts or "*.example.com" patterns (apex included);
+// an empty list applies it everywhere. CheckURL, when set, is an authenticated page
+// re-fetched to confirm the session is still alive, and LoggedOutMarkers are body
+// snippets that only appear once logged out. OnLogout is "flag" (default) or "pause".
+type SessionProfile struct {
+	Cookie           string            `json:"cookie,omitempty"`
+	Bearer           string            `json:"bearer,omitempty"`
+	Headers          map[string]string `json:"headers,omitempty"`
+	Hosts            []string          `json:"hosts,omitempty"`
+	CheckURL         string            `json:"check_url,omitempty"`
+	LoggedOutMarkers []string          `json:"logged_out_markers,omitempty"`
+	OnLogout         string            `json:"on_logout,omitempty"`
+}
+
+// ParseSessionProfile decodes and validates a stored profile value.
+func ParseSessionProfile(value string) (SessionProfile, error) {
+	var p SessionProfile
+	dec := json.NewDecoder(bytes.NewReader([]byte(value)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return p, fmt.Errorf("session profile: %w", err)
+	}
+	p.Cookie = strings.TrimSpace(p.Cookie)
+	if len(p.Cookie) >= 7 && strings.EqualFold(p.Cookie[:7], "cookie:") {
+		p.Cookie = strings.TrimSpace(p.Cookie[7:])
+	}
+	p.Bearer = strings.TrimSpace(p.Bearer)
+	if len(p.Bearer) >= 7 && strings.EqualFold(p.Bearer[:7], "bearer ") {
+		p.Bearer = strings.TrimSpace(p.Bearer[7:])
+	}
+	if p.Cookie == "" && p.Bearer == "" && len(p.Headers) == 0 {
+		return p, errors.New("session profile: cookie, bearer or headers required")
+	}
+	for name, v := range p.Headers {
+		if strings.TrimSpace(name) == "" || strings.ContainsAny(name, " :\r\n") || strings.ContainsAny(v, "\r\n") {
+			return p, fmt.Errorf("session profile: invalid header %q", name)
+		}
+	}
+	if strings.ContainsAny(p.Cookie+p.Bearer, "\r\n") {
+		return p, errors.New("session profile: cookie and bearer must be single-line")
+	}
+	var hosts []string
+	for _, host := range p.Hosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	p.Hosts = hosts
+	if p.CheckURL = strings.TrimSpace(p.CheckURL); p.CheckURL != "" {
+		parsed, err := url.Parse(p.CheckURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return p, fmt.Errorf("session profile: invalid check_url %q", p.CheckURL)
+		}
+	}
+	switch p.OnLogout = strings.ToLower(strings.TrimSpace(p.OnLogout)); p.OnLogout {
+	case "", "flag", "pause":
+	default:
+		return p, fmt.Errorf("session profile: on_logout must be flag or pause, got %q", p.OnLogout)
+	}
+	return p, nil
+}
+
+// AppliesTo reports whether the profile is bound to host.
+func (p SessionProfile) AppliesTo(host string) bool {
+	if len(p.Hosts) == 0 {
+		return true
+	}
+	host = strings.ToLower(strings.TrimSpace(host))
+	for _, pattern := range p.Hosts {
+		if base, ok := strings.CutPrefix(pattern, "*."); ok {
+			if host == base || strings.HasSuffix(host, "."+base) {
+				return true
+			}
+			continue
+		}
+		if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply attaches the profile to req. Headers the caller set explicitly win; profile
+// cookies are added only under names the request lacks.
+func (p SessionProfile) Apply(req *http.Request) {
+	for name, value := range p.Headers {
+		if req.Header.Get(name) == "" {
+			req.Header.Set(name, value)
+		}
+	}
+	if p.Bearer != "" && req.Header.Get("Authorization") == "" {
+		req.Header.Set("Authorization", "Bearer "+p.Bearer)
+	}
+	if p.Cookie == "" {
+		return
+	}
+	existing := req.Header.Get("Cookie")
+	if existing == "" {
+		req.Header.Set("Cookie", p.Cookie)
+		return
+	}
+	present := make(map[string]struct{})
+	for _, part := range strings.Split(existing, ";") {
+		name, _, _ := strings.Cut(strings.TrimSpace(part), "=")
+		present[name] = struct{}{}
+	}
+	merged := existing
+	for _, part := range strings.Split(p.Cookie, ";") {
+		part = strings.TrimSpace(part)
+		name, _, _ := strings.Cut(part, "=")
+		if _, ok := present[name]; ok || part == "" {
+			continue
+		}
+		merged += "; " + part
+	}
+	req.Header.Set("Cookie", merged)
+}
@@ -25,6 +25,8 @@ type GithubOptions struct {
 	Tokens      []Token
 	Logger      logger
 	UpdateToken func(tokenID string, used time.Time, lastErr string)
+	// Client carries the caller's proxy/Tor transport; nil connects directly.
+	Client *http.Client
 }
 
 type Token struct {
@@ -77,7 +79,10 @@ func RunGithubSearch(ctx context.Context, opts GithubOptions) error {
 		return err
 	}
 
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
 	rotator := newTokenRotator(opts.Tokens, opts.UpdateToken)
 
 	for _, file := range files {
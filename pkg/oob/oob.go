@@ -0,0 +1,282 @@
+// Package oob is a small interactsh-style listener for blind payloads: an HTTP server
+// and an authoritative DNS responder that record every request carrying a correlation
+// id handed out by NewID.
+package oob
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	httpBodyLimit = 64 << 10
+	dnsPacketSize = 512
+)
+
+var idRe = regexp.MustCompile(`bf[0-9a-f]{16}`)
+
+// Config describes where the listener binds and how payloads reach it. PublicHost is
+// embedded in payloads: a domain delegated to this machine yields "<id>.<domain>" names
+// that work for DNS and HTTP, an IP puts the id in the URL path and supports HTTP only.
+// PublicPort is the HTTP port targets connect to (default: the HTTPListen port) and
+// PublicIP, when set, answers A queries under PublicHost. DNSListen "" disables DNS.
+type Config struct {
+	PublicHost string
+	PublicPort int
+	PublicIP   string
+	HTTPListen string
+	DNSListen  string
+}
+
+// Interaction is one callback that carried a correlation id.
+type Interaction struct {
+	ID        string `json:"correlation_id"`
+	Protocol  string `json:"protocol"`
+	Remote    string `json:"remote"`
+	Timestamp string `json:"timestamp"`
+	Raw       string `json:"raw"`
+}
+
+// Listener runs the HTTP and DNS servers until Close.
+type Listener struct {
+	cfg      Config
+	handle   func(Interaction)
+	httpLn   net.Listener
+	httpSrv  *http.Server
+	dnsConn  net.PacketConn
+	hostIsIP bool
+	closeMu  sync.Mutex
+	closed   bool
+}
+
+// Start binds the listeners and calls handle, from the serving goroutines, for every
+// interaction.
+func Start(cfg Config, handle func(Interaction)) (*Listener, error) {
+	cfg.PublicHost = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(cfg.PublicHost), "."))
+	if cfg.PublicHost == "" {
+		return nil, errors.New("oob: public_host is required")
+	}
+	if cfg.HTTPListen == "" {
+		return nil, errors.New("oob: http_listen is required")
+	}
+	l := &Listener{cfg: cfg, handle: handle, hostIsIP: net.ParseIP(cfg.PublicHost) != nil}
+
+	ln, err := net.Listen("tcp", cfg.HTTPListen)
+	if err != nil {
+		return nil, fmt.Errorf("oob http: %w", err)
+	}
+	l.httpLn = ln
+	if l.cfg.PublicPort == 0 {
+		l.cfg.PublicPort = ln.Addr().(*net.TCPAddr).Port
+	}
+	l.httpSrv = &http.Server{Handler: http.HandlerFunc(l.serveHTTP), ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = l.httpSrv.Serve(ln) }()
+
+	if cfg.DNSListen != "" {
+		conn, err := net.ListenPacket("udp", cfg.DNSListen)
+		if err != nil {
+			_ = l.httpSrv.Close()
+			return nil, fmt.Errorf("oob dns: %w", err)
+		}
+		l.dnsConn = conn
+		go l.serveDNS()
+	}
+	return l, nil
+}
+
+// Close stops both servers.
+func (l *Listener) Close() error {
+	l.closeMu.Lock()
+	defer l.closeMu.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	err := l.httpSrv.Close()
+	if l.dnsConn != nil {
+		_ = l.dnsConn.Close()
+	}
+	return err
+}
+
+// HTTPAddr is the bound HTTP address.
+func (l *Listener) HTTPAddr() string {
+	return l.httpLn.Addr().String()
+}
+
+// DNSAddr is the bound DNS address, or "" without DNS.
+func (l *Listener) DNSAddr() string {
+	if l.dnsConn == nil {
+		return ""
+	}
+	return l.dnsConn.LocalAddr().String()
+}
+
+// NewID returns a fresh correlation id, safe as a DNS label.
+func NewID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		binary.BigEndian.PutUint64(buf, uint64(time.Now().UnixNano()))
+	}
+	return "bf" + hex.EncodeToString(buf)
+}
+
+// Expand fills a payload template for id. {{oob}} becomes an HTTP authority, followed by
+// a path prefix when PublicHost is an IP, so "http://{{oob}}/x" works either way;
+// {{oob_host}} is the bare DNS name and is only available with a domain, so ok is false
+// for such templates on an IP.
+func (l *Listener) Expand(tmpl, id string) (payload string, ok bool) {
+	if strings.Contains(tmpl, "{{oob_host}}") && l.hostIsIP {
+		return "", false
+	}
+	host := id + "." + l.cfg.PublicHost
+	authority := host
+	if l.hostIsIP {
+		authority = l.cfg.PublicHost
+		if strings.Contains(authority, ":") {
+			authority = "[" + authority + "]"
+		}
+	}
+	if l.cfg.PublicPort != 80 {
+		authority += ":" + strconv.Itoa(l.cfg.PublicPort)
+	}
+	if l.hostIsIP {
+		authority += "/" + id
+	}
+	return strings.NewReplacer("{{oob_host}}", host, "{{oob}}", authority).Replace(tmpl), true
+}
+
+func (l *Listener) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, httpBodyLimit)
+	raw, _ := httputil.DumpRequest(r, true)
+	_, _ = io.Copy(io.Discard, r.Body)
+	// The id normally sits in the Host or the path; headers and body are the fallback for
+	// payloads that smuggle it elsewhere.
+	id := idRe.FindString(strings.ToLower(r.Host + " " + r.URL.RequestURI()))
+	if id == "" {
+		id = idRe.FindString(strings.ToLower(string(raw)))
+	}
+	if id != "" {
+		l.emit(Interaction{ID: id, Protocol: "http", Remote: r.RemoteAddr, Raw: string(raw)})
+	}
+	// An empty body is a valid external DTD, so parameter-entity XXE probes resolve.
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (l *Listener) emit(in Interaction) {
+	in.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	if l.handle != nil {
+		l.handle(in)
+	}
+}
+
+func (l *Listener) serveDNS() {
+	buf := make([]byte, dnsPacketSize)
+	for {
+		n, addr, err := l.dnsConn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		query := append([]byte(nil), buf[:n]...)
+		name, qtype, end, ok := parseDNSQuestion(query)
+		if !ok {
+			continue
+		}
+		if id := idRe.FindString(name); id != "" {
+			l.emit(Interaction{ID: id, Protocol: "dns", Remote: addr.String(), Raw: fmt.Sprintf("%s %s", name, dnsTypeName(qtype))})
+		}
+		_, _ = l.dnsConn.WriteTo(l.dnsReply(query, name, qtype, end), addr)
+	}
+}
+
+// dnsReply answers authoritatively: A queries under PublicHost get PublicIP with a zero
+// TTL so repeated lookups keep reaching us; everything else gets an empty NOERROR.
+func (l *Listener) dnsReply(query []byte, name string, qtype uint16, end int) []byte {
+	reply := make([]byte, 0, end+16)
+	reply = append(reply, query[:2]...)
+	flags := binary.BigEndian.Uint16(query[2:4])
+	flags = 0x8000 | flags&0x7800 | 0x0400 | flags&0x0100 // QR, opcode, AA, RD
+	reply = binary.BigEndian.AppendUint16(reply, flags)
+	reply = binary.BigEndian.AppendUint16(reply, 1)
+	ip := net.ParseIP(l.cfg.PublicIP).To4()
+	inZone := name == l.cfg.PublicHost || strings.HasSuffix(name, "."+l.cfg.PublicHost)
+	answer := qtype == 1 && ip != nil && inZone
+	if answer {
+		reply = binary.BigEndian.AppendUint16(reply, 1)
+	} else {
+		reply = binary.BigEndian.AppendUint16(reply, 0)
+	}
+	reply = append(reply, 0, 0, 0, 0)
+	reply = append(reply, query[12:end]...)
+	if answer {
+		reply = append(reply, 0xc0, 0x0c)             // pointer to the question name
+		reply = append(reply, 0, 1, 0, 1, 0, 0, 0, 0) // A, IN, TTL 0
+		reply = append(reply, 0, 4)
+		reply = append(reply, ip...)
+	}
+	return reply
+}
+
+// parseDNSQuestion returns the lowercased first question name, its type and the offset
+// just past the question.
+func parseDNSQuestion(msg []byte) (string, uint16, int, bool) {
+	if len(msg) < 12 || msg[2]&0x80 != 0 || binary.BigEndian.Uint16(msg[4:6]) == 0 {
+		return "", 0, 0, false
+	}
+	var labels []string
+	i := 12
+	for {
+		if i >= len(msg) {
+			return "", 0, 0, false
+		}
+		size := int(msg[i])
+		if size == 0 {
+			i++
+			break
+		}
+		// Queries do not compress the question name.
+		if size&0xc0 != 0 || i+1+size > len(msg) {
+			return "", 0, 0, false
+		}
+		labels = append(labels, string(msg[i+1:i+1+size]))
+		i += 1 + size
+	}
+	if i+4 > len(msg) {
+		return "", 0, 0, false
+	}
+	qtype := binary.BigEndian.Uint16(msg[i : i+2])
+	return strings.ToLower(strings.Join(labels, ".")), qtype, i + 4, true
+}
+
+func dnsTypeName(qtype uint16) string {
+	switch qtype {
+	case 1:
+		return "A"
+	case 28:
+		return "AAAA"
+	case 5:
+		return "CNAME"
+	case 15:
+		return "MX"
+	case 16:
+		return "TXT"
+	default:
+		return "TYPE" + strconv.Itoa(int(qtype))
+	}
+}
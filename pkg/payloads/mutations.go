@@ -0,0 +1,141 @@
+package payloads
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Transforms are the payload mutations a family can chain. Each rewrites the value
+// before the vector's own encoding, so the percent-encoding ones are named for what a
+// query parameter carries on the wire: "double_url" encodes the payload once and the
+// vector encodes it again. A transform that does not apply to a payload (no spaces for
+// sql_comment, no "../" for the path tricks) returns it unchanged.
+var Transforms = map[string]func(string) string{
+	"double_url":         percentEncode,
+	"triple_url":         func(s string) string { return percentEncode(percentEncode(s)) },
+	"unicode":            unicodeEncode,
+	"fullwidth":          fullwidth,
+	"html_entity":        htmlEntities,
+	"case_toggle":        toggleCase,
+	"sql_comment":        func(s string) string { return strings.ReplaceAll(s, " ", "/**/") },
+	"whitespace_tab":     func(s string) string { return strings.ReplaceAll(s, " ", "\t") },
+	"whitespace_newline": func(s string) string { return strings.ReplaceAll(s, " ", "\n") },
+	"null_byte":          func(s string) string { return s + "\x00" },
+	"path_encoded_slash": func(s string) string { return strings.ReplaceAll(s, "../", "..%2f") },
+	"path_backslash":     func(s string) string { return strings.ReplaceAll(s, "../", `..\`) },
+	"path_nested":        func(s string) string { return strings.ReplaceAll(s, "../", "....//") },
+}
+
+// Mutate applies chain to payload in order.
+func Mutate(payload string, chain []string) (string, error) {
+	for _, name := range chain {
+		transform, ok := Transforms[name]
+		if !ok {
+			return "", fmt.Errorf("unknown transform %q", name)
+		}
+		payload = transform(payload)
+	}
+	return payload, nil
+}
+
+// Variants returns each payload followed by its distinct mutations, with the chain that
+// produced every mutated variant. Variants equal to a payload or an earlier variant are
+// dropped, so the first chain that yields a string is the one recorded.
+func Variants(payloads []string, chains [][]string) ([]string, map[string][]string) {
+	out := append([]string{}, payloads...)
+	produced := make(map[string][]string)
+	if len(chains) == 0 {
+		return out, produced
+	}
+	for _, payload := range payloads {
+		for _, chain := range chains {
+			variant, err := Mutate(payload, chain)
+			if err != nil || variant == payload {
+				continue
+			}
+			if _, exists := produced[variant]; exists || containsExact(payloads, variant) {
+				continue
+			}
+			produced[variant] = chain
+			out = append(out, variant)
+		}
+	}
+	return out, produced
+}
+
+func percentEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isUnreserved(c) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// unicodeEncode writes non-alphanumeric characters as IIS-style %uXXXX escapes.
+func unicodeEncode(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < 0x80 && isUnreserved(byte(r)) {
+			b.WriteRune(r)
+			continue
+		}
+		if r > 0xffff {
+			b.WriteRune(r)
+			continue
+		}
+		fmt.Fprintf(&b, "%%u%04X", r)
+	}
+	return b.String()
+}
+
+// fullwidth swaps ASCII punctuation for its fullwidth form (U+FF01-FF5E), which
+// back ends that apply NFKC normalization fold back after the WAF has looked.
+func fullwidth(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r > 0x20 && r < 0x7f && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			r += 0xfee0
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func htmlEntities(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < 0x80 && isUnreserved(byte(r)) {
+			b.WriteRune(r)
+			continue
+		}
+		fmt.Fprintf(&b, "&#x%X;", r)
+	}
+	return b.String()
+}
+
+func toggleCase(s string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			if upper {
+				r = unicode.ToUpper(r)
+			} else {
+				r = unicode.ToLower(r)
+			}
+			upper = !upper
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~'
+}
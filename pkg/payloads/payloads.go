@@ -0,0 +1,336 @@
+// Package payloads loads the payload families the injection, server-input and advanced
+// injection checks send. Families live in packs: directories of YAML files. The default
+// pack is compiled in; flow.yaml can add more, which may extend a bundled family or
+// define new ones.
+package payloads
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Steps that take payload families, and the vectors each one knows how to send.
+const (
+	StepInjection    = "injection-checks"
+	StepServerInput  = "server-input-checks"
+	StepAdvInjection = "adv-injection-checks"
+)
+
+var stepVectors = map[string][]string{
+	StepInjection:    {"query", "form", "json", "header", "cookie", "path"},
+	StepServerInput:  {"query"},
+	StepAdvInjection: {"query", "xml"},
+}
+
+const (
+	TagSafe        = "safe"
+	TagDestructive = "destructive"
+)
+
+var familyNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_]*$`)
+
+//go:embed default/*.yaml
+var defaultPack embed.FS
+
+// Family is one payload family. Vectors limit where payloads are placed, Tech lists the
+// stacks a stack-specific family is worth sending to (empty means every host), Regexes
+// match the response case-insensitively like Keywords do, Sleep templates take {{delay}}
+// or {{delay_ms}}, OOB templates take {{oob}} or {{oob_host}}, and Headers are added to
+// body-vector requests. Mutations are transform chains (see Transforms) applied to every
+// payload when mutation is on for a host. Tags are free-form except safe and destructive.
+type Family struct {
+	Name     string            `yaml:"name"`
+	Step     string            `yaml:"step"`
+	Vectors  []string          `yaml:"vectors"`
+	Tech     []string          `yaml:"tech"`
+	Tags     []string          `yaml:"tags"`
+	Payloads []string          `yaml:"payloads"`
+	Keywords []string          `yaml:"keywords"`
+	Regexes  []string          `yaml:"regexes"`
+	Sleep    []string          `yaml:"sleep"`
+	OOB      []string          `yaml:"oob"`
+	Headers  map[string]string `yaml:"headers"`
+
+	Mutations [][]string `yaml:"mutations"`
+
+	// Packs lists the packs that contributed to the family, default first.
+	Packs    []string         `yaml:"-"`
+	Compiled []*regexp.Regexp `yaml:"-"`
+}
+
+// Destructive reports whether the family is tagged destructive.
+func (f Family) Destructive() bool {
+	return containsFold(f.Tags, TagDestructive)
+}
+
+// HasVector reports whether the family may be sent through vector.
+func (f Family) HasVector(vector string) bool {
+	return containsFold(f.Vectors, vector)
+}
+
+type packFile struct {
+	Description string   `yaml:"description"`
+	Families    []Family `yaml:"families"`
+}
+
+// Set is the merged result of the default pack and every enabled pack.
+type Set struct {
+	families map[string][]Family
+	// Skipped lists "<pack>/<family>" contributions dropped for being destructive.
+	Skipped []string
+}
+
+// Families returns the step's families in load order.
+func (s *Set) Families(step string) []Family {
+	if s == nil {
+		return nil
+	}
+	return append([]Family(nil), s.families[step]...)
+}
+
+// Load reads the default pack and then each directory in dirs, validating every file.
+// A family whose name already exists for its step extends it: payloads, keywords,
+// regexes, templates, mutations, vectors, tech and tags are appended and headers
+// merged. Families or extensions tagged destructive are skipped unless allowDestructive
+// is set.
+func Load(dirs []string, allowDestructive bool) (*Set, error) {
+	set := &Set{families: make(map[string][]Family)}
+	if err := set.loadPack("default", defaultPack, "default", allowDestructive); err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{"default": true}
+	for _, dir := range dirs {
+		dir = strings.TrimSpace(dir)
+		if dir == "" {
+			continue
+		}
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, fmt.Errorf("payload pack %s: %w", dir, err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("payload pack %s: not a directory", dir)
+		}
+		name := filepath.Base(filepath.Clean(dir))
+		if seen[name] {
+			return nil, fmt.Errorf("payload pack %s: pack name %q already loaded", dir, name)
+		}
+		seen[name] = true
+		if err := set.loadPack(name, os.DirFS(dir), ".", allowDestructive); err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+func (s *Set) loadPack(pack string, fsys fs.FS, root string, allowDestructive bool) error {
+	entries, err := fs.ReadDir(fsys, root)
+	if err != nil {
+		return fmt.Errorf("payload pack %s: %w", pack, err)
+	}
+	var files []string
+	for _, entry := range entries {
+		ext := strings.ToLower(path.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		files = append(files, path.Join(root, entry.Name()))
+	}
+	sort.Strings(files)
+	if len(files) == 0 {
+		return fmt.Errorf("payload pack %s: no .yaml files", pack)
+	}
+
+	inPack := make(map[string]string)
+	for _, file := range files {
+		raw, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return fmt.Errorf("payload pack %s: %w", pack, err)
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(raw))
+		decoder.KnownFields(true)
+		var parsed packFile
+		if err := decoder.Decode(&parsed); err != nil {
+			return fmt.Errorf("payload pack %s: %s: %w", pack, path.Base(file), err)
+		}
+		for i := range parsed.Families {
+			family := parsed.Families[i]
+			if err := validate(&family); err != nil {
+				return fmt.Errorf("payload pack %s: %s: family #%d %q: %w", pack, path.Base(file), i+1, family.Name, err)
+			}
+			key := family.Step + "/" + family.Name
+			if other, dup := inPack[key]; dup {
+				return fmt.Errorf("payload pack %s: %s: family %q for %s is already defined in %s", pack, path.Base(file), family.Name, family.Step, other)
+			}
+			inPack[key] = path.Base(file)
+			if family.Destructive() && !allowDestructive {
+				s.Skipped = append(s.Skipped, pack+"/"+family.Name)
+				continue
+			}
+			family.Packs = []string{pack}
+			s.add(family)
+		}
+	}
+	return nil
+}
+
+func (s *Set) add(family Family) {
+	list := s.families[family.Step]
+	for i := range list {
+		if list[i].Name != family.Name {
+			continue
+		}
+		current := &list[i]
+		current.Vectors = appendUnique(current.Vectors, containsFold, family.Vectors...)
+		current.Tech = appendUnique(current.Tech, containsFold, family.Tech...)
+		current.Tags = appendUnique(current.Tags, containsFold, family.Tags...)
+		current.Keywords = appendUnique(current.Keywords, containsFold, family.Keywords...)
+		current.Payloads = appendUnique(current.Payloads, containsExact, family.Payloads...)
+		current.Sleep = appendUnique(current.Sleep, containsExact, family.Sleep...)
+		current.OOB = appendUnique(current.OOB, containsExact, family.OOB...)
+		for _, chain := range family.Mutations {
+			if !containsChain(current.Mutations, chain) {
+				current.Mutations = append(current.Mutations, chain)
+			}
+		}
+		for j, expr := range family.Regexes {
+			if !containsExact(current.Regexes, expr) {
+				current.Regexes = append(current.Regexes, expr)
+				current.Compiled = append(current.Compiled, family.Compiled[j])
+			}
+		}
+		for k, v := range family.Headers {
+			if current.Headers == nil {
+				current.Headers = make(map[string]string)
+			}
+			current.Headers[k] = v
+		}
+		current.Packs = append(current.Packs, family.Packs...)
+		return
+	}
+	s.families[family.Step] = append(list, family)
+}
+
+func validate(f *Family) error {
+	f.Name = strings.TrimSpace(f.Name)
+	f.Step = strings.TrimSpace(f.Step)
+	if !familyNameRe.MatchString(f.Name) {
+		return errors.New("name must be lowercase letters, digits and underscores")
+	}
+	allowed, ok := stepVectors[f.Step]
+	if !ok {
+		return fmt.Errorf("unknown step %q (want %s, %s or %s)", f.Step, StepInjection, StepServerInput, StepAdvInjection)
+	}
+	if len(f.Vectors) == 0 {
+		return errors.New("vectors is empty")
+	}
+	for i, vector := range f.Vectors {
+		f.Vectors[i] = strings.ToLower(strings.TrimSpace(vector))
+		if !containsFold(allowed, f.Vectors[i]) {
+			return fmt.Errorf("vector %q is not supported by %s (supported: %s)", vector, f.Step, strings.Join(allowed, ", "))
+		}
+	}
+	if containsFold(f.Vectors, "xml") && len(f.Vectors) > 1 {
+		return errors.New("vector xml sends the payload as the whole body and cannot be combined with others")
+	}
+	if containsFold(f.Vectors, "xml") && len(f.Mutations) > 0 {
+		return errors.New("mutations do not apply to vector xml, which sends the payload as the whole body")
+	}
+	if containsFold(f.Tags, TagSafe) && containsFold(f.Tags, TagDestructive) {
+		return errors.New("tags safe and destructive are mutually exclusive")
+	}
+	f.Payloads = trimEmpty(f.Payloads)
+	f.Keywords = trimEmpty(f.Keywords)
+	f.Sleep = trimEmpty(f.Sleep)
+	f.OOB = trimEmpty(f.OOB)
+	if len(f.Payloads)+len(f.Sleep)+len(f.OOB) == 0 {
+		return errors.New("no payloads, sleep or oob templates")
+	}
+	for _, tmpl := range f.Sleep {
+		if !strings.Contains(tmpl, "{{delay}}") && !strings.Contains(tmpl, "{{delay_ms}}") {
+			return fmt.Errorf("sleep template %q has no {{delay}} or {{delay_ms}}", tmpl)
+		}
+	}
+	for _, tmpl := range f.OOB {
+		if !strings.Contains(tmpl, "{{oob}}") && !strings.Contains(tmpl, "{{oob_host}}") {
+			return fmt.Errorf("oob template %q has no {{oob}} or {{oob_host}}", tmpl)
+		}
+	}
+	for i, chain := range f.Mutations {
+		if len(chain) == 0 {
+			return fmt.Errorf("mutation #%d is an empty chain", i+1)
+		}
+		for j, name := range chain {
+			chain[j] = strings.ToLower(strings.TrimSpace(name))
+			if _, ok := Transforms[chain[j]]; !ok {
+				return fmt.Errorf("mutation #%d: unknown transform %q", i+1, name)
+			}
+		}
+	}
+	f.Compiled = nil
+	for _, expr := range f.Regexes {
+		re, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			return fmt.Errorf("regex %q: %w", expr, err)
+		}
+		f.Compiled = append(f.Compiled, re)
+	}
+	return nil
+}
+
+func trimEmpty(values []string) []string {
+	out := values[:0]
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func appendUnique(dst []string, contains func([]string, string) bool, values ...string) []string {
+	for _, v := range values {
+		if !contains(dst, v) {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
+
+func containsChain(chains [][]string, want []string) bool {
+	for _, chain := range chains {
+		if strings.Join(chain, "|") == strings.Join(want, "|") {
+			return true
+		}
+	}
+	return false
+}
+
+func containsExact(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, want string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), strings.TrimSpace(want)) {
+			return true
+		}
+	}
+	return false
+}
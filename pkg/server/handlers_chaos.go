@@ -95,7 +95,7 @@ func (s *Server) chaosHandler(w http.ResponseWriter, r *http.Request) {
 		key = defaultChaosAPIKey
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := s.httpClient(10 * time.Second)
 	groups := make([]chaosGroup, len(mainDomains))
 	sem := make(chan struct{}, chaosParallelism)
 	var wg sync.WaitGroup
@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/rojo/hack/web_bounty_flow/pkg/configstore"
 )
@@ -148,6 +149,15 @@ func (s *Server) applyNetworkSettingsToApp() {
 	s.app.SetProxy(proxyEnabled, host, port)
 }
 
+// httpClient returns a client on the app's shared transport, which follows the Tor and
+// proxy settings applied at startup and on every network update.
+func (s *Server) httpClient(timeout time.Duration) *http.Client {
+	if s.app == nil {
+		return &http.Client{Timeout: timeout}
+	}
+	return s.app.HTTPClient(timeout)
+}
+
 func (s *Server) loadProxySettings() (bool, string, int) {
 	if s.configStore == nil {
 		if strings.TrimSpace(s.proxyHost) == "" {
@@ -326,6 +336,10 @@ func (s *Server) handleKeys(provider string, rest []string, w http.ResponseWrite
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		if err := validateKeyValue(provider, payload.Value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		key := configstore.DecryptedKey{
 			Label:  payload.Label,
 			Value:  payload.Value,
@@ -348,6 +362,10 @@ func (s *Server) handleKeys(provider string, rest []string, w http.ResponseWrite
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		if err := validateKeyValue(provider, payload.Value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		key := configstore.DecryptedKey{
 			ID:     rest[0],
 			Label:  payload.Label,
@@ -376,6 +394,16 @@ func (s *Server) handleKeys(provider string, rest []string, w http.ResponseWrite
 	}
 }
 
+// validateKeyValue rejects values the app could not use for providers with structured
+// values, so a broken session profile fails on save rather than mid-run.
+func validateKeyValue(provider, value string) error {
+	if provider == configstore.SessionProvider {
+		_, err := configstore.ParseSessionProfile(value)
+		return err
+	}
+	return nil
+}
+
 func (s *Server) githubRunHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
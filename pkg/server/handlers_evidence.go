@@ -0,0 +1,47 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/rojo/hack/web_bounty_flow/pkg/app"
+)
+
+// evidenceHandler serves one stored request/response pair from findings' evidence_ids.
+// ?part=request or ?part=response returns that side as raw HTTP text instead of JSON.
+func (s *Server) evidenceHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(r.URL.Path, "/api/evidence/")))
+	ev, err := app.ReadEvidence(filepath.Dir(s.cfg.Lists.Domains), id)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.Error(w, "evidence not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	switch r.URL.Query().Get("part") {
+	case "request":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(ev.Request))
+	case "response":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(ev.Response))
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":        id,
+			"request":   ev.Request,
+			"response":  ev.Response,
+			"truncated": ev.Truncated,
+		})
+	}
+}
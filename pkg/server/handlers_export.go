@@ -0,0 +1,351 @@
+package server
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rojo/hack/web_bounty_flow/pkg/app"
+	"github.com/rojo/hack/web_bounty_flow/pkg/configstore"
+)
+
+// leadExportFormats maps ?format= to the response content type and file extension.
+var leadExportFormats = map[string][2]string{
+	"curl":   {"text/plain; charset=utf-8", "sh"},
+	"raw":    {"text/plain; charset=utf-8", "http"},
+	"python": {"text/plain; charset=utf-8", "py"},
+	"burp":   {"application/xml; charset=utf-8", "xml"},
+}
+
+// leadExportHandler serves /api/leads/{id}/export?format=curl|raw|python|burp.
+func (s *Server) leadExportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// Lead ids embed URLs and payloads, so the id is taken from the escaped path.
+	escaped, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.EscapedPath(), "/api/leads/"), "/export")
+	id, err := url.PathUnescape(escaped)
+	if !ok || err != nil || strings.TrimSpace(id) == "" {
+		http.NotFound(w, r)
+		return
+	}
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	if format == "" {
+		format = "curl"
+	}
+	spec, ok := leadExportFormats[format]
+	if !ok {
+		http.Error(w, "format must be curl, raw, python or burp", http.StatusBadRequest)
+		return
+	}
+	lead, err := s.findLead(strings.TrimSpace(id))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if lead == nil {
+		http.Error(w, "lead not found", http.StatusNotFound)
+		return
+	}
+	req, body, err := s.leadRequest(lead)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var out string
+	switch format {
+	case "curl":
+		out = exportCurl(req, body)
+	case "raw":
+		out = app.RawRequest(req, body)
+	case "python":
+		out = exportPython(req, body)
+	case "burp":
+		out, err = exportBurp(req, body, lead)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", spec[0])
+	sum := sha256.Sum256([]byte(lead.ID))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", "lead-"+hex.EncodeToString(sum[:4])+"."+spec[1]))
+	_, _ = io.WriteString(w, out)
+}
+
+// leadRequest rebuilds the mutated request behind a lead. Query, form, JSON, header and
+// cookie vectors are rebuilt from the finding fields with the session profile the probe
+// ran under; anything else (spec requests, XML bodies, tampered forms) comes from the
+// stored evidence of the mutated probe, which already carries its session headers.
+func (s *Server) leadRequest(lead *leadItem) (*http.Request, []byte, error) {
+	ev := lead.Evidence
+	target := strings.TrimSpace(firstNonEmptyString(asRawString(ev["mutated_url"]), asRawString(ev["endpoint"]), asRawString(ev["url"]), lead.Target))
+	method := strings.ToUpper(strings.TrimSpace(firstNonEmptyString(asRawString(ev["method"]), http.MethodGet)))
+	param := asRawString(ev["param"])
+	payload := asRawString(ev["payload"])
+	vector := strings.ToLower(strings.TrimSpace(asRawString(ev["vector"])))
+
+	var body []byte
+	headers := make(map[string]string)
+	switch vector {
+	case "url-query":
+		if asRawString(ev["mutated_url"]) == "" && param != "" {
+			target = mutateQueryParam(target, param, payload)
+		}
+	case "x-www-form-urlencoded":
+		body = []byte(url.Values{param: []string{payload}}.Encode())
+		headers["Content-Type"] = "application/x-www-form-urlencoded"
+	case "json", "json-body":
+		body, _ = json.Marshal(map[string]string{param: payload})
+		headers["Content-Type"] = "application/json"
+	case "request-header":
+		headers[param] = payload
+	case "cookie":
+		headers["Cookie"] = param + "=" + payload
+	default:
+		ids := asStringSlice(ev["evidence_ids"])
+		if len(ids) > 0 {
+			return s.evidenceRequest(ids[len(ids)-1], target)
+		}
+	}
+
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, nil, fmt.Errorf("lead has no exportable url")
+	}
+	req, err := http.NewRequest(method, parsed.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if name := asRawString(ev["session_profile"]); name != "" {
+		profile, ok := s.sessionProfile(name)
+		if !ok {
+			return nil, nil, fmt.Errorf("session profile %q not found in the config store", name)
+		}
+		profile.Apply(req)
+	}
+	return req, body, nil
+}
+
+// evidenceRequest parses a stored raw request. The wire form has no scheme, so it is
+// taken from the finding URL.
+func (s *Server) evidenceRequest(id, target string) (*http.Request, []byte, error) {
+	stored, err := app.ReadEvidence(filepath.Dir(s.cfg.Lists.Domains), id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("evidence %s: %w", id, err)
+	}
+	req, err := http.ReadRequest(bufio.NewReader(strings.NewReader(stored.Request)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("evidence %s: %w", id, err)
+	}
+	body, _ := io.ReadAll(req.Body)
+	req.Body = http.NoBody
+	req.RequestURI = ""
+	req.URL.Scheme = "https"
+	if parsed, err := url.Parse(target); err == nil && parsed.Scheme == "http" {
+		req.URL.Scheme = "http"
+	}
+	req.URL.Host = req.Host
+	req.Header.Del("Content-Length")
+	return req, body, nil
+}
+
+func (s *Server) sessionProfile(name string) (configstore.SessionProfile, bool) {
+	if s.configStore == nil {
+		return configstore.SessionProfile{}, false
+	}
+	cfg, err := s.configStore.LoadDecrypted()
+	if err != nil {
+		return configstore.SessionProfile{}, false
+	}
+	provider := cfg.Providers[configstore.SessionProvider]
+	if provider == nil {
+		return configstore.SessionProfile{}, false
+	}
+	for _, key := range provider.Keys {
+		if key.ID != name && !strings.EqualFold(key.Label, name) {
+			continue
+		}
+		profile, err := configstore.ParseSessionProfile(key.Value)
+		return profile, err == nil
+	}
+	return configstore.SessionProfile{}, false
+}
+
+func mutateQueryParam(raw, param, value string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	query := parsed.Query()
+	query.Set(param, value)
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+func sortedHeaderLines(header http.Header) [][2]string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var lines [][2]string
+	for _, name := range names {
+		for _, value := range header[name] {
+			lines = append(lines, [2]string{name, value})
+		}
+	}
+	return lines
+}
+
+func shellQuote(v string) string {
+	return "'" + strings.ReplaceAll(v, "'", `'\''`) + "'"
+}
+
+func exportCurl(req *http.Request, body []byte) string {
+	var b strings.Builder
+	b.WriteString("curl -i -s -k -X " + shellQuote(req.Method))
+	if req.Host != "" && req.Host != req.URL.Host {
+		b.WriteString(" \\\n    -H " + shellQuote("Host: "+req.Host))
+	}
+	for _, line := range sortedHeaderLines(req.Header) {
+		b.WriteString(" \\\n    -H " + shellQuote(line[0]+": "+line[1]))
+	}
+	if len(body) > 0 {
+		b.WriteString(" \\\n    --data-binary " + shellQuote(string(body)))
+	}
+	b.WriteString(" \\\n    " + shellQuote(req.URL.String()) + "\n")
+	return b.String()
+}
+
+// pythonBytes renders b as a Python bytes literal so non-UTF-8 payloads survive.
+func pythonBytes(b []byte) string {
+	var out strings.Builder
+	out.WriteString(`b"`)
+	for _, c := range b {
+		switch {
+		case c == '\\' || c == '"':
+			out.WriteByte('\\')
+			out.WriteByte(c)
+		case c >= 0x20 && c < 0x7f:
+			out.WriteByte(c)
+		default:
+			fmt.Fprintf(&out, `\x%02x`, c)
+		}
+	}
+	out.WriteByte('"')
+	return out.String()
+}
+
+func exportPython(req *http.Request, body []byte) string {
+	var b strings.Builder
+	b.WriteString("import requests\n\n")
+	b.WriteString("url = " + strconv.QuoteToASCII(req.URL.String()) + "\n")
+	b.WriteString("headers = {\n")
+	if req.Host != "" && req.Host != req.URL.Host {
+		b.WriteString("    \"Host\": " + strconv.QuoteToASCII(req.Host) + ",\n")
+	}
+	for _, line := range sortedHeaderLines(req.Header) {
+		b.WriteString("    " + strconv.QuoteToASCII(line[0]) + ": " + strconv.QuoteToASCII(line[1]) + ",\n")
+	}
+	b.WriteString("}\n")
+	data := "None"
+	if len(body) > 0 {
+		data = pythonBytes(body)
+	}
+	b.WriteString("data = " + data + "\n\n")
+	b.WriteString("response = requests.request(" + strconv.QuoteToASCII(req.Method) + ", url, headers=headers, data=data, verify=False, allow_redirects=False)\n")
+	b.WriteString("print(response.status_code, len(response.content))\n")
+	return b.String()
+}
+
+type burpCDATA struct {
+	Value string `xml:",cdata"`
+}
+
+type burpHost struct {
+	IP   string `xml:"ip,attr"`
+	Name string `xml:",chardata"`
+}
+
+type burpBase64 struct {
+	Base64 bool   `xml:"base64,attr"`
+	Value  string `xml:",cdata"`
+}
+
+type burpItem struct {
+	Time           string     `xml:"time"`
+	URL            burpCDATA  `xml:"url"`
+	Host           burpHost   `xml:"host"`
+	Port           string     `xml:"port"`
+	Protocol       string     `xml:"protocol"`
+	Method         burpCDATA  `xml:"method"`
+	Path           burpCDATA  `xml:"path"`
+	Extension      string     `xml:"extension"`
+	Request        burpBase64 `xml:"request"`
+	Status         string     `xml:"status"`
+	ResponseLength string     `xml:"responselength"`
+	MimeType       string     `xml:"mimetype"`
+	Response       burpBase64 `xml:"response"`
+	Comment        string     `xml:"comment"`
+}
+
+type burpItems struct {
+	XMLName    xml.Name   `xml:"items"`
+	ExportTime string     `xml:"exportTime,attr"`
+	Items      []burpItem `xml:"item"`
+}
+
+// exportBurp writes Burp's saved-items XML, which "Paste from file" and the importers
+// read. The request goes base64-encoded so payload bytes are kept as sent.
+func exportBurp(req *http.Request, body []byte, lead *leadItem) (string, error) {
+	now := time.Now().UTC().Format("Mon Jan 02 15:04:05 MST 2006")
+	port := req.URL.Port()
+	if port == "" {
+		port = "443"
+		if req.URL.Scheme == "http" {
+			port = "80"
+		}
+	}
+	extension := strings.TrimPrefix(path.Ext(req.URL.Path), ".")
+	if extension == "" {
+		extension = "null"
+	}
+	comment := strings.TrimSpace(lead.Category + " " + lead.Family)
+	item := burpItem{
+		Time:      now,
+		URL:       burpCDATA{req.URL.String()},
+		Host:      burpHost{Name: req.URL.Hostname()},
+		Port:      port,
+		Protocol:  req.URL.Scheme,
+		Method:    burpCDATA{req.Method},
+		Path:      burpCDATA{req.URL.RequestURI()},
+		Extension: extension,
+		Request:   burpBase64{Base64: true, Value: base64.StdEncoding.EncodeToString([]byte(app.RawRequest(req, body)))},
+		Response:  burpBase64{Base64: true},
+		Comment:   comment,
+	}
+	out, err := xml.MarshalIndent(burpItems{ExportTime: now, Items: []burpItem{item}}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(out) + "\n", nil
+}
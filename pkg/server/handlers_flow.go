@@ -2,6 +2,8 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 )
 
@@ -11,6 +13,15 @@ func (s *Server) runHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var payload runPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s.app != nil {
+		s.app.SetSessionProfile(payload.Session)
+	}
+
 	if err := s.startFlow(); err != nil {
 		http.Error(w, err.Error(), http.StatusConflict)
 		return
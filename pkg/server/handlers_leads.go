@@ -12,6 +12,8 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/rojo/hack/web_bounty_flow/pkg/configstore"
 )
 
 type leadStatePayload struct {
@@ -91,18 +93,11 @@ func (s *Server) leadReplayHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "lead id is required", http.StatusBadRequest)
 		return
 	}
-	leads, _, err := s.collectLeads()
+	selected, err := s.findLead(id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	var selected *leadItem
-	for i := range leads {
-		if leads[i].ID == id {
-			selected = &leads[i]
-			break
-		}
-	}
 	if selected == nil {
 		http.Error(w, "lead not found", http.StatusNotFound)
 		return
@@ -141,13 +136,7 @@ func (s *Server) leadReplayHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	proxyEnabled, proxyURL := s.currentProxyURL()
-	client := &http.Client{Timeout: 20 * time.Second}
-	if proxyEnabled {
-		proxyParsed, parseErr := url.Parse(proxyURL)
-		if parseErr == nil {
-			client.Transport = &http.Transport{Proxy: http.ProxyURL(proxyParsed)}
-		}
-	}
+	client := s.httpClient(20 * time.Second)
 	started := time.Now()
 	resp, err := client.Do(req)
 	if err != nil {
@@ -172,6 +161,19 @@ func (s *Server) leadReplayHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (s *Server) findLead(id string) (*leadItem, error) {
+	leads, _, err := s.collectLeads()
+	if err != nil {
+		return nil, err
+	}
+	for i := range leads {
+		if leads[i].ID == id {
+			return &leads[i], nil
+		}
+	}
+	return nil, nil
+}
+
 func (s *Server) collectLeads() ([]leadItem, time.Time, error) {
 	baseDir := filepath.Dir(s.cfg.Lists.Domains)
 	fuzzDir := filepath.Join(baseDir, "fuzzing")
@@ -192,6 +194,7 @@ func (s *Server) collectLeads() ([]leadItem, time.Time, error) {
 		{category: "adv-injection", source: "adv-injection/soap_hits.jsonl", path: filepath.Join(fuzzDir, "adv-injection", "soap_hits.jsonl")},
 		{category: "adv-injection", source: "adv-injection/ssrf_hits.jsonl", path: filepath.Join(fuzzDir, "adv-injection", "ssrf_hits.jsonl")},
 		{category: "adv-injection", source: "adv-injection/smtp_hits.jsonl", path: filepath.Join(fuzzDir, "adv-injection", "smtp_hits.jsonl")},
+		{category: "oob", source: "oob/interactions.jsonl", path: filepath.Join(fuzzDir, "oob", "interactions.jsonl")},
 		{category: "csrf", source: "csrf/findings.jsonl", path: filepath.Join(fuzzDir, "csrf", "findings.jsonl")},
 		{category: "clickjacking", source: "clickjacking/findings.jsonl", path: filepath.Join(fuzzDir, "clickjacking", "findings.jsonl")},
 		{category: "cors", source: "cors/findings.jsonl", path: filepath.Join(fuzzDir, "cors", "findings.jsonl")},
@@ -200,7 +203,32 @@ func (s *Server) collectLeads() ([]leadItem, time.Time, error) {
 		{category: "xss", source: "xss/reflected_hits.jsonl", path: filepath.Join(fuzzDir, "xss", "reflected_hits.jsonl")},
 		{category: "xss", source: "xss/dom_hits.jsonl", path: filepath.Join(fuzzDir, "xss", "dom_hits.jsonl")},
 		{category: "xss", source: "xss/stored_hits.jsonl", path: filepath.Join(fuzzDir, "xss", "stored_hits.jsonl")},
+		{category: "secrets", source: "recon/js_secrets.jsonl", path: filepath.Join(baseDir, "recon", "js_secrets.jsonl")},
+		{category: "sourcemap", source: "recon/sourcemaps/sourcemaps.jsonl", path: filepath.Join(baseDir, "recon", "sourcemaps", "sourcemaps.jsonl")},
+		{category: "authz", source: "api-specs/authz_findings.jsonl", path: filepath.Join(fuzzDir, "api-specs", "authz_findings.jsonl")},
+		{category: "graphql", source: "graphql/findings.jsonl", path: filepath.Join(fuzzDir, "graphql", "findings.jsonl")},
+		{category: "forms", source: "forms/findings.jsonl", path: filepath.Join(fuzzDir, "forms", "findings.jsonl")},
+	}
+	// Families added by payload packs write <family>_hits.jsonl next to the bundled ones.
+	listed := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		listed[spec.path] = true
 	}
+	for _, category := range []string{"injection", "server-input", "adv-injection"} {
+		matches, _ := filepath.Glob(filepath.Join(fuzzDir, category, "*_hits.jsonl"))
+		for _, path := range matches {
+			if listed[path] {
+				continue
+			}
+			specs = append(specs, struct {
+				category string
+				source   string
+				path     string
+			}{category: category, source: category + "/" + filepath.Base(path), path: path})
+		}
+	}
+	siblings := loadHostClusterSiblings(filepath.Join(baseDir, "recon", "host_clusters.jsonl"))
+	expiries := loadSessionExpiries(filepath.Join(baseDir, "recon", "session_events.jsonl"))
 	var leads []leadItem
 	latest := time.Time{}
 	for _, spec := range specs {
@@ -213,6 +241,10 @@ func (s *Server) collectLeads() ([]leadItem, time.Time, error) {
 			if lead.ID == "" || lead.Domain == "" {
 				continue
 			}
+			lead.SiblingHosts = siblings[strings.ToLower(lead.Domain)]
+			if sessionExpiredAt(expiries, lead.Domain, lead.Timestamp) {
+				lead.Reasons = append(lead.Reasons, "session_expired")
+			}
 			leads = append(leads, lead)
 		}
 	}
@@ -246,6 +278,87 @@ func (s *Server) collectLeads() ([]leadItem, time.Time, error) {
 	return uniqueLeads, latest, nil
 }
 
+// sessionExpiry is a window in which a session profile was logged out; Until is zero
+// while it was never restored.
+type sessionExpiry struct {
+	profile configstore.SessionProfile
+	from    time.Time
+	until   time.Time
+}
+
+// loadSessionExpiries pairs "expired" events from the app's session log with the next
+// "restored" or "loaded" event for the same profile; a run that loads the profile again
+// starts with a fresh session.
+func loadSessionExpiries(path string) []sessionExpiry {
+	rows, _ := readJSONLRecords(path)
+	var out []sessionExpiry
+	pending := make(map[string]int)
+	for _, row := range rows {
+		at, err := time.Parse(time.RFC3339, asRawString(row["timestamp"]))
+		if err != nil {
+			continue
+		}
+		name := asRawString(row["profile"])
+		switch asRawString(row["event"]) {
+		case "expired":
+			var hosts []string
+			raw, _ := row["hosts"].([]any)
+			for _, host := range raw {
+				hosts = append(hosts, asRawString(host))
+			}
+			pending[name] = len(out)
+			out = append(out, sessionExpiry{profile: configstore.SessionProfile{Hosts: hosts}, from: at})
+		case "restored", "loaded":
+			if idx, ok := pending[name]; ok {
+				out[idx].until = at
+				delete(pending, name)
+			}
+		}
+	}
+	return out
+}
+
+// sessionExpiredAt reports whether a lead on host was recorded while a session bound to
+// that host was logged out, i.e. it likely reflects an anonymous view.
+func sessionExpiredAt(expiries []sessionExpiry, host, timestamp string) bool {
+	if len(expiries) == 0 {
+		return false
+	}
+	at, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return false
+	}
+	for _, expiry := range expiries {
+		if at.Before(expiry.from) || (!expiry.until.IsZero() && !at.Before(expiry.until)) {
+			continue
+		}
+		if expiry.profile.AppliesTo(host) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadHostClusterSiblings maps each host to the look-alike hosts sharing its cluster,
+// so a lead found on a representative points at the siblings it likely applies to.
+func loadHostClusterSiblings(path string) map[string][]string {
+	out := make(map[string][]string)
+	rows, _ := readJSONLRecords(path)
+	for _, row := range rows {
+		host := strings.ToLower(strings.TrimSpace(asRawString(row["host"])))
+		if host == "" {
+			continue
+		}
+		raw, _ := row["siblings"].([]any)
+		for _, sibling := range raw {
+			if name := strings.TrimSpace(asRawString(sibling)); name != "" {
+				out[host] = append(out[host], name)
+			}
+		}
+	}
+	return out
+}
+
 func buildWildcardGroups(leads []leadItem) []leadsWildcardGroup {
 	wildcardBuckets := make(map[string]map[string][]leadItem)
 	for _, lead := range leads {
@@ -7,7 +7,12 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/rojo/hack/web_bounty_flow/pkg/app"
 )
 
 func (s *Server) uploadHandler(w http.ResponseWriter, r *http.Request) {
@@ -27,18 +32,34 @@ func (s *Server) uploadHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	file, _, err := r.FormFile("file")
+	file, header, err := r.FormFile("file")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 	defer file.Close()
 
+	// Captured traffic is merged into the corpus rather than replacing it.
+	if list == "request_corpus" {
+		if _, err := s.app.ImportRequests("", file, header.Filename); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(uploadResponse{Status: "uploaded"})
+		return
+	}
+
 	dest, err := s.listPath(list)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	// Specs are kept side by side rather than overwriting one list; api-specs expands
+	// every file in the uploads directory on its next run.
+	if list == "api_specs" {
+		dest = apiSpecUploadPath(app.APISpecUploadsDir(filepath.Dir(s.cfg.Lists.Domains)), header.Filename)
+	}
 
 	if err := s.saveTemporaryFile(dest, file); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -49,6 +70,33 @@ func (s *Server) uploadHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(uploadResponse{Status: "uploaded"})
 }
 
+// importRequestsHandler merges a HAR file or Burp XML export into the request corpus.
+// format is optional and detected from the file content when omitted.
+func (s *Server) importRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseMultipartForm(64 << 20); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	summary, err := s.app.ImportRequests(r.FormValue("format"), file, header.Filename)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
 func (s *Server) urlHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -131,6 +179,9 @@ func (s *Server) listHandler(w http.ResponseWriter, r *http.Request) {
 			present = true
 		}
 		lines := readListLines(dest)
+		if listType == "url_corpus" {
+			lines = filterURLCorpusLines(lines, r)
+		}
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(map[string]any{
 			"present": present,
@@ -161,6 +212,44 @@ func (s *Server) listHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// filterURLCorpusLines narrows url_corpus entries with optional comma-separated
+// source= and status= parameters (e.g. status=2xx,301) and max_age_hours=.
+func filterURLCorpusLines(lines []string, r *http.Request) []string {
+	query := r.URL.Query()
+	filter := app.URLCorpusFilter{
+		Sources:  splitQueryList(query.Get("source")),
+		Statuses: splitQueryList(query.Get("status")),
+	}
+	if hours, err := strconv.Atoi(query.Get("max_age_hours")); err == nil && hours > 0 {
+		filter.MaxAge = time.Duration(hours) * time.Hour
+	}
+	if !filter.Active() {
+		return lines
+	}
+	now := time.Now()
+	var out []string
+	for _, line := range lines {
+		var rec app.URLCorpusRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			continue
+		}
+		if filter.Match(rec, now) {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+func splitQueryList(raw string) []string {
+	var out []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
 func (s *Server) notesHandler(w http.ResponseWriter, r *http.Request) {
 	name := strings.TrimSpace(strings.ToLower(r.URL.Query().Get("name")))
 	path, err := notePath(name)
@@ -215,3 +304,14 @@ func notePath(name string) (string, error) {
 		return "", fmt.Errorf("unsupported note name %q", name)
 	}
 }
+
+var apiSpecUploadNameRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func apiSpecUploadPath(uploadsDir, filename string) string {
+	name := apiSpecUploadNameRe.ReplaceAllString(filepath.Base(filename), "_")
+	name = strings.TrimLeft(name, ".")
+	if name == "" {
+		name = "spec.json"
+	}
+	return filepath.Join(uploadsDir, name)
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type screenshotsResponse struct {
+	Present  bool             `json:"present"`
+	Count    int              `json:"count"`
+	Rows     []map[string]any `json:"rows"`
+	Clusters []map[string]any `json:"clusters"`
+}
+
+// screenshotsHandler lists captures (optionally filtered by ?cluster=) and serves
+// the PNGs themselves under /api/screenshots/<file>.
+func (s *Server) screenshotsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	outDir := filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "screenshots")
+
+	if name := strings.TrimPrefix(r.URL.Path, "/api/screenshots/"); name != r.URL.Path && name != "" {
+		if name != filepath.Base(name) || !strings.HasSuffix(strings.ToLower(name), ".png") {
+			http.Error(w, "invalid screenshot name", http.StatusBadRequest)
+			return
+		}
+		path := filepath.Join(outDir, "png", name)
+		if _, err := os.Stat(path); err != nil {
+			http.Error(w, "screenshot not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		http.ServeFile(w, r, path)
+		return
+	}
+
+	resp := screenshotsResponse{Rows: []map[string]any{}, Clusters: []map[string]any{}}
+	rows, _ := readJSONLRecords(filepath.Join(outDir, "screenshots.jsonl"))
+	cluster := strings.TrimSpace(r.URL.Query().Get("cluster"))
+	for _, row := range rows {
+		if cluster != "" && asRawString(row["cluster"]) != cluster {
+			continue
+		}
+		resp.Rows = append(resp.Rows, row)
+	}
+	if raw, err := os.ReadFile(filepath.Join(outDir, "clusters.json")); err == nil {
+		_ = json.Unmarshal(raw, &resp.Clusters)
+	}
+	resp.Count = len(resp.Rows)
+	resp.Present = len(rows) > 0
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
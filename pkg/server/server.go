@@ -86,6 +86,7 @@ func New(cfg *config.Config) *Server {
 
 	s.mux.HandleFunc("/api/upload", s.corsMiddleware(s.uploadHandler))
 	s.mux.HandleFunc("/api/url", s.corsMiddleware(s.urlHandler))
+	s.mux.HandleFunc("/api/import/requests", s.corsMiddleware(s.importRequestsHandler))
 	s.mux.HandleFunc("/api/run", s.corsMiddleware(s.runHandler))
 	s.mux.HandleFunc("/api/run/stop", s.corsMiddleware(s.stopHandler))
 	s.mux.HandleFunc("/api/run/pause", s.corsMiddleware(s.pauseHandler))
@@ -102,11 +103,15 @@ func New(cfg *config.Config) *Server {
 	s.mux.HandleFunc("/api/notes", s.corsMiddleware(s.notesHandler))
 	s.mux.HandleFunc("/api/tools", s.corsMiddleware(s.toolsHandler))
 	s.mux.HandleFunc("/api/live-webservers", s.corsMiddleware(s.liveWebserversHandler))
+	s.mux.HandleFunc("/api/screenshots", s.corsMiddleware(s.screenshotsHandler))
+	s.mux.HandleFunc("/api/screenshots/", s.corsMiddleware(s.screenshotsHandler))
 	s.mux.HandleFunc("/api/amass-enum", s.corsMiddleware(s.amassEnumHandler))
 	s.mux.HandleFunc("/api/progress/subdomain", s.corsMiddleware(s.subdomainProgressHandler))
 	s.mux.HandleFunc("/api/leads", s.corsMiddleware(s.leadsHandler))
 	s.mux.HandleFunc("/api/leads/state", s.corsMiddleware(s.leadStateHandler))
 	s.mux.HandleFunc("/api/leads/replay", s.corsMiddleware(s.leadReplayHandler))
+	s.mux.HandleFunc("/api/leads/", s.corsMiddleware(s.leadExportHandler))
+	s.mux.HandleFunc("/api/evidence/", s.corsMiddleware(s.evidenceHandler))
 	s.mux.HandleFunc("/api/chaos", s.corsMiddleware(s.chaosHandler))
 	s.mux.HandleFunc("/api/manual/xss/run", s.corsMiddleware(s.manualXSSRunHandler))
 	s.mux.HandleFunc("/api/manual/xss/status", s.corsMiddleware(s.manualXSSStatusHandler))
@@ -132,6 +137,12 @@ type keyPayload struct {
 	Active bool   `json:"active"`
 }
 
+// runPayload optionally names the session profile for the run; empty applies every
+// active profile.
+type runPayload struct {
+	Session string `json:"session"`
+}
+
 type settingsPayload struct {
 	AutoRun bool `json:"auto_run"`
 }
@@ -243,6 +254,7 @@ type liveWebserverRow struct {
 	WebServer     string   `json:"web_server"`
 	Technologies  []string `json:"technologies"`
 	ContentLength int      `json:"content_length"`
+	Port          string   `json:"port,omitempty"`
 }
 
 type amassEnumRow struct {
@@ -266,6 +278,7 @@ type leadItem struct {
 	Reasons      []string       `json:"reasons,omitempty"`
 	ManualAction string         `json:"manual_action,omitempty"`
 	Evidence     map[string]any `json:"evidence,omitempty"`
+	SiblingHosts []string       `json:"sibling_hosts,omitempty"`
 	Source       string         `json:"source"`
 	Timestamp    string         `json:"timestamp,omitempty"`
 	Done         bool           `json:"done"`
@@ -638,7 +651,7 @@ func (s *Server) runManualPlaywrightXSS(target, authHeader, mode string) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 12*time.Minute)
 	defer cancel()
-	nodeBin, err := resolveNodeBinary()
+	nodeBin, err := app.ResolveNodeBinary()
 	if err != nil {
 		s.setManualXSSStatus("error: node runtime not found in PATH or ~/.nvm; install Node.js or restart server with nvm loaded")
 		return
@@ -665,41 +678,6 @@ func (s *Server) runManualPlaywrightXSS(target, authHeader, mode string) {
 	s.setManualXSSStatus("done")
 }
 
-func resolveNodeBinary() (string, error) {
-	if path, err := exec.LookPath("node"); err == nil {
-		return path, nil
-	}
-
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", errors.New("unable to resolve home directory")
-	}
-
-	patterns := []string{
-		filepath.Join(home, ".nvm", "versions", "node", "*", "bin", "node"),
-		filepath.Join(home, ".volta", "bin", "node"),
-		filepath.Join(home, ".asdf", "shims", "node"),
-	}
-	var candidates []string
-	for _, pattern := range patterns {
-		matches, _ := filepath.Glob(pattern)
-		candidates = append(candidates, matches...)
-	}
-	sort.Strings(candidates)
-	for i := len(candidates) - 1; i >= 0; i-- {
-		candidate := candidates[i]
-		info, statErr := os.Stat(candidate)
-		if statErr != nil || info.IsDir() {
-			continue
-		}
-		if info.Mode()&0o111 == 0 {
-			continue
-		}
-		return candidate, nil
-	}
-	return "", errors.New("node binary not found")
-}
-
 func (s *Server) setManualXSSStatus(status string) {
 	s.xssMu.Lock()
 	s.xssStatus = status
@@ -943,6 +921,7 @@ func (s *Server) inferCompletedStepsFromArtifacts() {
 	)
 	doneIfPending("robots-sitemaps",
 		fileExists(filepath.Join(s.cfg.Paths.RobotsDir, "robots_urls.txt")) ||
+			fileExists(filepath.Join(s.cfg.Paths.RobotsDir, "robots.jsonl")) ||
 			fileExists(filepath.Join(s.cfg.Paths.RobotsDir, "_hits.txt")) ||
 			fileExists(s.cfg.Paths.SitemapsFile),
 	)
@@ -958,6 +937,17 @@ func (s *Server) inferCompletedStepsFromArtifacts() {
 		fileExists(filepath.Join(reconDir, "all_urls.txt")) ||
 			fileExists(filepath.Join(reconDir, "urls_all.txt")),
 	)
+	doneIfPending("js-intel", fileExists(filepath.Join(reconDir, "js_intel.jsonl")))
+	doneIfPending("source-maps", fileExists(filepath.Join(reconDir, "sourcemaps", "sourcemaps.jsonl")))
+	doneIfPending("url-templates", fileExists(filepath.Join(reconDir, "url_templates.jsonl")))
+	doneIfPending("host-roles", fileExists(filepath.Join(reconDir, "host_roles.jsonl")))
+	doneIfPending("host-clusters", fileExists(filepath.Join(reconDir, "host_clusters.jsonl")))
+	doneIfPending("waf-detect", fileExists(filepath.Join(reconDir, "waf_state.jsonl")))
+	doneIfPending("tech-profile", fileExists(filepath.Join(reconDir, "tech_profile.jsonl")))
+	doneIfPending("screenshots", fileExists(filepath.Join(baseDir, "screenshots", "screenshots.jsonl")))
+	doneIfPending("api-specs", fileExists(filepath.Join(reconDir, "api_specs", "api_requests.jsonl")))
+	doneIfPending("graphql", fileExists(filepath.Join(baseDir, "fuzzing", "graphql", "endpoints.jsonl")))
+	doneIfPending("form-tamper", fileExists(filepath.Join(baseDir, "fuzzing", "forms", "forms.jsonl")))
 	doneIfPending("param-fuzz",
 		fileExists(filepath.Join(baseDir, "fuzzing", "params", "query_hits.jsonl")) ||
 			fileExists(filepath.Join(baseDir, "fuzzing", "params", "body_hits.jsonl")) ||
@@ -1196,6 +1186,8 @@ func (s *Server) clearResults() error {
 	targets := []string{
 		filepath.Join(baseDir, "recon"),
 		filepath.Join(baseDir, "fuzzing"),
+		filepath.Join(baseDir, "screenshots"),
+		app.EvidenceDir(baseDir),
 		s.cfg.Paths.RobotsDir,
 		s.cfg.Paths.DorkingDir,
 		filepath.Join(s.cfg.Paths.LogsDir, "runops"),
@@ -1356,12 +1348,52 @@ func (s *Server) listPath(name string) (string, error) {
 		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "fuzzing", "ffuf", "dir_hits.txt"), nil
 	case "robots_urls":
 		return filepath.Join(s.cfg.Paths.RobotsDir, "robots_urls.txt"), nil
+	case "robots_rules":
+		return filepath.Join(s.cfg.Paths.RobotsDir, "robots.jsonl"), nil
+	case "sitemap_urls":
+		return filepath.Join(s.cfg.Paths.RobotsDir, "sitemaps.jsonl"), nil
 	case "wayback_urls":
 		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "recon", "waybackurls_urls.txt"), nil
 	case "katana_urls":
 		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "recon", "katana_urls.txt"), nil
 	case "all_urls":
 		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "recon", "all_urls.txt"), nil
+	case "url_corpus":
+		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "recon", "url_corpus.jsonl"), nil
+	case "js_intel":
+		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "recon", "js_intel.jsonl"), nil
+	case "js_endpoints":
+		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "recon", "js_endpoints.txt"), nil
+	case "js_secrets":
+		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "recon", "js_secrets.jsonl"), nil
+	case "sourcemaps":
+		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "recon", "sourcemaps", "sourcemaps.jsonl"), nil
+	case "api_specs":
+		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "recon", "api_specs", "api_requests.jsonl"), nil
+	case "api_spec_authz":
+		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "fuzzing", "api-specs", "authz_findings.jsonl"), nil
+	case "graphql_endpoints":
+		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "fuzzing", "graphql", "endpoints.jsonl"), nil
+	case "graphql_findings":
+		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "fuzzing", "graphql", "findings.jsonl"), nil
+	case "form_inventory":
+		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "fuzzing", "forms", "forms.jsonl"), nil
+	case "form_findings":
+		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "fuzzing", "forms", "findings.jsonl"), nil
+	case "request_corpus":
+		return app.RequestCorpusPath(filepath.Dir(s.cfg.Lists.Domains)), nil
+	case "session_events":
+		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "recon", "session_events.jsonl"), nil
+	case "url_templates":
+		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "recon", "url_templates.jsonl"), nil
+	case "host_roles":
+		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "recon", "host_roles.jsonl"), nil
+	case "host_clusters":
+		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "recon", "host_clusters.jsonl"), nil
+	case "waf_state":
+		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "recon", "waf_state.jsonl"), nil
+	case "tech_profile":
+		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "recon", "tech_profile.jsonl"), nil
 	case "params_candidates":
 		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "recon", "params_candidates.txt"), nil
 	case "param_fuzz_query_hits":
@@ -1394,6 +1426,10 @@ func (s *Server) listPath(name string) (string, error) {
 		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "fuzzing", "adv-injection", "ssrf_hits.jsonl"), nil
 	case "adv_injection_smtp_hits":
 		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "fuzzing", "adv-injection", "smtp_hits.jsonl"), nil
+	case "oob_payloads":
+		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "fuzzing", "oob", "payloads.jsonl"), nil
+	case "oob_interactions":
+		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "fuzzing", "oob", "interactions.jsonl"), nil
 	case "csrf_candidates":
 		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "fuzzing", "csrf", "candidates.jsonl"), nil
 	case "csrf_findings":
@@ -1430,6 +1466,8 @@ func (s *Server) listPath(name string) (string, error) {
 		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "fuzzing", "nmap", "services.csv"), nil
 	case "nmap_searchsploit":
 		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "fuzzing", "nmap", "searchsploit.txt"), nil
+	case "nmap_web_services":
+		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "fuzzing", "nmap", "web_services.jsonl"), nil
 	case "nuclei_findings":
 		return filepath.Join(filepath.Dir(s.cfg.Lists.Domains), "fuzzing", "nuclei", "findings.jsonl"), nil
 	case "tier_isolation_ip_map":
@@ -2065,6 +2103,19 @@ func leadEvidence(row map[string]any) map[string]any {
 		"param",
 		"payload",
 		"vector",
+		"match",
+		"line",
+		"source",
+		"map_url",
+		"recovered",
+		"operation_id",
+		"security",
+		"engine",
+		"field",
+		"query",
+		"page",
+		"field_type",
+		"original_value",
 		"mutated_url",
 		"status_code",
 		"baseline_status_code",
@@ -2077,6 +2128,18 @@ func leadEvidence(row map[string]any) map[string]any {
 		"mutated_duration_ms",
 		"baseline_location",
 		"mutated_location",
+		"transforms",
+		"diff",
+		"timing",
+		"correlation_id",
+		"protocol",
+		"remote",
+		"sent_at",
+		"interaction",
+		"evidence_ids",
+		"session_profile",
+		"contexts",
+		"survived_chars",
 		"origin",
 		"referer",
 		"chain_signals",
@@ -2123,15 +2186,17 @@ func deriveSeverity(category string, row map[string]any) string {
 	reasons := strings.ToLower(strings.Join(asStringSlice(row["reasons"]), " "))
 	switch category {
 	case "server-input":
-		if strings.Contains(reasons, "family_keyword") || strings.Contains(reasons, "server_error_on_payload") {
+		if strings.Contains(reasons, "family_keyword") || strings.Contains(reasons, "family_regex") || strings.Contains(reasons, "server_error_on_payload") || strings.Contains(reasons, "time_based_confirmed") {
 			return "high"
 		}
 		return "medium"
 	case "injection", "adv-injection":
-		if strings.Contains(reasons, "family_keyword") || status >= 500 {
+		if strings.Contains(reasons, "family_keyword") || strings.Contains(reasons, "family_regex") || strings.Contains(reasons, "time_based_confirmed") || status >= 500 {
 			return "high"
 		}
 		return "medium"
+	case "oob":
+		return "high"
 	case "open-redirect":
 		chain := strings.Join(asStringSlice(row["chain_signals"]), ",")
 		if strings.TrimSpace(chain) != "" {
@@ -2173,26 +2238,41 @@ func computeLeadROI(category, severity string, reasons []string, row map[string]
 		"injection":     18,
 		"server-input":  24,
 		"adv-injection": 20,
+		"oob":           26,
 		"csrf":          16,
 		"clickjacking":  10,
 		"cors":          22,
 		"open-redirect": 22,
 		"xss":           20,
+		"secrets":       20,
+		"sourcemap":     8,
+		"authz":         22,
+		"graphql":       16,
+		"forms":         12,
 	}[category]
 	score := base + categoryBoost
 	reasonText := strings.ToLower(strings.Join(reasons, " "))
-	if strings.Contains(reasonText, "family_keyword") {
+	if strings.Contains(reasonText, "family_keyword") || strings.Contains(reasonText, "family_regex") {
 		score += 10
 	}
 	if strings.Contains(reasonText, "server_error_on_payload") {
 		score += 8
 	}
+	if strings.Contains(reasonText, "time_based_confirmed") {
+		score += 12
+	}
+	if strings.Contains(reasonText, "oob_interaction:") {
+		score += 12
+	}
 	if strings.Contains(reasonText, "cross_origin_request_accepted") || strings.Contains(reasonText, "arbitrary_origin_reflection") {
 		score += 12
 	}
 	if strings.Contains(reasonText, "credentials_allowed") {
 		score += 12
 	}
+	if strings.Contains(reasonText, "breakout:") || strings.Contains(reasonText, "javascript_url_possible:") {
+		score += 10
+	}
 	if len(asStringSlice(row["chain_signals"])) > 0 {
 		score += 20
 	}
@@ -0,0 +1,108 @@
+#!/usr/bin/env node
+import fs from "node:fs/promises";
+import path from "node:path";
+import { chromium } from "playwright";
+
+function parseArgs(argv) {
+  const out = {};
+  for (let i = 0; i < argv.length; i += 1) {
+    const key = argv[i];
+    if (!key.startsWith("--")) continue;
+    const val = argv[i + 1];
+    out[key.slice(2)] = val;
+    i += 1;
+  }
+  return out;
+}
+
+function fileNameFor(target) {
+  return String(target)
+    .replace(/^https?:\/\//i, (m) => m.toLowerCase().replace("://", "_"))
+    .replace(/[^a-zA-Z0-9._-]+/g, "_")
+    .slice(0, 180);
+}
+
+async function writeJSONL(filePath, rows) {
+  const content = rows.map((r) => JSON.stringify(r)).join("\n");
+  await fs.writeFile(filePath, content ? `${content}\n` : "", "utf8");
+}
+
+async function main() {
+  const args = parseArgs(process.argv.slice(2));
+  const targetsFile = String(args.targets || "").trim();
+  const outDir = String(args["out-dir"] || "").trim();
+  const concurrency = Math.max(1, Number.parseInt(args.concurrency || "4", 10) || 4);
+  const timeout = Math.max(3000, Number.parseInt(args.timeout || "20000", 10) || 20000);
+  const proxy = String(args.proxy || "").trim();
+  const ignoreHTTPSErrors = String(args["ignore-https-errors"] || "").trim() === "true";
+  const caSPKI = String(args["ca-spki"] || "").trim();
+  if (!targetsFile || !outDir) {
+    throw new Error("Usage: --targets <file> --out-dir <path> [--concurrency 4] [--timeout 20000] [--proxy http://host:port] [--ignore-https-errors true] [--ca-spki <base64,...>]");
+  }
+
+  const targets = (await fs.readFile(targetsFile, "utf8"))
+    .split("\n")
+    .map((line) => line.trim())
+    .filter((line) => /^https?:\/\//i.test(line));
+  const pngDir = path.join(outDir, "png");
+  await fs.mkdir(pngDir, { recursive: true });
+
+  const launchOpts = { headless: true };
+  if (proxy) launchOpts.proxy = { server: proxy };
+  // Chromium accepts chains containing one of these public keys, which is how a custom CA is trusted.
+  if (caSPKI) launchOpts.args = [`--ignore-certificate-errors-spki-list=${caSPKI}`];
+  const browser = await chromium.launch(launchOpts);
+  const rows = [];
+  let cursor = 0;
+
+  async function capture(target) {
+    const context = await browser.newContext({
+      viewport: { width: 1280, height: 800 },
+      ignoreHTTPSErrors,
+    });
+    const page = await context.newPage();
+    page.on("dialog", async (dialog) => {
+      try { await dialog.dismiss(); } catch {}
+    });
+    const row = { url: target, final_url: "", title: "", status: 0, file: "", error: "", at: new Date().toISOString() };
+    try {
+      const resp = await page.goto(target, { waitUntil: "load", timeout });
+      row.status = resp ? resp.status() : 0;
+      try { await page.waitForLoadState("networkidle", { timeout: 4000 }); } catch {}
+      row.final_url = page.url();
+      try { row.title = (await page.title()).trim(); } catch {}
+      const file = `${fileNameFor(target)}.png`;
+      await page.screenshot({ path: path.join(pngDir, file), timeout });
+      row.file = file;
+    } catch (err) {
+      row.final_url = row.final_url || page.url();
+      row.error = String(err?.message || err).split("\n")[0].slice(0, 300);
+    }
+    rows.push(row);
+    await context.close();
+  }
+
+  async function worker() {
+    while (cursor < targets.length) {
+      const next = targets[cursor];
+      cursor += 1;
+      await capture(next);
+    }
+  }
+
+  await Promise.all(Array.from({ length: Math.min(concurrency, targets.length) }, () => worker()));
+  await browser.close();
+
+  rows.sort((a, b) => a.url.localeCompare(b.url));
+  await writeJSONL(path.join(outDir, "captures.jsonl"), rows);
+  console.log(JSON.stringify({
+    targets: targets.length,
+    captured: rows.filter((r) => r.file).length,
+    failed: rows.filter((r) => !r.file).length,
+  }));
+}
+
+main().catch((err) => {
+  console.error(err?.stack || String(err));
+  process.exitCode = 1;
+});
@@ -41,6 +41,22 @@ async function writeJSONL(filePath, rows) {
   await fs.writeFile(filePath, content ? `${content}\n` : "", "utf8");
 }
 
+// reflected_hits.jsonl is shared with the Go param-fuzz step; keep its rows.
+async function readForeignRows(filePath, mode) {
+  try {
+    const raw = await fs.readFile(filePath, "utf8");
+    return raw.split("\n").filter((line) => {
+      try {
+        return JSON.parse(line).mode === mode;
+      } catch {
+        return false;
+      }
+    }).map((line) => JSON.parse(line));
+  } catch {
+    return [];
+  }
+}
+
 async function safePageContent(page) {
   try {
     return await page.content();
@@ -239,7 +255,8 @@ async function main() {
     } catch {}
   }
 
-  await writeJSONL(path.join(outDir, "reflected_hits.jsonl"), reflectedHits);
+  const reflectedPath = path.join(outDir, "reflected_hits.jsonl");
+  await writeJSONL(reflectedPath, [...(await readForeignRows(reflectedPath, "param-fuzz")), ...reflectedHits]);
   await writeJSONL(path.join(outDir, "dom_hits.jsonl"), domHits);
   await writeJSONL(path.join(outDir, "stored_hits.jsonl"), storedHits);
   await fs.writeFile(path.join(outDir, "visited_urls.txt"), `${[...visited].join("\n")}\n`, "utf8");
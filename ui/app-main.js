@@ -54,6 +54,17 @@ const cookieRows = document.getElementById("cookie-pairs");
 const cookieAddRow = document.getElementById("cookie-add-row");
 const cookieSave = document.getElementById("cookie-save");
 const cookieStatus = document.getElementById("cookie-status");
+const sessionProfiles = document.getElementById("session-profiles");
+const sessionAdd = document.getElementById("session-add");
+const sessionStatus = document.getElementById("session-status");
+const sessionFields = {
+  name: document.getElementById("session-name"),
+  hosts: document.getElementById("session-hosts"),
+  bearer: document.getElementById("session-bearer"),
+  checkURL: document.getElementById("session-check-url"),
+  markers: document.getElementById("session-markers"),
+  onLogout: document.getElementById("session-on-logout"),
+};
 const authEditor = document.getElementById("auth-editor");
 const authSave = document.getElementById("auth-save");
 const authStatus = document.getElementById("auth-status");
@@ -147,6 +158,10 @@ const cookieAuthFeature = initCookieAuthFeature({
   authSave,
   authStatus,
   authPreview,
+  sessionProfiles,
+  sessionAdd,
+  sessionStatus,
+  sessionFields,
 });
 const discoveryTablesFeature = initDiscoveryTablesFeature({
   escapeHTML,
@@ -22,7 +22,15 @@ export const FLOW_SEGMENTS = [
       { label: "Run robots.txt and sitemap discovery in main flow.", stepId: "robots-sitemaps", implemented: true },
       { label: "Integrate waybackurls into active flow.", stepId: "waybackurls", implemented: true },
       { label: "Integrate katana crawling into active flow.", stepId: "katana", implemented: true },
-      { label: "Consolidate URL corpus from all sources.", stepId: "url-corpus", implemented: true },
+      { label: "Consolidate URL corpus from all sources and index source, first/last seen and live status per URL.", stepId: "url-corpus", implemented: true },
+      { label: "Mine JavaScript assets for endpoints, params, hosts, buckets and secrets.", stepId: "js-intel", implemented: true },
+      { label: "Recover original sources from exposed JS source maps and mine them.", stepId: "source-maps", implemented: true },
+      { label: "Cluster URLs into path templates and sample representatives for checks.", stepId: "url-templates", implemented: true },
+      { label: "Segment live hosts into auth/admin/uploads/static/cdn/api roles.", stepId: "host-roles", implemented: true },
+      { label: "Cluster look-alike live hosts by body simhash, favicon hash and header fingerprint.", stepId: "host-clusters", implemented: true },
+      { label: "Detect WAF/CDN vendors per host and throttle protected hosts.", stepId: "waf-detect", implemented: true },
+      { label: "Score per-host technologies (version, confidence, evidence) and match CVEs.", stepId: "tech-profile", implemented: true },
+      { label: "Screenshot live web servers headless and cluster look-alike pages.", stepId: "screenshots", implemented: true },
       { label: "Auto-generate dork links for org/wildcard/domain/api-domain seeds.", stepId: "dork-links", implemented: true },
     ],
   },
@@ -32,10 +40,14 @@ export const FLOW_SEGMENTS = [
       { label: "Generate custom CeWL wordlist from live web servers.", stepId: "cewl", implemented: true },
       { label: "Run ffuf documentation endpoint fuzzing.", stepId: "fuzz-docs", implemented: true },
       { label: "Run ffuf directory/API path fuzzing.", stepId: "fuzz-dirs", implemented: true },
-      { label: "Fuzz query/body/header/cookie parameters.", stepId: "param-fuzz", implemented: true },
+      { label: "Expand OpenAPI/Swagger/Postman specs into concrete requests and check declared auth.", stepId: "api-specs", implemented: true },
+      { label: "Discover GraphQL endpoints, recover the schema and check batching, depth, GET-CSRF and field authz.", stepId: "graphql", implemented: true },
+      { label: "Harvest HTML forms and replay hidden/readonly/disabled and maxlength/pattern/range tampering.", stepId: "form-tamper", implemented: true },
+      { label: "Fuzz query/body/header/cookie parameters and track canary reflections by context.", stepId: "param-fuzz", implemented: true },
       { label: "Automate SQLi/NoSQL/XPath/LDAP checks.", stepId: "injection-checks", implemented: true },
       { label: "Automate OS command/path traversal/file inclusion checks.", stepId: "server-input-checks", implemented: true },
       { label: "Automate XXE/SOAP/SSRF/SMTP injection checks.", stepId: "adv-injection-checks", implemented: true },
+      { label: "Catch blind SSRF/XXE/command injection callbacks on the built-in OOB listener.", stepId: "adv-injection-checks", implemented: true },
     ],
   },
   {
@@ -11,27 +11,49 @@ export const FILE_EXPLANATIONS = {
   ips: "IP addresses found during recon (for infrastructure profiling and segmentation checks).",
   out_of_scope: "Explicitly excluded targets; flow should avoid testing these hosts/domains.",
   robots_urls: "URLs extracted from robots.txt/sitemap paths; often reveals hidden or low-linked endpoints.",
+  robots_rules: "Per-host robots.txt parsed into user-agent groups with Allow/Disallow rules, crawl-delay and sitemaps.",
+  sitemap_urls: "Sitemap URLs (indexes and .xml.gz followed) with lastmod, first-seen date and a flag for pages new since the last run.",
   wayback_urls: "Historical URLs from web archives; useful for old endpoints and forgotten functionality.",
   katana_urls: "Crawler-discovered URLs from active crawling against live targets.",
   all_urls: "Merged URL corpus from multiple discovery sources; baseline input for later fuzzing stages.",
+  url_corpus: "Per-URL index of the corpus: sources that found it, first/last seen run, last httpx status and content type, and parameter names. Filter with url_corpus in flow.yaml.",
+  js_intel: "Per-script JavaScript analysis: endpoints, GraphQL operations, params, hosts, cloud buckets, secret kinds and source maps.",
+  js_endpoints: "In-scope endpoints extracted from JavaScript assets; merged into the URL corpus.",
+  js_secrets: "Hard-coded keys and tokens found in JavaScript assets (redacted); surfaced as leads.",
+  sourcemaps: "JS bundles that expose source maps, with recovered file counts and what the recovered sources revealed (tree under recon/sourcemaps/<host>/).",
+  url_templates: "URL path templates ({int}, {uuid}, {hash}, {date}, {locale}) with member counts and the representatives check modules test.",
+  host_roles: "Per-host role classification (auth/admin/uploads/static/cdn/api) with the signals behind each role.",
+  host_clusters: "Look-alike host clusters (body simhash, favicon hash, header fingerprint); only representatives are actively tested by default.",
+  waf_state: "Per-host WAF/CDN state from headers, cookies, block pages and a benign attack probe; protected hosts are fuzzed at a lower rate.",
+  tech_profile: "Per-host technologies with version, confidence score, supporting evidence and matched CVEs; drives nuclei tags and payload families.",
   params_candidates: "Likely parameter names collected for parameter fuzzing and replay-based behavior checks.",
   fuzzing_doc_hits: "Potential documentation endpoints found via ffuf (docs, swagger, openapi, api-reference paths).",
+  api_specs: "Concrete requests expanded from OpenAPI 2/3 and Postman specs (fetched from doc hits or uploaded here as .json/.yaml) with method, params and example values.",
+  request_corpus: "Structured requests imported from HAR files and Burp XML history (method, headers, cookies, body); replayed by param fuzzing and injection checks.",
+  session_events: "Session profile events: when an authenticated session was detected as logged out and when it was restored; leads found in between are flagged session_expired.",
+  api_spec_authz: "Spec operations declared as secured that answered 2xx without credentials.",
+  graphql_endpoints: "Confirmed GraphQL endpoints with engine fingerprint, introspection or suggestion-recovered root fields, and batching/alias/GET support.",
+  graphql_findings: "GraphQL findings: introspection, field suggestions, batching/alias abuse, missing depth limit, GET/form CSRF and anonymous access to sensitive fields.",
+  form_inventory: "HTML forms harvested from corpus pages and live roots: action, method, enctype and every field with hidden/disabled/readonly/maxlength/pattern constraints.",
+  form_findings: "Form submissions whose response changed when a hidden or readonly value was tampered, a disabled field re-enabled, or a client-side constraint broken.",
   fuzzing_dir_hits: "Potential interesting directories/API paths found via ffuf brute-force wordlists.",
-  param_fuzz_query_hits: "Query parameter fuzz hits. We look for response changes that suggest hidden logic or unsafe parameter handling.",
+  param_fuzz_query_hits: "Query parameter fuzz hits: responses that depart from several baseline samples in status, length, body structure, headers or timing, with the diff explained per hit.",
   param_fuzz_body_hits: "Body parameter fuzz hits. We look for behavior deltas when adding/changing body fields.",
   param_fuzz_header_hits: "Header fuzz hits. We look for trust/misuse of attacker-controlled headers.",
   param_fuzz_cookie_hits: "Cookie fuzz hits. We look for state/control issues from manipulated cookie values.",
-  injection_sqli_hits: "SQL Injection candidates. We inject SQL-like payloads and hunt for DB errors or response anomalies.",
-  injection_nosqli_hits: "NoSQL Injection candidates. We inject operator-style payloads and watch for auth/query logic shifts.",
+  injection_sqli_hits: "SQL Injection candidates. We inject SQL-like payloads and hunt for DB errors or response anomalies; sleep payloads only count once repeated paired timings confirm the delay.",
+  injection_nosqli_hits: "NoSQL Injection candidates. We inject operator-style payloads and watch for auth/query logic shifts, plus $where sleep() timing confirmed over paired samples.",
   injection_xpath_hits: "XPath Injection candidates. We test if XML/XPath queries can be altered by crafted input.",
   injection_ldap_hits: "LDAP Injection candidates. We test if LDAP filters are manipulable via user-controlled input.",
-  server_input_os_command_hits: "OS Command Injection candidates. We test whether input reaches shell/system command execution.",
+  server_input_os_command_hits: "OS Command Injection candidates. We test whether input reaches shell/system command execution, including blind sleep payloads confirmed by paired timing.",
   server_input_path_traversal_hits: "Path Traversal candidates. We test if file path input can escape intended directories.",
   server_input_file_inclusion_hits: "File Inclusion candidates. We test for unsafe local/remote file loading behavior.",
   adv_injection_xxe_hits: "XXE candidates. We test XML parsing paths for external entity processing and file/SSRF access.",
   adv_injection_soap_hits: "SOAP injection candidates. We test SOAP/XML operations for unsafe parser/business logic handling.",
   adv_injection_ssrf_hits: "SSRF candidates. We test whether server-side requests can be redirected to attacker-chosen hosts.",
   adv_injection_smtp_hits: "SMTP injection candidates. We test mail-related fields for header/protocol injection patterns.",
+  oob_payloads: "Blind payloads sent with an out-of-band correlation ID (SSRF, XXE, command injection, remote inclusion), with the endpoint and parameter each one went to.",
+  oob_interactions: "HTTP/DNS callbacks received by the built-in OOB listener, each tied back to the exact endpoint, parameter and payload that triggered it.",
   csrf_candidates: "State-changing endpoints that may require CSRF protection and were selected for replay checks.",
   csrf_findings: "CSRF risk findings. We look for cross-origin state changes accepted without robust anti-CSRF controls.",
   csrf_replay_log: "Request replay details for CSRF testing (baseline vs forged-origin/cookie replay behavior).",
@@ -50,6 +72,7 @@ export const FILE_EXPLANATIONS = {
   nmap_targets: "Hosts selected for Nmap service fingerprinting.",
   nmap_services: "Discovered open services/ports from Nmap; useful for exposed service triage.",
   nmap_searchsploit: "Searchsploit correlation output mapped from detected service fingerprints.",
+  nmap_web_services: "Live web services Nmap found on non-default ports (admin consoles, dev servers); merged into domains_http.",
   nuclei_findings: "Template-based vulnerability matches from nuclei (heuristic findings; manual verification required).",
   tier_isolation_ip_map: "Domain-to-IP mapping used to detect shared hosting and weak environment isolation.",
   tier_isolation_findings: "Potential segmentation/isolation issues where sensitive and public assets overlap on infra.",
@@ -58,7 +81,7 @@ export const FILE_EXPLANATIONS = {
   static_review_correlated: "Static findings correlated to discovered live endpoints for higher-priority review.",
   runops_scorecard_json: "Run completion scorecard in JSON format for pipeline health and stage coverage.",
   runops_scorecard_md: "Run completion scorecard in Markdown format for quick human review.",
-  xss_reflected_hits: "Reflected XSS candidates where injected input appears in immediate responses in dangerous contexts.",
+  xss_reflected_hits: "Reflected XSS candidates: where each param-fuzz canary comes back (HTML text, attribute, script, JSON, header), which special characters survive unencoded there, and a context-fit payload to confirm. Playwright scan reflections are kept alongside.",
   xss_dom_hits: "DOM XSS candidates where client-side scripts may use untrusted data in unsafe sinks.",
   xss_stored_hits: "Stored XSS candidates where payloads may persist and execute for later viewers.",
   xss_scan_log: "Raw log output from Playwright-assisted XSS scan runs.",
@@ -76,10 +99,25 @@ export const LIST_FILES = [
   { type: "organizations", label: "Organizations", uploadable: true },
   { type: "ips", label: "IPs", uploadable: true },
   { type: "out_of_scope", label: "Out of scope", uploadable: true },
+  { type: "api_specs", label: "API Specs", uploadable: true, accept: ".json,.yaml,.yml" },
+  { type: "request_corpus", label: "Request Corpus (HAR/Burp)", uploadable: true, accept: ".har,.xml,.json" },
   { type: "robots_urls", label: "Robots URLs", uploadable: false },
+  { type: "robots_rules", label: "Robots Rules", uploadable: false },
+  { type: "sitemap_urls", label: "Sitemap URLs", uploadable: false },
   { type: "wayback_urls", label: "Wayback URLs", uploadable: false },
   { type: "katana_urls", label: "Katana URLs", uploadable: false },
   { type: "all_urls", label: "All URLs", uploadable: false },
+  { type: "url_corpus", label: "URL Corpus Index", uploadable: false },
+  { type: "session_events", label: "Session Events", uploadable: false },
+  { type: "js_intel", label: "JS Intel", uploadable: false },
+  { type: "js_endpoints", label: "JS Endpoints", uploadable: false },
+  { type: "js_secrets", label: "JS Secrets", uploadable: false },
+  { type: "sourcemaps", label: "Source Maps", uploadable: false },
+  { type: "url_templates", label: "URL Templates", uploadable: false },
+  { type: "host_roles", label: "Host Roles", uploadable: false },
+  { type: "host_clusters", label: "Host Clusters", uploadable: false },
+  { type: "waf_state", label: "WAF State", uploadable: false },
+  { type: "tech_profile", label: "Tech Profile", uploadable: false },
   { type: "params_candidates", label: "Param Candidates", uploadable: false },
   { type: "param_fuzz_query_hits", label: "Param Fuzz Query Hits", uploadable: false },
   { type: "param_fuzz_body_hits", label: "Param Fuzz Body Hits", uploadable: false },
@@ -96,6 +134,8 @@ export const LIST_FILES = [
   { type: "adv_injection_soap_hits", label: "Advanced Injection SOAP Hits", uploadable: false },
   { type: "adv_injection_ssrf_hits", label: "Advanced Injection SSRF Hits", uploadable: false },
   { type: "adv_injection_smtp_hits", label: "Advanced Injection SMTP Hits", uploadable: false },
+  { type: "oob_payloads", label: "OOB Payloads Sent", uploadable: false },
+  { type: "oob_interactions", label: "OOB Interactions", uploadable: false },
   { type: "csrf_candidates", label: "CSRF Candidates", uploadable: false },
   { type: "csrf_findings", label: "CSRF Findings", uploadable: false },
   { type: "csrf_replay_log", label: "CSRF Replay Log", uploadable: false },
@@ -114,6 +154,7 @@ export const LIST_FILES = [
   { type: "nmap_targets", label: "Nmap Targets", uploadable: false },
   { type: "nmap_services", label: "Nmap Services", uploadable: false },
   { type: "nmap_searchsploit", label: "Nmap Searchsploit Correlation", uploadable: false },
+  { type: "nmap_web_services", label: "Nmap Web Services", uploadable: false },
   { type: "nuclei_findings", label: "Nuclei Findings", uploadable: false },
   { type: "tier_isolation_ip_map", label: "Tier Isolation IP Map", uploadable: false },
   { type: "tier_isolation_findings", label: "Tier Isolation Findings", uploadable: false },
@@ -127,6 +168,11 @@ export const LIST_FILES = [
   { type: "xss_stored_hits", label: "XSS Stored Hits", uploadable: false },
   { type: "xss_scan_log", label: "XSS Scan Log", uploadable: false },
   { type: "fuzzing_doc_hits", label: "Fuzzing Doc Hits", uploadable: false },
+  { type: "api_spec_authz", label: "API Spec Authz Findings", uploadable: false },
+  { type: "graphql_endpoints", label: "GraphQL Endpoints", uploadable: false },
+  { type: "graphql_findings", label: "GraphQL Findings", uploadable: false },
+  { type: "form_inventory", label: "Form Inventory", uploadable: false },
+  { type: "form_findings", label: "Form Tampering Findings", uploadable: false },
   { type: "fuzzing_dir_hits", label: "Fuzzing Dir Hits", uploadable: false },
 ];
 
@@ -139,16 +185,34 @@ export const GENERATED_FILE_GROUPS = [
       "apidomains_http",
       "apidomains_dead",
       "robots_urls",
+      "robots_rules",
+      "sitemap_urls",
       "wayback_urls",
       "katana_urls",
       "all_urls",
+      "url_corpus",
+      "js_intel",
+      "js_endpoints",
+      "js_secrets",
+      "sourcemaps",
+      "url_templates",
+      "host_roles",
+      "host_clusters",
+      "waf_state",
+      "tech_profile",
       "params_candidates",
+      "session_events",
     ],
   },
   {
     title: "Input & Injection Fuzzing",
     types: [
       "fuzzing_doc_hits",
+      "api_spec_authz",
+      "graphql_endpoints",
+      "graphql_findings",
+      "form_inventory",
+      "form_findings",
       "fuzzing_dir_hits",
       "param_fuzz_query_hits",
       "param_fuzz_body_hits",
@@ -165,6 +229,8 @@ export const GENERATED_FILE_GROUPS = [
       "adv_injection_soap_hits",
       "adv_injection_ssrf_hits",
       "adv_injection_smtp_hits",
+      "oob_payloads",
+      "oob_interactions",
     ],
   },
   {
@@ -197,6 +263,7 @@ export const GENERATED_FILE_GROUPS = [
       "nmap_targets",
       "nmap_services",
       "nmap_searchsploit",
+      "nmap_web_services",
       "nuclei_findings",
       "tier_isolation_ip_map",
       "tier_isolation_findings",
@@ -11,6 +11,10 @@ export function initCookieAuthFeature({
   authSave,
   authStatus,
   authPreview,
+  sessionProfiles,
+  sessionAdd,
+  sessionStatus,
+  sessionFields = {},
 }) {
   let cookiePairs = [];
   let authAutosaveTimer = null;
@@ -86,6 +90,72 @@ export function initCookieAuthFeature({
     }
   }
 
+  function setSessionStatus(text) {
+    if (sessionStatus) {
+      sessionStatus.textContent = text;
+    }
+  }
+
+  function splitList(raw) {
+    return String(raw || "")
+      .split(",")
+      .map((part) => part.trim())
+      .filter(Boolean);
+  }
+
+  async function sendSessionKey(method, path, body) {
+    const response = await fetch(`${backendUrl}/api/config/providers/sessions/keys${path}`, {
+      method,
+      headers: { "Content-Type": "application/json" },
+      body: body ? JSON.stringify(body) : undefined,
+    });
+    if (!response.ok) {
+      throw new Error(await response.text());
+    }
+  }
+
+  function renderSessionProfiles(keys) {
+    if (!sessionProfiles) {
+      return;
+    }
+    if (!keys.length) {
+      sessionProfiles.innerHTML = '<p class="muted">No session profiles yet. Checks run unauthenticated.</p>';
+      return;
+    }
+    sessionProfiles.innerHTML = keys.map((key) => `
+      <div class="config-row" data-session-id="${escapeHTML(key.id || "")}">
+        <input type="text" name="session_label" class="config-label" value="${escapeHTML(key.label || "")}" />
+        <textarea name="session_value" class="config-value" rows="4">${escapeHTML(key.value || "")}</textarea>
+        <label class="inline">
+          <input type="checkbox" name="session_active" class="config-active" ${key.active ? "checked" : ""} />
+          Active
+        </label>
+        <div class="config-actions">
+          <button class="config-save" type="button">Save</button>
+          <button class="config-delete" type="button">Remove</button>
+        </div>
+        ${key.last_error ? `<p class="muted">${escapeHTML(key.last_error)} (${escapeHTML(key.last_used || "")})</p>` : ""}
+      </div>
+    `).join("");
+  }
+
+  async function loadSessionProfiles() {
+    if (!sessionProfiles) {
+      return;
+    }
+    try {
+      const response = await fetch(`${backendUrl}/api/config/providers/sessions`);
+      if (!response.ok) {
+        throw new Error(await response.text());
+      }
+      const data = await response.json();
+      renderSessionProfiles(Array.isArray(data.keys) ? data.keys : []);
+      setSessionStatus("");
+    } catch (error) {
+      setSessionStatus(`Load failed: ${error.message}`);
+    }
+  }
+
   function setAuthStatus(text) {
     if (authStatus) {
       authStatus.textContent = text;
@@ -225,6 +295,59 @@ export function initCookieAuthFeature({
     await saveAuthDoc();
   });
 
+  sessionAdd?.addEventListener("click", async () => {
+    const label = String(sessionFields.name?.value || "").trim();
+    if (!label) {
+      setSessionStatus("Profile name is required.");
+      return;
+    }
+    const profile = {
+      cookie: cookiePairs
+        .filter((pair) => String(pair?.key || "").trim())
+        .map((pair) => `${String(pair.key).trim()}=${String(pair.value || "").trim()}`)
+        .join("; "),
+      bearer: String(sessionFields.bearer?.value || "").trim(),
+      hosts: splitList(sessionFields.hosts?.value),
+      check_url: String(sessionFields.checkURL?.value || "").trim(),
+      logged_out_markers: splitList(sessionFields.markers?.value),
+      on_logout: String(sessionFields.onLogout?.value || "flag"),
+    };
+    try {
+      await sendSessionKey("POST", "", { label, value: JSON.stringify(profile), active: true });
+      if (sessionFields.bearer) {
+        sessionFields.bearer.value = "";
+      }
+      await loadSessionProfiles();
+      setSessionStatus(`Saved profile ${label}`);
+    } catch (error) {
+      setSessionStatus(`Save failed: ${error.message}`);
+    }
+  });
+
+  sessionProfiles?.addEventListener("click", async (event) => {
+    const row = event.target.closest("[data-session-id]");
+    if (!row) {
+      return;
+    }
+    const id = encodeURIComponent(row.dataset.sessionId);
+    try {
+      if (event.target.classList.contains("config-delete")) {
+        await sendSessionKey("DELETE", `/${id}`);
+      } else if (event.target.classList.contains("config-save")) {
+        await sendSessionKey("PUT", `/${id}`, {
+          label: row.querySelector(".config-label").value.trim(),
+          value: row.querySelector(".config-value").value.trim(),
+          active: row.querySelector(".config-active").checked,
+        });
+      } else {
+        return;
+      }
+      await loadSessionProfiles();
+    } catch (error) {
+      setSessionStatus(`Update failed: ${error.message}`);
+    }
+  });
+
   authEditor?.addEventListener("input", () => {
     setAuthStatus("Unsaved changes...");
     refreshAuthPreview();
@@ -238,6 +361,7 @@ export function initCookieAuthFeature({
       }
       void loadCookieDoc();
       void loadAuthDoc();
+      void loadSessionProfiles();
     },
   };
 }
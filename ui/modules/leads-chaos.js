@@ -59,6 +59,7 @@ export function initLeadsChaosFeature({
       "url",
       "param",
       "payload",
+      "transforms",
       "vector",
       "mutated_url",
       "status_code",
@@ -75,6 +76,11 @@ export function initLeadsChaosFeature({
       "origin",
       "referer",
       "chain_signals",
+      "correlation_id",
+      "protocol",
+      "remote",
+      "sent_at",
+      "evidence_ids",
       "matcher-name",
       "template-id",
       "template",
@@ -139,6 +145,9 @@ export function initLeadsChaosFeature({
                     ? `<a href="${escapeHTML(lead.target || "")}" target="_blank" rel="noopener noreferrer"><code>${escapeHTML(lead.target || "")}</code></a>`
                     : `<code>${escapeHTML(lead.target || "")}</code>`
                 }</div>
+                ${Array.isArray(lead.sibling_hosts) && lead.sibling_hosts.length
+                  ? `<p class="muted">Look-alike siblings: ${lead.sibling_hosts.map((host) => `<code>${escapeHTML(host)}</code>`).join(" ")}</p>`
+                  : ""}
                 <div class="lead-item__actions">
                   <label class="lead-done-toggle">
                     <input type="checkbox" name="lead_done_${escapeHTML(lead.id || "")}" data-lead-action="done" data-lead-id="${escapeHTML(lead.id || "")}" ${lead.done ? "checked" : ""} />
@@ -146,13 +155,21 @@ export function initLeadsChaosFeature({
                   </label>
                   <button type="button" class="lead-options-button" data-lead-action="toggle-menu" data-lead-id="${escapeHTML(lead.id || "")}">Options</button>
                   <button type="button" class="lead-replay-button" data-lead-action="replay" data-lead-id="${escapeHTML(lead.id || "")}">Replay</button>
+                  ${Array.isArray(lead.evidence?.evidence_ids) && lead.evidence.evidence_ids.length
+                    ? `<button type="button" class="lead-replay-button" data-lead-action="evidence" data-lead-id="${escapeHTML(lead.id || "")}" data-evidence-ids="${escapeHTML(lead.evidence.evidence_ids.join(","))}">Evidence</button>`
+                    : ""}
                 </div>
+                <div class="lead-item__exchange" data-lead-exchange="${escapeHTML(lead.id || "")}" hidden></div>
                 <div class="lead-item__menu" data-lead-menu="${escapeHTML(lead.id || "")}" hidden>
                   <button type="button" data-lead-action="bucket" data-bucket="hits" data-lead-id="${escapeHTML(lead.id || "")}">Hits</button>
                   <button type="button" data-lead-action="bucket" data-bucket="investigation" data-lead-id="${escapeHTML(lead.id || "")}">Further Investigation</button>
                   <button type="button" data-lead-action="bucket" data-bucket="archive" data-lead-id="${escapeHTML(lead.id || "")}">Archive</button>
                   <button type="button" data-lead-action="bucket" data-bucket="active" data-lead-id="${escapeHTML(lead.id || "")}">Reset to Leads</button>
                   <button type="button" data-lead-action="delete" data-lead-id="${escapeHTML(lead.id || "")}">Delete</button>
+                  <button type="button" data-lead-action="export" data-format="curl" data-lead-id="${escapeHTML(lead.id || "")}">Export curl</button>
+                  <button type="button" data-lead-action="export" data-format="raw" data-lead-id="${escapeHTML(lead.id || "")}">Export raw HTTP</button>
+                  <button type="button" data-lead-action="export" data-format="python" data-lead-id="${escapeHTML(lead.id || "")}">Export Python</button>
+                  <button type="button" data-lead-action="export" data-format="burp" data-lead-id="${escapeHTML(lead.id || "")}">Export Burp XML</button>
                 </div>
                 ${(() => {
                   const entries = sortedLeadEvidenceEntries(lead.evidence);
@@ -358,6 +375,38 @@ export function initLeadsChaosFeature({
     return response.json();
   }
 
+  async function fetchEvidence(id) {
+    const response = await fetch(`${backendUrl}/api/evidence/${encodeURIComponent(id)}`);
+    if (!response.ok) {
+      throw new Error(await response.text());
+    }
+    return response.json();
+  }
+
+  // The first id is the baseline exchange and the last the mutated one; a single id is
+  // shown on its own.
+  async function showLeadEvidence(leadId, ids) {
+    const view = leadsWildcards.querySelector(`[data-lead-exchange="${CSS.escape(leadId)}"]`);
+    if (!view) {
+      return;
+    }
+    if (!view.hidden) {
+      view.hidden = true;
+      return;
+    }
+    const picked = ids.length > 1 ? [ids[0], ids[ids.length - 1]] : ids;
+    const items = await Promise.all(picked.map((id) => fetchEvidence(id)));
+    const labels = items.length > 1 ? ["Baseline", "Mutated"] : ["Exchange"];
+    view.innerHTML = items.map((item, index) => `
+      <div class="lead-item__exchange-pane">
+        <span class="lead-item__evidence-key">${labels[index]}${item.truncated ? " (truncated)" : ""}</span>
+        <pre>${escapeHTML(item.request || "")}</pre>
+        <pre>${escapeHTML(item.response || "")}</pre>
+      </div>
+    `).join("");
+    view.hidden = false;
+  }
+
   leadsWildcards?.addEventListener("click", async (event) => {
     const button = event.target.closest("button[data-lead-action]");
     if (!button) {
@@ -377,6 +426,11 @@ export function initLeadsChaosFeature({
       }
       return;
     }
+    if (action === "export") {
+      const format = encodeURIComponent(button.dataset.format || "curl");
+      window.open(`${backendUrl}/api/leads/${encodeURIComponent(leadId)}/export?format=${format}`, "_blank", "noopener");
+      return;
+    }
     try {
       if (action === "bucket") {
         await updateLeadState({ id: leadId, bucket: button.dataset.bucket || "" });
@@ -397,6 +451,11 @@ export function initLeadsChaosFeature({
         leadsStatus.textContent = `Replay ${statusCode} ${url}${proxied}`;
         return;
       }
+      if (action === "evidence") {
+        const ids = String(button.dataset.evidenceIds || "").split(",").filter(Boolean);
+        await showLeadEvidence(leadId, ids);
+        return;
+      }
     } catch (error) {
       leadsStatus.textContent = `Lead action failed: ${error.message}`;
     } finally {
@@ -410,14 +410,14 @@ export function initScopeFilesFeature({
       return;
     }
 
-    const renderCard = ({ type, label, uploadable }) => {
+    const renderCard = ({ type, label, uploadable, accept }) => {
       const inputId = `scope-upload-${type}`;
 
       return `
         <article class="scope-card" data-type="${escapeHTML(type)}">
           <h3 class="scope-card__name"><button type="button" class="scope-card__open" data-type="${escapeHTML(type)}" data-label="${escapeHTML(label)}" data-base-label="${escapeHTML(label)}">${escapeHTML(label)} (0)</button></h3>
           <span class="scope-card__status scope-card__status--missing">Missing</span>
-          ${uploadable ? `<input id="${inputId}" name="${inputId}" type="file" accept=".txt,.csv" />` : ""}
+          ${uploadable ? `<input id="${inputId}" name="${inputId}" type="file" accept="${escapeHTML(accept || ".txt,.csv")}" />` : ""}
           ${uploadable ? `<button type="button" class="scope-card__upload" data-input-id="${inputId}">Upload</button>` : '<p class="muted">Auto-generated by flow.</p>'}
         </article>
       `;